@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 	"time"
@@ -18,6 +19,8 @@ type Anthropic struct {
 	httpClient        httpClient
 	apiKey, modelName string
 	baseURL           string
+	headers           map[string]string // extra request headers
+	extraBody         map[string]any    // extra request body fields
 }
 
 var _ Provider = (*Anthropic)(nil) // ensure the interface is implemented
@@ -26,6 +29,8 @@ type (
 	AnthropicOptions struct {
 		HttpClient httpClient
 		BaseURL    string
+		Headers    map[string]string
+		ExtraBody  map[string]any
 	}
 
 	// AnthropicOption allows to customize the Anthropic provider.
@@ -43,6 +48,17 @@ func WithAnthropicBaseURL(url string) AnthropicOption {
 	return func(o *AnthropicOptions) { o.BaseURL = url }
 }
 
+// WithAnthropicHeaders sets the extra HTTP headers sent with each request (e.g. for proxies or API gateways).
+func WithAnthropicHeaders(h map[string]string) AnthropicOption {
+	return func(o *AnthropicOptions) { o.Headers = h }
+}
+
+// WithAnthropicExtraBody sets the extra fields merged into the request body (the existing fields are overridden,
+// and the fields with nil values are removed).
+func WithAnthropicExtraBody(b map[string]any) AnthropicOption {
+	return func(o *AnthropicOptions) { o.ExtraBody = b }
+}
+
 // NewAnthropic creates a new Anthropic provider.
 func NewAnthropic(apiKey, model string, opt ...AnthropicOption) *Anthropic { //nolint:dupl
 	var opts AnthropicOptions
@@ -55,6 +71,8 @@ func NewAnthropic(apiKey, model string, opt ...AnthropicOption) *Anthropic { //n
 		httpClient: opts.HttpClient,
 		apiKey:     apiKey,
 		modelName:  model,
+		headers:    opts.Headers,
+		extraBody:  opts.ExtraBody,
 	}
 
 	if p.httpClient == nil { // set default HTTP client
@@ -76,13 +94,12 @@ func (p *Anthropic) Query(
 	changes, commits string,
 	opts ...Option,
 ) (*Response, error) {
-	var (
-		opt          = options{}.Apply(opts...)
-		instructions = GeneratePrompt(opts...)
-	)
+	var opt, instructions = requestOptions(opts...)
 
-	if opt.MaxOutputTokens == 0 {
-		opt.MaxOutputTokens = defaultMaxOutputTokens // set default value
+	if opt.Candidates > 1 { // the API does not support multiple candidates natively
+		return queryInParallel(ctx, opt.Candidates, func(ctx context.Context) (*Response, error) {
+			return p.Query(ctx, changes, commits, append(opts[:len(opts):len(opts)], withSingleCandidate())...)
+		})
 	}
 
 	req, rErr := p.newRequest(ctx, instructions, changes, commits, opt)
@@ -101,22 +118,42 @@ func (p *Anthropic) Query(
 		return nil, p.responseToError(resp)
 	}
 
-	answer, aErr := p.parseResponse(resp)
+	var (
+		answers []string
+		usage   Usage
+		aErr    error
+	)
+
+	if opt.Stream != nil {
+		var answer string
+
+		answer, usage, aErr = p.parseStream(resp, opt.Stream)
+		answers = []string{answer}
+	} else {
+		answers, usage, aErr = p.parseResponse(resp)
+	}
+
 	if aErr != nil {
 		return nil, aErr
 	}
 
-	if opt.ShortMessageOnly {
-		var parts = strings.Split(answer, "\n")
+	var response = newResponse(instructions, answers, opt)
 
-		if len(parts) == 0 {
-			return nil, errors.New("no response from the Anthropic API")
-		}
+	response.Model, response.Usage = p.modelName, usage
+
+	return response, nil
+}
 
-		return &Response{Prompt: instructions, Answer: parts[0]}, nil
+// Request renders the HTTP request that [Anthropic.Query] would send, without sending it. When several candidates
+// are requested, such a request is sent for each of them.
+func (p *Anthropic) Request(ctx context.Context, changes, commits string, opts ...Option) (*http.Request, error) {
+	if (options{}).Apply(opts...).Candidates > 1 {
+		opts = append(opts[:len(opts):len(opts)], withSingleCandidate())
 	}
 
-	return &Response{Prompt: instructions, Answer: answer}, nil
+	var opt, instructions = requestOptions(opts...)
+
+	return p.newRequest(ctx, instructions, changes, commits, opt)
 }
 
 // newRequest creates a new HTTP request for the Anthropic API.
@@ -141,10 +178,10 @@ func (p *Anthropic) newRequest(
 		System      string    `json:"system"`
 	}{
 		Model:       p.modelName,
-		Stream:      false,
+		Stream:      o.Stream != nil,
 		System:      instructions,
-		Temperature: 0.1, //nolint:mnd
-		TopP:        0.1, //nolint:mnd
+		Temperature: o.temperature(),
+		TopP:        o.topP(),
 		MaxTokens:   o.MaxOutputTokens,
 		Messages: []message{
 			{Role: roleUser, Content: wrapChanges(changes)},
@@ -155,6 +192,10 @@ func (p *Anthropic) newRequest(
 		return nil, jErr
 	}
 
+	if j, jErr = mergeExtraBody(j, p.extraBody); jErr != nil {
+		return nil, jErr
+	}
+
 	base := anthropicDefaultBaseURL
 	if p.baseURL != "" {
 		base = p.baseURL
@@ -172,6 +213,7 @@ func (p *Anthropic) newRequest(
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("x-api-key", p.apiKey)
 	req.Header.Set("anthropic-version", "2023-06-01") // https://docs.anthropic.com/en/api/versioning
+	setExtraHeaders(req, p.headers)
 
 	return req, nil
 }
@@ -209,28 +251,33 @@ func (p *Anthropic) responseToError(resp *http.Response) error {
 			resp.StatusCode, http.StatusText(resp.StatusCode))
 	}
 
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return newAuthError(err)
+	}
+
 	if retryable {
-		return newRetryableError(err)
+		return newRetryableError(err, retryAfterHeader(resp.Header))
 	}
 
 	return err
 }
 
 // parseResponse parses the response from the Anthropic API.
-func (p *Anthropic) parseResponse(resp *http.Response) (string, error) {
+func (p *Anthropic) parseResponse(resp *http.Response) ([]string, Usage, error) {
 	var answer struct {
 		Content []struct {
 			Type string `json:"type"`
 			Text string `json:"text"`
 		} `json:"content"`
+		Usage anthropicUsage `json:"usage"`
 	}
 
 	if dErr := json.NewDecoder(resp.Body).Decode(&answer); dErr != nil {
-		return "", dErr
+		return nil, Usage{}, dErr
 	}
 
 	if len(answer.Content) == 0 {
-		return "", errors.New("no response from the Anthropic API")
+		return nil, Usage{}, errors.New("no response from the Anthropic API")
 	}
 
 	var texts = make([]string, 0, len(answer.Content))
@@ -241,5 +288,92 @@ func (p *Anthropic) parseResponse(resp *http.Response) (string, error) {
 		}
 	}
 
-	return strings.Trim(strings.Join(texts, "\n"), "\n\t "), nil
+	return []string{strings.Trim(strings.Join(texts, "\n"), "\n\t ")}, answer.Usage.usage(), nil
+}
+
+// parseStream parses the streamed (server-sent events) response from the Anthropic API, writing the text
+// to w as it arrives.
+func (p *Anthropic) parseStream(resp *http.Response, w io.Writer) (string, Usage, error) {
+	// https://docs.anthropic.com/en/api/messages-streaming
+	var (
+		b     strings.Builder
+		usage anthropicUsage
+	)
+
+	if err := readSSE(resp.Body, func(event, data string) error {
+		switch event {
+		case "message_start": // contains the input tokens
+			var chunk struct {
+				Message struct {
+					Usage anthropicUsage `json:"usage"`
+				} `json:"message"`
+			}
+
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				return err
+			}
+
+			usage = chunk.Message.Usage
+		case "message_delta": // contains the (cumulative) output tokens
+			var chunk struct {
+				Usage anthropicUsage `json:"usage"`
+			}
+
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				return err
+			}
+
+			usage.OutputTokens = chunk.Usage.OutputTokens
+		case "content_block_delta":
+			var chunk struct {
+				Delta struct {
+					Type string `json:"type"`
+					Text string `json:"text"`
+				} `json:"delta"`
+			}
+
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				return err
+			}
+
+			if chunk.Delta.Type != "text_delta" || chunk.Delta.Text == "" {
+				return nil
+			}
+
+			b.WriteString(chunk.Delta.Text)
+
+			_, err := io.WriteString(w, chunk.Delta.Text)
+
+			return err
+		case "error":
+			var body struct {
+				Error struct {
+					Type    string `json:"type"`
+					Message string `json:"message"`
+				} `json:"error"`
+			}
+
+			if err := json.Unmarshal([]byte(data), &body); err != nil {
+				return err
+			}
+
+			var err = fmt.Errorf("Anthropic API error: %s", body.Error.Message)
+
+			if body.Error.Type == "overloaded_error" {
+				return newRetryableError(err, 0)
+			}
+
+			return err
+		}
+
+		return nil
+	}); err != nil {
+		return "", Usage{}, err
+	}
+
+	if b.Len() == 0 {
+		return "", Usage{}, errors.New("no response from the Anthropic API")
+	}
+
+	return strings.Trim(b.String(), "\n\t "), usage.usage(), nil
 }
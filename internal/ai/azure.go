@@ -0,0 +1,324 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// AzureOpenAIDefaultAPIVersion is the Azure OpenAI API version used by default (the latest GA version).
+const AzureOpenAIDefaultAPIVersion = "2024-10-21"
+
+// AzureOpenAI is a provider for the Azure OpenAI Service. Unlike the OpenAI API, the model is selected by the
+// deployment name, which is a part of the URL, and the API key is sent in the "api-key" header.
+type AzureOpenAI struct {
+	httpClient         httpClient
+	apiKey, deployment string
+	endpoint           string
+	apiVersion         string
+}
+
+var _ Provider = (*AzureOpenAI)(nil) // ensure the interface is implemented
+
+type (
+	azureOpenAIOptions struct {
+		HttpClient httpClient
+		APIVersion string
+	}
+
+	// AzureOpenAIOption allows to customize the Azure OpenAI provider.
+	AzureOpenAIOption func(*azureOpenAIOptions)
+)
+
+// WithAzureOpenAIHttpClient sets the HTTP client for the Azure OpenAI provider.
+func WithAzureOpenAIHttpClient(c httpClient) AzureOpenAIOption {
+	return func(o *azureOpenAIOptions) { o.HttpClient = c }
+}
+
+// WithAzureOpenAIAPIVersion overrides the default API version ([AzureOpenAIDefaultAPIVersion]).
+func WithAzureOpenAIAPIVersion(v string) AzureOpenAIOption {
+	return func(o *azureOpenAIOptions) { o.APIVersion = v }
+}
+
+// NewAzureOpenAI creates a new Azure OpenAI provider. The endpoint is the resource URL, e.g.
+// "https://my-resource.openai.azure.com", and the deployment is the name of the model deployment.
+func NewAzureOpenAI(apiKey, endpoint, deployment string, opt ...AzureOpenAIOption) *AzureOpenAI {
+	var opts azureOpenAIOptions
+
+	for _, o := range opt {
+		o(&opts)
+	}
+
+	var p = AzureOpenAI{
+		httpClient: opts.HttpClient,
+		apiKey:     apiKey,
+		deployment: deployment,
+		endpoint:   strings.TrimRight(endpoint, "/"),
+		apiVersion: opts.APIVersion,
+	}
+
+	if p.httpClient == nil { // set default HTTP client
+		p.httpClient = &http.Client{
+			Timeout:   60 * time.Second,                         //nolint:mnd
+			Transport: &http.Transport{ForceAttemptHTTP2: true}, // use HTTP/2 (why not?)
+		}
+	}
+
+	if p.apiVersion == "" {
+		p.apiVersion = AzureOpenAIDefaultAPIVersion
+	}
+
+	return &p
+}
+
+func (p *AzureOpenAI) Query(
+	ctx context.Context,
+	changes, commits string,
+	opts ...Option,
+) (*Response, error) {
+	var opt, instructions = requestOptions(opts...)
+
+	req, rErr := p.newRequest(ctx, instructions, changes, commits, opt)
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	resp, rErr := p.httpClient.Do(req)
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, p.responseToError(resp)
+	}
+
+	var (
+		answers []string
+		usage   Usage
+		aErr    error
+	)
+
+	if opt.Stream != nil {
+		var answer string
+
+		answer, usage, aErr = p.parseStream(resp, opt.Stream)
+		answers = []string{answer}
+	} else {
+		answers, usage, aErr = p.parseResponse(resp)
+	}
+
+	if aErr != nil {
+		return nil, aErr
+	}
+
+	var response = newResponse(instructions, answers, opt)
+
+	response.Model, response.Usage = p.deployment, usage
+
+	return response, nil
+}
+
+// Request renders the HTTP request that [AzureOpenAI.Query] would send, without sending it.
+func (p *AzureOpenAI) Request(ctx context.Context, changes, commits string, opts ...Option) (*http.Request, error) {
+	var opt, instructions = requestOptions(opts...)
+
+	return p.newRequest(ctx, instructions, changes, commits, opt)
+}
+
+// newRequest creates a new HTTP request for the Azure OpenAI API.
+func (p *AzureOpenAI) newRequest(
+	ctx context.Context,
+	instructions, changes, commits string,
+	o options,
+) (*http.Request, error) {
+	type message struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+
+	// https://learn.microsoft.com/en-us/azure/ai-services/openai/reference#chat-completions
+	// (the model is not sent - it's defined by the deployment)
+	j, jErr := json.Marshal(struct {
+		Messages            []message            `json:"messages"`
+		Stream              bool                 `json:"stream,omitempty"`
+		StreamOptions       *openAIStreamOptions `json:"stream_options,omitempty"`
+		Temperature         float64              `json:"temperature"`
+		TopP                float64              `json:"top_p"`
+		HowMany             int                  `json:"n"`
+		MaxCompletionTokens int64                `json:"max_completion_tokens"`
+	}{
+		Stream:              o.Stream != nil,
+		StreamOptions:       newOpenAIStreamOptions(o),
+		Temperature:         o.temperature(),
+		TopP:                o.topP(),
+		HowMany:             max(o.Candidates, 1),
+		MaxCompletionTokens: o.MaxOutputTokens,
+		Messages: []message{
+			{Role: "system", Content: instructions},
+			{Role: roleUser, Content: wrapChanges(changes)},
+			{Role: roleUser, Content: wrapCommits(commits)},
+		},
+	})
+	if jErr != nil {
+		return nil, jErr
+	}
+
+	req, rErr := http.NewRequestWithContext(ctx,
+		http.MethodPost,
+		fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
+			p.endpoint, url.PathEscape(p.deployment), url.QueryEscape(p.apiVersion),
+		),
+		bytes.NewReader(j),
+	)
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("api-key", p.apiKey)
+
+	return req, nil
+}
+
+// responseToError converts the response from the Azure OpenAI API to an error.
+func (p *AzureOpenAI) responseToError(resp *http.Response) error {
+	// https://learn.microsoft.com/en-us/azure/ai-services/openai/reference#error
+	var body struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	retryable := resp.StatusCode == http.StatusTooManyRequests || // 429 - rate limit (the quota is exceeded)
+		resp.StatusCode == http.StatusInternalServerError || // 500
+		resp.StatusCode == http.StatusBadGateway || // 502
+		resp.StatusCode == http.StatusServiceUnavailable || // 503
+		resp.StatusCode == http.StatusGatewayTimeout // 504
+
+	var err error
+
+	if dErr := json.NewDecoder(resp.Body).Decode(&body); dErr == nil && body.Error.Message != "" {
+		if body.Error.Code == "DeploymentNotFound" {
+			err = fmt.Errorf("Azure OpenAI API error: %s (check the deployment name %q and the endpoint)", //nolint:staticcheck
+				body.Error.Message, p.deployment,
+			)
+		} else {
+			err = fmt.Errorf("Azure OpenAI API error: %s (status code: %d)", //nolint:staticcheck
+				body.Error.Message, resp.StatusCode,
+			)
+		}
+	} else {
+		err = fmt.Errorf("unexpected Azure OpenAI API response status code: %d (%s)",
+			resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return newAuthError(err)
+	}
+
+	if retryable {
+		return newRetryableError(err, retryAfterHeader(resp.Header))
+	}
+
+	return err
+}
+
+// parseResponse parses the response from the Azure OpenAI API. Each choice is returned as a separate answer.
+func (p *AzureOpenAI) parseResponse(resp *http.Response) ([]string, Usage, error) {
+	var answer struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage openAIUsage `json:"usage"`
+	}
+
+	if dErr := json.NewDecoder(resp.Body).Decode(&answer); dErr != nil {
+		return nil, Usage{}, dErr
+	}
+
+	var texts = make([]string, 0, len(answer.Choices))
+
+	for _, choice := range answer.Choices {
+		if text := strings.Trim(choice.Message.Content, "\n\t "); text != "" {
+			texts = append(texts, text)
+		}
+	}
+
+	if len(texts) == 0 { // the content may be empty if it was blocked by the content filter
+		return nil, Usage{}, errors.New("no response from the Azure OpenAI API")
+	}
+
+	return texts, answer.Usage.usage(), nil
+}
+
+// parseStream parses the streamed (server-sent events) response from the Azure OpenAI API, writing the text
+// to w as it arrives.
+func (p *AzureOpenAI) parseStream(resp *http.Response, w io.Writer) (string, Usage, error) {
+	var (
+		b     strings.Builder
+		usage Usage
+	)
+
+	if err := readSSE(resp.Body, func(_, data string) error {
+		if data == "[DONE]" { // the stream is terminated by the "[DONE]" message
+			return nil
+		}
+
+		// the first chunk may contain the content filter results only (with no choices)
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Usage *openAIUsage `json:"usage"` // sent in the last chunk
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return err
+		}
+
+		if chunk.Error != nil { // the error may be sent in the middle of the stream
+			return fmt.Errorf("Azure OpenAI API error: %s", chunk.Error.Message) //nolint:staticcheck
+		}
+
+		if chunk.Usage != nil {
+			usage = chunk.Usage.usage()
+		}
+
+		for _, choice := range chunk.Choices {
+			if text := choice.Delta.Content; text != "" {
+				b.WriteString(text)
+
+				if _, err := io.WriteString(w, text); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return "", Usage{}, err
+	}
+
+	if b.Len() == 0 {
+		return "", Usage{}, errors.New("no response from the Azure OpenAI API")
+	}
+
+	return strings.Trim(b.String(), "\n\t "), usage, nil
+}
@@ -0,0 +1,123 @@
+package ai_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+)
+
+func TestAzureOpenAI_Query(t *testing.T) {
+	t.Parallel()
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/deployments/my-gpt/chat/completions" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+
+		if v := r.URL.Query().Get("api-version"); v != "2025-01-01-preview" {
+			t.Errorf("unexpected api version: %s", v)
+		}
+
+		if key := r.Header.Get("api-key"); key != "secret" || r.Header.Get("Authorization") != "" {
+			t.Errorf("unexpected auth headers: %v", r.Header)
+		}
+
+		var body map[string]any
+
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := body["model"]; ok {
+			t.Error("the model must not be sent (it's defined by the deployment)")
+		}
+
+		if body["max_completion_tokens"] != float64(123) {
+			t.Errorf("unexpected max tokens: %v", body["max_completion_tokens"])
+		}
+
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"\nfeat: Add Azure\n"}}]}`)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	var p = ai.NewAzureOpenAI("secret", srv.URL+"/", "my-gpt", ai.WithAzureOpenAIAPIVersion("2025-01-01-preview"))
+
+	resp, err := p.Query(context.Background(), "the diff", "the log", ai.WithMaxOutputTokens(123))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Answer != "feat: Add Azure" {
+		t.Errorf("unexpected answer: %q", resp.Answer)
+	}
+}
+
+func TestAzureOpenAI_Errors(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		giveStatus    int
+		giveBody      string
+		wantRetryable bool
+		wantAuth      bool
+		wantContains  string
+	}{
+		"rate limit": {
+			giveStatus:    http.StatusTooManyRequests,
+			giveBody:      `{"error":{"code":"429","message":"Rate limit is exceeded."}}`,
+			wantRetryable: true,
+			wantContains:  "Rate limit is exceeded",
+		},
+		"unavailable": {
+			giveStatus:    http.StatusServiceUnavailable,
+			wantRetryable: true,
+			wantContains:  "status code: 503",
+		},
+		"deployment not found": {
+			giveStatus:   http.StatusNotFound,
+			giveBody:     `{"error":{"code":"DeploymentNotFound","message":"The API deployment does not exist."}}`,
+			wantContains: `check the deployment name "my-gpt"`,
+		},
+		"unauthorized": {
+			giveStatus:   http.StatusUnauthorized,
+			giveBody:     `{"error":{"code":"401","message":"Access denied due to invalid subscription key."}}`,
+			wantAuth:     true,
+			wantContains: "invalid subscription key",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tc.giveStatus)
+				_, _ = io.WriteString(w, tc.giveBody)
+			}))
+
+			t.Cleanup(srv.Close)
+
+			_, err := ai.NewAzureOpenAI("key", srv.URL, "my-gpt").Query(context.Background(), "diff", "log")
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+
+			if got := ai.IsRetryableError(err); got != tc.wantRetryable {
+				t.Errorf("retryable: got %t, want %t (%v)", got, tc.wantRetryable, err)
+			}
+
+			if got := ai.IsAuthError(err); got != tc.wantAuth {
+				t.Errorf("auth: got %t, want %t (%v)", got, tc.wantAuth, err)
+			}
+
+			if !strings.Contains(err.Error(), tc.wantContains) {
+				t.Errorf("error %q does not contain %q", err, tc.wantContains)
+			}
+		})
+	}
+}
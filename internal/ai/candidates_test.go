@@ -0,0 +1,92 @@
+package ai_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+)
+
+func TestProviders_Candidates(t *testing.T) {
+	t.Parallel()
+
+	t.Run("native (openai)", func(t *testing.T) {
+		t.Parallel()
+
+		var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body struct {
+				N int `json:"n"`
+			}
+
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Error(err)
+			}
+
+			if body.N != 3 {
+				t.Errorf("unexpected number of choices requested: %d", body.N)
+			}
+
+			_, _ = io.WriteString(w, `{"choices":[
+				{"message":{"content":"feat: One\n\nbody"}},
+				{"message":{"content":"feat: Two"}},
+				{"message":{"content":"feat: One\n\nbody"}}
+			]}`)
+		}))
+
+		t.Cleanup(srv.Close)
+
+		resp, err := ai.NewOpenAI("key", "model", ai.WithOpenAIBaseURL(srv.URL)).
+			Query(context.Background(), "diff", "log", ai.WithCandidates(3))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// duplicates are removed
+		if want := []string{"feat: One\n\nbody", "feat: Two"}; !slices.Equal(resp.Answers, want) {
+			t.Errorf("unexpected answers: %q, want %q", resp.Answers, want)
+		}
+
+		if resp.Answer != resp.Answers[0] {
+			t.Errorf("the answer should be the first one: %q", resp.Answer)
+		}
+	})
+
+	t.Run("parallel fallback (anthropic)", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+
+		var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = fmt.Fprintf(w, `{"content":[{"type":"text","text":"feat: Answer %d\n\nbody"}]}`, calls.Add(1))
+		}))
+
+		t.Cleanup(srv.Close)
+
+		resp, err := ai.NewAnthropic("key", "model", ai.WithAnthropicBaseURL(srv.URL)).
+			Query(context.Background(), "diff", "log", ai.WithCandidates(3), ai.WithShortMessageOnly(true))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if calls.Load() != 3 {
+			t.Errorf("unexpected number of requests: %d", calls.Load())
+		}
+
+		if len(resp.Answers) != 3 {
+			t.Fatalf("unexpected number of answers: %q", resp.Answers)
+		}
+
+		for i := range 3 {
+			if want := fmt.Sprintf("feat: Answer %d", i+1); !slices.Contains(resp.Answers, want) {
+				t.Errorf("answer %q not found in %q", want, resp.Answers)
+			}
+		}
+	})
+}
@@ -1,17 +1,26 @@
 package ai
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
 
 // retryableError wraps an error returned by an AI provider when the failure is temporary
 // and retrying the request may succeed (e.g. rate limit, server overload).
 type retryableError struct {
-	err error
+	err        error
+	retryAfter time.Duration // the delay suggested by the server (zero if unknown)
 }
 
 func (e *retryableError) Error() string { return e.err.Error() }
 
 func (e *retryableError) Unwrap() error { return e.err }
 
+// RetryAfter returns the delay before the next attempt suggested by the server, or zero if unknown.
+func (e *retryableError) RetryAfter() time.Duration { return e.retryAfter }
+
 // IsRetryableError reports whether err or any error in its chain is a retryableError.
 func IsRetryableError(err error) bool {
 	var t *retryableError
@@ -19,4 +28,50 @@ func IsRetryableError(err error) bool {
 	return errors.As(err, &t)
 }
 
-func newRetryableError(err error) *retryableError { return &retryableError{err: err} }
+func newRetryableError(err error, retryAfter time.Duration) *retryableError {
+	return &retryableError{err: err, retryAfter: retryAfter}
+}
+
+// retryAfterHeader returns the delay suggested by the server in the response headers, or zero if none. The
+// "retry-after-ms" header (OpenAI, Azure) takes precedence over the standard "Retry-After" one (seconds or an
+// HTTP date).
+func retryAfterHeader(h http.Header) time.Duration {
+	if ms, err := strconv.ParseFloat(h.Get("retry-after-ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+
+	var v = h.Get("Retry-After")
+
+	if v == "" {
+		return 0
+	}
+
+	if sec, err := strconv.ParseFloat(v, 64); err == nil {
+		return max(time.Duration(sec*float64(time.Second)), 0)
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+
+	return 0
+}
+
+// authError wraps an error returned by an AI provider when the request is rejected due to the credentials
+// (e.g. invalid API key, no access to the model). Retrying it makes no sense, but another provider may work.
+type authError struct {
+	err error
+}
+
+func (e *authError) Error() string { return e.err.Error() }
+
+func (e *authError) Unwrap() error { return e.err }
+
+// IsAuthError reports whether err or any error in its chain is an authError.
+func IsAuthError(err error) bool {
+	var t *authError
+
+	return errors.As(err, &t)
+}
+
+func newAuthError(err error) *authError { return &authError{err: err} }
@@ -0,0 +1,82 @@
+package ai_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+)
+
+func TestProviders_RetryAfter(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		giveStatus  int
+		giveHeaders map[string]string
+		giveBody    string
+		newProvider func(baseURL string) ai.Provider
+		wantDelay   time.Duration
+	}{
+		"openai (retry-after-ms)": {
+			giveStatus:  http.StatusTooManyRequests,
+			giveHeaders: map[string]string{"retry-after-ms": "1500", "Retry-After": "2"},
+			newProvider: func(u string) ai.Provider { return ai.NewOpenAI("key", "model", ai.WithOpenAIBaseURL(u)) },
+			wantDelay:   1500 * time.Millisecond,
+		},
+		"anthropic (retry-after)": {
+			giveStatus:  529,
+			giveHeaders: map[string]string{"Retry-After": "7"},
+			giveBody:    `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+			newProvider: func(u string) ai.Provider { return ai.NewAnthropic("key", "model", ai.WithAnthropicBaseURL(u)) },
+			wantDelay:   7 * time.Second,
+		},
+		"gemini (retry info)": {
+			giveStatus: http.StatusTooManyRequests,
+			giveBody: `{"error":{"code":429,"message":"quota exceeded","status":"RESOURCE_EXHAUSTED","details":[
+				{"@type":"type.googleapis.com/google.rpc.QuotaFailure"},
+				{"@type":"type.googleapis.com/google.rpc.RetryInfo","retryDelay":"37s"}
+			]}}`,
+			newProvider: func(u string) ai.Provider { return ai.NewGemini("key", "model", ai.WithGeminiBaseURL(u)) },
+			wantDelay:   37 * time.Second,
+		},
+		"no hint": {
+			giveStatus:  http.StatusServiceUnavailable,
+			newProvider: func(u string) ai.Provider { return ai.NewOpenAI("key", "model", ai.WithOpenAIBaseURL(u)) },
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				for k, v := range tc.giveHeaders {
+					w.Header().Set(k, v)
+				}
+
+				w.WriteHeader(tc.giveStatus)
+				_, _ = io.WriteString(w, tc.giveBody)
+			}))
+
+			t.Cleanup(srv.Close)
+
+			_, err := tc.newProvider(srv.URL).Query(context.Background(), "diff", "log")
+			if !ai.IsRetryableError(err) {
+				t.Fatalf("expected a retryable error, got %v", err)
+			}
+
+			var ra interface{ RetryAfter() time.Duration }
+
+			if !errors.As(err, &ra) {
+				t.Fatalf("the error does not carry the delay: %v", err)
+			}
+
+			if got := ra.RetryAfter(); got != tc.wantDelay {
+				t.Errorf("unexpected delay: %s, want %s", got, tc.wantDelay)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package ai
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// mergeExtraBody merges the extra fields into the JSON object j (the request body), overriding the existing
+// fields with the same names. The fields with nil values are removed from the object, which allows dropping
+// the fields that are not supported by an API-compatible service.
+func mergeExtraBody(j []byte, extra map[string]any) ([]byte, error) {
+	if len(extra) == 0 {
+		return j, nil
+	}
+
+	var body map[string]any
+
+	if err := json.Unmarshal(j, &body); err != nil {
+		return nil, err
+	}
+
+	for key, value := range extra {
+		if value == nil {
+			delete(body, key)
+
+			continue
+		}
+
+		body[key] = value
+	}
+
+	return json.Marshal(body)
+}
+
+// setExtraHeaders sets the extra headers to the request, overriding the existing ones.
+func setExtraHeaders(req *http.Request, headers map[string]string) {
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+}
@@ -0,0 +1,92 @@
+package ai_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+)
+
+func TestProviders_ExtraHeadersAndBody(t *testing.T) {
+	t.Parallel()
+
+	var (
+		headers = map[string]string{"X-Title": "describe-commit"}
+		extra   = map[string]any{"temperature": nil, "reasoning": map[string]any{"effort": "low"}}
+	)
+
+	for name, tc := range map[string]struct {
+		giveAnswer  string
+		newProvider func(baseURL string) ai.Provider
+	}{
+		"openai": {
+			giveAnswer: `{"choices":[{"message":{"content":"feat: Extra"}}]}`,
+			newProvider: func(u string) ai.Provider {
+				return ai.NewOpenAI("", "model",
+					ai.WithOpenAIBaseURL(u), ai.WithOpenAIHeaders(headers), ai.WithOpenAIExtraBody(extra),
+				)
+			},
+		},
+		"anthropic": {
+			giveAnswer: `{"content":[{"type":"text","text":"feat: Extra"}]}`,
+			newProvider: func(u string) ai.Provider {
+				return ai.NewAnthropic("key", "model",
+					ai.WithAnthropicBaseURL(u), ai.WithAnthropicHeaders(headers), ai.WithAnthropicExtraBody(extra),
+				)
+			},
+		},
+		"gemini": {
+			giveAnswer: `{"candidates":[{"content":{"parts":[{"text":"feat: Extra"}]}}]}`,
+			newProvider: func(u string) ai.Provider {
+				return ai.NewGemini("key", "model",
+					ai.WithGeminiBaseURL(u), ai.WithGeminiHeaders(headers), ai.WithGeminiExtraBody(extra),
+				)
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("X-Title"); got != "describe-commit" {
+					t.Errorf("the extra header is not sent: %q", got)
+				}
+
+				if name == "openai" && r.Header.Get("Authorization") != "" {
+					t.Error("the authorization header must not be sent without the API key")
+				}
+
+				var body map[string]any
+
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Fatal(err)
+				}
+
+				if _, ok := body["temperature"]; ok {
+					t.Error("the field with nil value must be removed")
+				}
+
+				if r, _ := body["reasoning"].(map[string]any); r["effort"] != "low" {
+					t.Errorf("the extra field is not merged: %v", body)
+				}
+
+				_, _ = io.WriteString(w, tc.giveAnswer)
+			}))
+
+			t.Cleanup(srv.Close)
+
+			resp, err := tc.newProvider(srv.URL).Query(context.Background(), "diff", "log")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.Answer != "feat: Extra" {
+				t.Errorf("unexpected answer: %q", resp.Answer)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 	"time"
@@ -20,6 +21,8 @@ type Gemini struct {
 	httpClient        httpClient
 	apiKey, modelName string
 	baseURL           string
+	headers           map[string]string // extra request headers
+	extraBody         map[string]any    // extra request body fields
 }
 
 var _ Provider = (*Gemini)(nil) // ensure the interface is implemented
@@ -28,6 +31,8 @@ type (
 	geminiOptions struct {
 		HttpClient httpClient
 		BaseURL    string
+		Headers    map[string]string
+		ExtraBody  map[string]any
 	}
 
 	// GeminiOption allows to customize the Gemini provider.
@@ -45,6 +50,17 @@ func WithGeminiBaseURL(url string) GeminiOption {
 	return func(o *geminiOptions) { o.BaseURL = url }
 }
 
+// WithGeminiHeaders sets the extra HTTP headers sent with each request (e.g. for proxies or API gateways).
+func WithGeminiHeaders(h map[string]string) GeminiOption {
+	return func(o *geminiOptions) { o.Headers = h }
+}
+
+// WithGeminiExtraBody sets the extra fields merged into the request body (the existing fields are overridden,
+// and the fields with nil values are removed).
+func WithGeminiExtraBody(b map[string]any) GeminiOption {
+	return func(o *geminiOptions) { o.ExtraBody = b }
+}
+
 // NewGemini creates a new Gemini provider.
 func NewGemini(apiKey, model string, opt ...GeminiOption) *Gemini { //nolint:dupl
 	var opts geminiOptions
@@ -57,6 +73,8 @@ func NewGemini(apiKey, model string, opt ...GeminiOption) *Gemini { //nolint:dup
 		httpClient: opts.HttpClient,
 		apiKey:     apiKey,
 		modelName:  model,
+		headers:    opts.Headers,
+		extraBody:  opts.ExtraBody,
 	}
 
 	if p.httpClient == nil { // set default HTTP client
@@ -78,14 +96,7 @@ func (p *Gemini) Query(
 	changes, commits string,
 	opts ...Option,
 ) (*Response, error) {
-	var (
-		opt          = options{}.Apply(opts...)
-		instructions = GeneratePrompt(opts...)
-	)
-
-	if opt.MaxOutputTokens == 0 {
-		opt.MaxOutputTokens = defaultMaxOutputTokens // set default value
-	}
+	var opt, instructions = requestOptions(opts...)
 
 	// https://ai.google.dev/gemini-api/docs/text-generation?lang=rest
 	req, rErr := p.newRequest(ctx, instructions, changes, commits, opt)
@@ -104,22 +115,37 @@ func (p *Gemini) Query(
 		return nil, p.responseToError(resp)
 	}
 
-	answer, aErr := p.parseResponse(resp)
+	var (
+		answers []string
+		usage   Usage
+		aErr    error
+	)
+
+	if opt.Stream != nil {
+		var answer string
+
+		answer, usage, aErr = p.parseStream(resp, opt.Stream)
+		answers = []string{answer}
+	} else {
+		answers, usage, aErr = p.parseResponse(resp)
+	}
+
 	if aErr != nil {
 		return nil, aErr
 	}
 
-	if opt.ShortMessageOnly {
-		var parts = strings.Split(answer, "\n")
+	var response = newResponse(instructions, answers, opt)
 
-		if len(parts) == 0 {
-			return nil, errors.New("no response from the Gemini API")
-		}
+	response.Model, response.Usage = p.modelName, usage
 
-		return &Response{Prompt: instructions, Answer: parts[0]}, nil
-	}
+	return response, nil
+}
 
-	return &Response{Prompt: instructions, Answer: answer}, nil
+// Request renders the HTTP request that [Gemini.Query] would send, without sending it.
+func (p *Gemini) Request(ctx context.Context, changes, commits string, opts ...Option) (*http.Request, error) {
+	var opt, instructions = requestOptions(opts...)
+
+	return p.newRequest(ctx, instructions, changes, commits, opt)
 }
 
 // newRequest creates a new HTTP request for the Gemini API.
@@ -162,10 +188,10 @@ func (p *Gemini) newRequest( //nolint:funlen
 		Contents       []content       `json:"contents"`
 	}{
 		GenerationConfig: generationConfig{
-			Temperature:     0.1, //nolint:mnd
+			Temperature:     o.temperature(),
 			MaxOutputTokens: o.MaxOutputTokens,
-			TopP:            0.1, //nolint:mnd
-			CandidateCount:  1,
+			TopP:            o.topP(),
+			CandidateCount:  max(o.Candidates, 1),
 		},
 		SafetySettings: []safetySetting{
 			{Category: "HARM_CATEGORY_DANGEROUS_CONTENT", Threshold: safetyThresholdBlockLowAndAbove},
@@ -186,16 +212,24 @@ func (p *Gemini) newRequest( //nolint:funlen
 		return nil, jErr
 	}
 
+	if j, jErr = mergeExtraBody(j, p.extraBody); jErr != nil {
+		return nil, jErr
+	}
+
 	base := geminiDefaultBaseURL
 	if p.baseURL != "" {
 		base = p.baseURL
 	}
 
 	// https://ai.google.dev/gemini-api/docs/text-generation?lang=rest
-	req, rErr := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf(
-		base+"/v1beta/models/%s:generateContent",
-		p.modelName,
-	), bytes.NewReader(j))
+	var url = fmt.Sprintf(base+"/v1beta/models/%s:generateContent", p.modelName)
+
+	if o.Stream != nil {
+		// https://ai.google.dev/api/generate-content#method:-models.streamgeneratecontent
+		url = fmt.Sprintf(base+"/v1beta/models/%s:streamGenerateContent?alt=sse", p.modelName)
+	}
+
+	req, rErr := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(j))
 	if rErr != nil {
 		return nil, rErr
 	}
@@ -204,6 +238,7 @@ func (p *Gemini) newRequest( //nolint:funlen
 
 	// https://cloud.google.com/docs/authentication/api-keys-use#using-with-rest
 	req.Header.Set("x-goog-api-key", p.apiKey)
+	setExtraHeaders(req, p.headers)
 
 	return req, nil
 }
@@ -216,6 +251,10 @@ func (p *Gemini) responseToError(resp *http.Response) error {
 		Error struct {
 			Message string `json:"message"`
 			Status  string `json:"status"` // gRPC status name, e.g. "RESOURCE_EXHAUSTED"
+			Details []struct {
+				Reason     string `json:"reason"`     // e.g. "API_KEY_INVALID"
+				RetryDelay string `json:"retryDelay"` // google.rpc.RetryInfo, e.g. "37s"
+			} `json:"details"`
 		} `json:"error"`
 	}
 
@@ -225,11 +264,27 @@ func (p *Gemini) responseToError(resp *http.Response) error {
 		resp.StatusCode == http.StatusServiceUnavailable || // 503 - transient (UNAVAILABLE)
 		resp.StatusCode == http.StatusGatewayTimeout // 504
 
-	var err error
+	var (
+		err        error
+		auth       = resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden
+		retryAfter = retryAfterHeader(resp.Header)
+	)
 
 	if dErr := json.NewDecoder(resp.Body).Decode(&body); dErr == nil && body.Error.Message != "" {
 		err = fmt.Errorf("Gemini API error: %s (status code: %d)", body.Error.Message, resp.StatusCode)
 
+		// the invalid API key is reported with the 400 (INVALID_ARGUMENT) status code
+		for _, d := range body.Error.Details {
+			if d.Reason == "API_KEY_INVALID" {
+				auth = true
+			}
+
+			// the quota errors contain the delay after which the quota is restored
+			if delay, dErr := time.ParseDuration(d.RetryDelay); dErr == nil && delay > 0 {
+				retryAfter = delay
+			}
+		}
+
 		// guard against edge cases where the gRPC status arrives with an unexpected HTTP code
 		if body.Error.Status == "RESOURCE_EXHAUSTED" || body.Error.Status == "UNAVAILABLE" {
 			retryable = true
@@ -239,15 +294,19 @@ func (p *Gemini) responseToError(resp *http.Response) error {
 			resp.StatusCode, http.StatusText(resp.StatusCode))
 	}
 
+	if auth {
+		return newAuthError(err)
+	}
+
 	if retryable {
-		return newRetryableError(err)
+		return newRetryableError(err, retryAfter)
 	}
 
 	return err
 }
 
-// parseResponse parses the response from the Gemini API.
-func (p *Gemini) parseResponse(resp *http.Response) (string, error) {
+// parseResponse parses the response from the Gemini API. Each candidate is returned as a separate answer.
+func (p *Gemini) parseResponse(resp *http.Response) ([]string, Usage, error) {
 	var answer struct {
 		Candidates []struct {
 			Content struct {
@@ -256,25 +315,104 @@ func (p *Gemini) parseResponse(resp *http.Response) (string, error) {
 				} `json:"parts"`
 			} `json:"content"`
 		} `json:"candidates"`
+		UsageMetadata geminiUsage `json:"usageMetadata"`
 	}
 
 	if dErr := json.NewDecoder(resp.Body).Decode(&answer); dErr != nil {
-		return "", dErr
+		return nil, Usage{}, dErr
 	}
 
 	if len(answer.Candidates) == 0 || len(answer.Candidates[0].Content.Parts) == 0 {
-		return "", errors.New("no content found")
+		return nil, Usage{}, errors.New("no content found")
 	}
 
-	var texts = make([]string, 0, len(answer.Candidates[0].Content.Parts))
+	var answers = make([]string, 0, len(answer.Candidates))
 
 	for _, candidate := range answer.Candidates {
+		var texts = make([]string, 0, len(candidate.Content.Parts))
+
 		for _, part := range candidate.Content.Parts {
 			if part.Text != "" {
 				texts = append(texts, part.Text)
 			}
 		}
+
+		if text := strings.Trim(strings.Join(texts, "\n"), "\n\t "); text != "" {
+			answers = append(answers, text)
+		}
+	}
+
+	if len(answers) == 0 {
+		return nil, Usage{}, errors.New("no content found")
+	}
+
+	return answers, answer.UsageMetadata.usage(), nil
+}
+
+// parseStream parses the streamed (server-sent events) response from the Gemini API, writing the text
+// to w as it arrives.
+func (p *Gemini) parseStream(resp *http.Response, w io.Writer) (string, Usage, error) {
+	var (
+		b     strings.Builder
+		usage Usage
+	)
+
+	if err := readSSE(resp.Body, func(_, data string) error {
+		var chunk struct {
+			Candidates []struct {
+				Content struct {
+					Parts []struct {
+						Text string `json:"text"`
+					} `json:"parts"`
+				} `json:"content"`
+			} `json:"candidates"`
+			UsageMetadata *geminiUsage `json:"usageMetadata"`
+			Error         *struct {
+				Message string `json:"message"`
+				Status  string `json:"status"`
+			} `json:"error"`
+		}
+
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return err
+		}
+
+		if chunk.Error != nil { // the error may be sent in the middle of the stream
+			var err = fmt.Errorf("Gemini API error: %s", chunk.Error.Message)
+
+			if chunk.Error.Status == "RESOURCE_EXHAUSTED" || chunk.Error.Status == "UNAVAILABLE" {
+				return newRetryableError(err, 0)
+			}
+
+			return err
+		}
+
+		if chunk.UsageMetadata != nil { // each chunk contains the usage so far
+			usage = chunk.UsageMetadata.usage()
+		}
+
+		for _, candidate := range chunk.Candidates {
+			for _, part := range candidate.Content.Parts {
+				if part.Text == "" {
+					continue
+				}
+
+				b.WriteString(part.Text)
+
+				if _, err := io.WriteString(w, part.Text); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return "", Usage{}, err
+	}
+
+	if b.Len() == 0 {
+		return "", Usage{}, errors.New("no content found")
 	}
 
-	return strings.Trim(strings.Join(texts, "\n"), "\n\t "), nil
+	return strings.Trim(b.String(), "\n\t "), usage, nil
 }
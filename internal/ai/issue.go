@@ -0,0 +1,69 @@
+package ai
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// IssueKeyPlaceholder is replaced with the issue key in the issue key format (see [FormatIssueKey]).
+const IssueKeyPlaceholder = "{key}"
+
+// ExtractIssueKey extracts the issue (ticket) key from the branch name (e.g. "PROJ-123" from
+// "feature/PROJ-123-some-thing"): the first capturing group of the pattern, if any, or the whole match (also if
+// the group is optional and does not participate in the match). An empty string is returned if the branch name
+// does not match.
+func ExtractIssueKey(pattern *regexp.Regexp, branch string) string {
+	var m = pattern.FindStringSubmatch(branch)
+
+	switch {
+	case m == nil:
+		return ""
+	case len(m) > 1 && m[1] != "":
+		return m[1]
+	}
+
+	return m[0]
+}
+
+// FormatIssueKey returns the subject prefix for the issue key, replacing the [IssueKeyPlaceholder] in the format
+// (e.g. "[{key}]" gives "[PROJ-123]"). An empty string is returned for the empty key.
+func FormatIssueKey(format, key string) string {
+	if key == "" {
+		return ""
+	}
+
+	return strings.ReplaceAll(format, IssueKeyPlaceholder, key)
+}
+
+// PrependIssueKey prepends the formatted issue key (see [FormatIssueKey]) to the subject line of the commit
+// message, separated by a space. The message is returned as is if the subject already starts with the key or the
+// prefix (e.g. the AI was asked to add it).
+func PrependIssueKey(message, format, key string) string {
+	var prefix = FormatIssueKey(format, key)
+
+	if prefix == "" || startsWith(message, key) || startsWith(message, prefix) {
+		return message
+	}
+
+	return prefix + " " + message
+}
+
+// startsWith reports whether the message starts with the prefix as a whole word: the prefix ending with a letter
+// or digit must not be followed by another one, so "PROJ-123 fix" does not start with "PROJ-12".
+func startsWith(message, prefix string) bool {
+	if !strings.HasPrefix(message, prefix) {
+		return false
+	}
+
+	var (
+		last, _ = utf8.DecodeLastRuneInString(prefix)
+		next, n = utf8.DecodeRuneInString(message[len(prefix):])
+	)
+
+	return n == 0 || !isAlphanumeric(last) || !isAlphanumeric(next)
+}
+
+// isAlphanumeric reports whether the rune is a letter or a digit.
+func isAlphanumeric(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
@@ -0,0 +1,90 @@
+package ai_test
+
+import (
+	"regexp"
+	"testing"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+)
+
+func TestExtractIssueKey(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		givePattern string
+		giveBranch  string
+		want        string
+	}{
+		"whole match":    {givePattern: `[A-Z][A-Z0-9]+-\d+`, giveBranch: "feature/PROJ-123-some-thing", want: "PROJ-123"},
+		"first group":    {givePattern: `^\w+/(\d+)-`, giveBranch: "fix/42-null-pointer", want: "42"},
+		"no match":       {givePattern: `[A-Z]+-\d+`, giveBranch: "main"},
+		"detached HEAD":  {givePattern: `[A-Z]+-\d+`},
+		"optional group": {givePattern: `([A-Z]+-\d+)?`, giveBranch: "main"},
+		"group not participating": {
+			givePattern: `issue-(\d+)|[A-Z]+-\d+`, giveBranch: "feature/PROJ-123-some-thing", want: "PROJ-123",
+		},
+		"group participating": {givePattern: `issue-(\d+)|[A-Z]+-\d+`, giveBranch: "fix/issue-42", want: "42"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ai.ExtractIssueKey(regexp.MustCompile(tc.givePattern), tc.giveBranch); got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestPrependIssueKey(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		giveMessage, giveFormat, giveKey string
+		want                             string
+	}{
+		"plain": {
+			giveMessage: "feat: Add X\n\nbody", giveFormat: "{key}", giveKey: "PROJ-1",
+			want: "PROJ-1 feat: Add X\n\nbody",
+		},
+		"formatted": {
+			giveMessage: "fix: Y", giveFormat: "[{key}]", giveKey: "PROJ-1",
+			want: "[PROJ-1] fix: Y",
+		},
+		"already prefixed": {
+			giveMessage: "[PROJ-1] fix: Y", giveFormat: "[{key}]", giveKey: "PROJ-1",
+			want: "[PROJ-1] fix: Y",
+		},
+		"starts with the key": {
+			giveMessage: "PROJ-1: fix: Y", giveFormat: "[{key}]", giveKey: "PROJ-1",
+			want: "PROJ-1: fix: Y",
+		},
+		"starts with the longer key": {
+			giveMessage: "PROJ-123 fix: Y", giveFormat: "{key}", giveKey: "PROJ-12",
+			want: "PROJ-12 PROJ-123 fix: Y",
+		},
+		"starts with the longer formatted key": {
+			giveMessage: "PROJ-123: fix: Y", giveFormat: "{key}:", giveKey: "PROJ-12",
+			want: "PROJ-12: PROJ-123: fix: Y",
+		},
+		"key only": {
+			giveMessage: "PROJ-1", giveFormat: "[{key}]", giveKey: "PROJ-1",
+			want: "PROJ-1",
+		},
+		"prefix followed by a letter": {
+			giveMessage: "[PROJ-1]fix: Y", giveFormat: "[{key}]", giveKey: "PROJ-1",
+			want: "[PROJ-1]fix: Y",
+		},
+		"no key": {
+			giveMessage: "fix: Y", giveFormat: "{key}",
+			want: "fix: Y",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ai.PrependIssueKey(tc.giveMessage, tc.giveFormat, tc.giveKey); got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
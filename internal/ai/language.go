@@ -0,0 +1,83 @@
+package ai
+
+import (
+	"slices"
+	"strings"
+)
+
+// DefaultLanguage is the language of the commit messages by default.
+const DefaultLanguage = "en"
+
+// languages maps the supported language codes (ISO 639-1, with the region for some of them) to their English
+// names, which are used in the prompt.
+var languages = map[string]string{ //nolint:gochecknoglobals
+	"ar":    "Arabic",
+	"bg":    "Bulgarian",
+	"cs":    "Czech",
+	"da":    "Danish",
+	"de":    "German",
+	"el":    "Greek",
+	"en":    "English",
+	"es":    "Spanish",
+	"et":    "Estonian",
+	"fa":    "Persian",
+	"fi":    "Finnish",
+	"fr":    "French",
+	"he":    "Hebrew",
+	"hi":    "Hindi",
+	"hr":    "Croatian",
+	"hu":    "Hungarian",
+	"id":    "Indonesian",
+	"it":    "Italian",
+	"ja":    "Japanese",
+	"ko":    "Korean",
+	"lt":    "Lithuanian",
+	"lv":    "Latvian",
+	"nl":    "Dutch",
+	"no":    "Norwegian",
+	"pl":    "Polish",
+	"pt":    "Portuguese",
+	"pt-br": "Brazilian Portuguese",
+	"ro":    "Romanian",
+	"ru":    "Russian",
+	"sk":    "Slovak",
+	"sl":    "Slovenian",
+	"sr":    "Serbian",
+	"sv":    "Swedish",
+	"th":    "Thai",
+	"tr":    "Turkish",
+	"uk":    "Ukrainian",
+	"vi":    "Vietnamese",
+	"zh":    "Chinese",
+	"zh-cn": "Simplified Chinese",
+	"zh-tw": "Traditional Chinese",
+}
+
+// normalizeLanguage converts the language code to the form used in the languages map ("pt_BR" -> "pt-br").
+func normalizeLanguage(code string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(code)), "_", "-")
+}
+
+// SupportedLanguages returns a sorted list of supported language codes.
+func SupportedLanguages() []string {
+	var codes = make([]string, 0, len(languages))
+
+	for code := range languages {
+		codes = append(codes, code)
+	}
+
+	slices.Sort(codes)
+
+	return codes
+}
+
+// IsLanguageSupported checks if the given language code is supported (the code is case-insensitive).
+func IsLanguageSupported(code string) bool {
+	_, ok := languages[normalizeLanguage(code)]
+
+	return ok
+}
+
+// LanguageName returns the English name of the language by its code, or an empty string if the language is
+// not supported.
+func LanguageName(code string) string { return languages[normalizeLanguage(code)] }
@@ -0,0 +1,489 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const ollamaDefaultBaseURL = "http://localhost:11434"
+
+// Ollama is a provider for the native Ollama API (https://github.com/ollama/ollama/blob/main/docs/api.md).
+type Ollama struct {
+	httpClient httpClient
+	modelName  string
+	baseURL    string
+	numCtx     int
+	keepAlive  string
+	autoPull   bool
+	pullOutput io.Writer
+}
+
+var _ Provider = (*Ollama)(nil) // ensure the interface is implemented
+
+type (
+	ollamaOptions struct {
+		HttpClient httpClient
+		BaseURL    string
+		NumCtx     int
+		KeepAlive  string
+		AutoPull   bool
+		PullOutput io.Writer
+	}
+
+	// OllamaOption allows to customize the Ollama provider.
+	OllamaOption func(*ollamaOptions)
+)
+
+// WithOllamaHttpClient sets the HTTP client for the Ollama provider.
+func WithOllamaHttpClient(c httpClient) OllamaOption {
+	return func(o *ollamaOptions) { o.HttpClient = c }
+}
+
+// WithOllamaBaseURL overrides the default Ollama API base URL (http://localhost:11434).
+func WithOllamaBaseURL(url string) OllamaOption {
+	return func(o *ollamaOptions) { o.BaseURL = url }
+}
+
+// WithOllamaNumCtx sets the size of the context window (in tokens) used by the model (0 = the model default).
+// Large diffs are silently truncated by Ollama when they don't fit into the context window.
+func WithOllamaNumCtx(n int) OllamaOption {
+	return func(o *ollamaOptions) { o.NumCtx = n }
+}
+
+// WithOllamaKeepAlive sets how long the model stays loaded in memory after the request (e.g. "10m", "-1" to
+// keep it loaded forever, "0" to unload it immediately). Empty = the server default.
+func WithOllamaKeepAlive(d string) OllamaOption {
+	return func(o *ollamaOptions) { o.KeepAlive = d }
+}
+
+// WithOllamaAutoPull enables pulling the model when it is not found on the server. The pull progress is
+// written to w (if not nil).
+func WithOllamaAutoPull(on bool, w io.Writer) OllamaOption {
+	return func(o *ollamaOptions) { o.AutoPull, o.PullOutput = on, w }
+}
+
+// NewOllama creates a new Ollama provider.
+func NewOllama(model string, opt ...OllamaOption) *Ollama {
+	var opts ollamaOptions
+
+	for _, o := range opt {
+		o(&opts)
+	}
+
+	var p = Ollama{
+		httpClient: opts.HttpClient,
+		modelName:  model,
+		baseURL:    ollamaDefaultBaseURL,
+		numCtx:     opts.NumCtx,
+		keepAlive:  opts.KeepAlive,
+		autoPull:   opts.AutoPull,
+		pullOutput: opts.PullOutput,
+	}
+
+	if p.httpClient == nil { // set default HTTP client
+		// no overall timeout: loading the local model (or pulling it) may take a while, so only the waiting for
+		// the response headers is limited (the request is canceled with the context anyway)
+		p.httpClient = &http.Client{
+			Transport: &http.Transport{ResponseHeaderTimeout: 10 * time.Minute}, //nolint:mnd
+		}
+	}
+
+	if opts.BaseURL != "" {
+		p.baseURL = strings.TrimRight(opts.BaseURL, "/")
+	}
+
+	return &p
+}
+
+// ollamaModelNotFoundError is returned when the model is not available on the Ollama server.
+type ollamaModelNotFoundError struct{ err error }
+
+func (e *ollamaModelNotFoundError) Error() string { return e.err.Error() }
+
+func (e *ollamaModelNotFoundError) Unwrap() error { return e.err }
+
+func (p *Ollama) Query(
+	ctx context.Context,
+	changes, commits string,
+	opts ...Option,
+) (*Response, error) {
+	var opt, instructions = requestOptions(opts...)
+
+	if opt.Candidates > 1 { // the API does not support multiple candidates natively
+		return queryInParallel(ctx, opt.Candidates, func(ctx context.Context) (*Response, error) {
+			return p.Query(ctx, changes, commits, append(opts[:len(opts):len(opts)], withSingleCandidate())...)
+		})
+	}
+
+	answer, usage, err := p.chat(ctx, instructions, changes, commits, opt)
+	if notFound := (*ollamaModelNotFoundError)(nil); errors.As(err, &notFound) && p.autoPull {
+		if err = p.Pull(ctx); err != nil {
+			return nil, err
+		}
+
+		answer, usage, err = p.chat(ctx, instructions, changes, commits, opt)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var response = newResponse(instructions, []string{answer}, opt)
+
+	response.Model, response.Usage = p.modelName, usage
+
+	return response, nil
+}
+
+// chat sends the request to the /api/chat endpoint and returns the answer along with the token usage.
+func (p *Ollama) chat(ctx context.Context, instructions, changes, commits string, opt options) (string, Usage, error) {
+	req, rErr := p.newRequest(ctx, instructions, changes, commits, opt)
+	if rErr != nil {
+		return "", Usage{}, rErr
+	}
+
+	resp, rErr := p.httpClient.Do(req)
+	if rErr != nil {
+		return "", Usage{}, rErr
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", Usage{}, p.responseToError(resp)
+	}
+
+	if opt.Stream != nil {
+		return p.parseStream(resp, opt.Stream)
+	}
+
+	return p.parseResponse(resp)
+}
+
+// Request renders the HTTP request that [Ollama.Query] would send, without sending it. When several candidates
+// are requested, such a request is sent for each of them.
+func (p *Ollama) Request(ctx context.Context, changes, commits string, opts ...Option) (*http.Request, error) {
+	if (options{}).Apply(opts...).Candidates > 1 {
+		opts = append(opts[:len(opts):len(opts)], withSingleCandidate())
+	}
+
+	var opt, instructions = requestOptions(opts...)
+
+	return p.newRequest(ctx, instructions, changes, commits, opt)
+}
+
+// newRequest creates a new HTTP request for the Ollama chat API.
+func (p *Ollama) newRequest(
+	ctx context.Context,
+	instructions, changes, commits string,
+	o options,
+) (*http.Request, error) {
+	type (
+		message struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		}
+
+		// https://github.com/ollama/ollama/blob/main/docs/modelfile.md#valid-parameters-and-values
+		modelOptions struct {
+			Temperature float64 `json:"temperature"`
+			TopP        float64 `json:"top_p"`
+			NumPredict  int64   `json:"num_predict"`
+			NumCtx      int     `json:"num_ctx,omitempty"`
+		}
+	)
+
+	// https://github.com/ollama/ollama/blob/main/docs/api.md#generate-a-chat-completion
+	j, jErr := json.Marshal(struct {
+		Model     string       `json:"model"`
+		Messages  []message    `json:"messages"`
+		Stream    bool         `json:"stream"`
+		KeepAlive string       `json:"keep_alive,omitempty"`
+		Options   modelOptions `json:"options"`
+	}{
+		Model:     p.modelName,
+		Stream:    o.Stream != nil,
+		KeepAlive: p.keepAlive,
+		Options: modelOptions{
+			Temperature: o.temperature(),
+			TopP:        o.topP(),
+			NumPredict:  o.MaxOutputTokens,
+			NumCtx:      p.numCtx,
+		},
+		Messages: []message{
+			{Role: "system", Content: instructions},
+			{Role: roleUser, Content: wrapChanges(changes)},
+			{Role: roleUser, Content: wrapCommits(commits)},
+		},
+	})
+	if jErr != nil {
+		return nil, jErr
+	}
+
+	req, rErr := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/api/chat", bytes.NewReader(j))
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	return req, nil
+}
+
+// responseToError converts the response from the Ollama API to an error.
+func (p *Ollama) responseToError(resp *http.Response) error {
+	var body struct {
+		Error string `json:"error"`
+	}
+
+	retryable := resp.StatusCode == http.StatusTooManyRequests || // 429
+		resp.StatusCode == http.StatusInternalServerError || // 500
+		resp.StatusCode == http.StatusBadGateway || // 502
+		resp.StatusCode == http.StatusServiceUnavailable || // 503 - e.g. the server is overloaded
+		resp.StatusCode == http.StatusGatewayTimeout // 504
+
+	var err error
+
+	if dErr := json.NewDecoder(resp.Body).Decode(&body); dErr == nil && body.Error != "" {
+		err = fmt.Errorf("Ollama API error: %s (status code: %d)", body.Error, resp.StatusCode)
+	} else {
+		err = fmt.Errorf("unexpected Ollama API response status code: %d (%s)",
+			resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return &ollamaModelNotFoundError{
+			err: fmt.Errorf("%w; run `ollama pull %s` or enable the auto-pull", err, p.modelName),
+		}
+	}
+
+	if retryable {
+		return newRetryableError(err, retryAfterHeader(resp.Header))
+	}
+
+	return err
+}
+
+// parseResponse parses the (non-streamed) response from the Ollama chat API.
+func (p *Ollama) parseResponse(resp *http.Response) (string, Usage, error) {
+	var answer struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		ollamaUsage
+	}
+
+	if dErr := json.NewDecoder(resp.Body).Decode(&answer); dErr != nil {
+		return "", Usage{}, dErr
+	}
+
+	var text = strings.Trim(answer.Message.Content, "\n\t ")
+
+	if text == "" {
+		return "", Usage{}, errors.New("no response from the Ollama API")
+	}
+
+	return text, answer.usage(), nil
+}
+
+// parseStream parses the streamed response (newline-delimited JSON objects) from the Ollama chat API, writing
+// the text to w as it arrives.
+func (p *Ollama) parseStream(resp *http.Response, w io.Writer) (string, Usage, error) {
+	var (
+		b     strings.Builder
+		usage Usage
+	)
+
+	if err := readNDJSON(resp.Body, func(line []byte) error {
+		var chunk struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+			Error string `json:"error"`
+			Done  bool   `json:"done"`
+			ollamaUsage
+		}
+
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return err
+		}
+
+		if chunk.Error != "" { // the error may be sent in the middle of the stream
+			return fmt.Errorf("Ollama API error: %s", chunk.Error)
+		}
+
+		if chunk.Done { // the last object contains the statistics
+			usage = chunk.usage()
+		}
+
+		if text := chunk.Message.Content; text != "" {
+			b.WriteString(text)
+
+			if _, err := io.WriteString(w, text); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return "", Usage{}, err
+	}
+
+	if strings.TrimSpace(b.String()) == "" {
+		return "", Usage{}, errors.New("no response from the Ollama API")
+	}
+
+	return strings.Trim(b.String(), "\n\t "), usage, nil
+}
+
+// Pull downloads the model to the Ollama server, writing the progress to the pull output (if set).
+func (p *Ollama) Pull(ctx context.Context) error {
+	j, jErr := json.Marshal(struct {
+		Model  string `json:"model"`
+		Stream bool   `json:"stream"`
+	}{Model: p.modelName, Stream: true})
+	if jErr != nil {
+		return jErr
+	}
+
+	// https://github.com/ollama/ollama/blob/main/docs/api.md#pull-a-model
+	req, rErr := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/api/pull", bytes.NewReader(j))
+	if rErr != nil {
+		return rErr
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, rErr := p.httpClient.Do(req)
+	if rErr != nil {
+		return rErr
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to pull the model %s: %w", p.modelName, p.responseToError(resp))
+	}
+
+	var (
+		out          = p.pullOutput
+		lastStatus   string
+		lastProgress int64 = -1
+	)
+
+	if out == nil {
+		out = io.Discard
+	}
+
+	return readNDJSON(resp.Body, func(line []byte) error {
+		var progress struct {
+			Status    string `json:"status"`
+			Total     int64  `json:"total"`
+			Completed int64  `json:"completed"`
+			Error     string `json:"error"`
+		}
+
+		if err := json.Unmarshal(line, &progress); err != nil {
+			return err
+		}
+
+		if progress.Error != "" {
+			return fmt.Errorf("failed to pull the model %s: %s", p.modelName, progress.Error)
+		}
+
+		if progress.Total > 0 { // report the download progress every 10%
+			var percent = progress.Completed * 100 / progress.Total / 10 * 10 //nolint:mnd
+
+			if progress.Status == lastStatus && percent == lastProgress {
+				return nil
+			}
+
+			lastStatus, lastProgress = progress.Status, percent
+
+			_, err := fmt.Fprintf(out, "ollama: %s %s (%d%%)\n", p.modelName, progress.Status, percent)
+
+			return err
+		}
+
+		if progress.Status == lastStatus {
+			return nil
+		}
+
+		lastStatus, lastProgress = progress.Status, -1
+
+		_, err := fmt.Fprintf(out, "ollama: %s %s\n", p.modelName, progress.Status)
+
+		return err
+	})
+}
+
+// OllamaModel describes the model available on the Ollama server.
+type OllamaModel struct {
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"` // in bytes
+	ModifiedAt time.Time `json:"modified_at"`
+	Details    struct {
+		Family            string `json:"family"`
+		ParameterSize     string `json:"parameter_size"`
+		QuantizationLevel string `json:"quantization_level"`
+	} `json:"details"`
+}
+
+// Models returns the list of the models available on the Ollama server (the /api/tags endpoint).
+func (p *Ollama) Models(ctx context.Context) ([]OllamaModel, error) {
+	// https://github.com/ollama/ollama/blob/main/docs/api.md#list-local-models
+	req, rErr := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/api/tags", http.NoBody)
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	resp, rErr := p.httpClient.Do(req)
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, p.responseToError(resp)
+	}
+
+	var body struct {
+		Models []OllamaModel `json:"models"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	return body.Models, nil
+}
+
+// readNDJSON reads the newline-delimited JSON stream, calling fn for each non-empty line.
+func readNDJSON(r io.Reader, fn func(line []byte) error) error {
+	var scanner = bufio.NewScanner(r)
+
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024) //nolint:mnd // the lines may be long
+
+	for scanner.Scan() {
+		var line = bytes.TrimSpace(scanner.Bytes())
+
+		if len(line) == 0 {
+			continue
+		}
+
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
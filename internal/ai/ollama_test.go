@@ -0,0 +1,200 @@
+package ai_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+)
+
+func TestOllama_Query(t *testing.T) {
+	t.Parallel()
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+
+		var body struct {
+			Model     string `json:"model"`
+			Stream    bool   `json:"stream"`
+			KeepAlive string `json:"keep_alive"`
+			Messages  []struct {
+				Role, Content string
+			} `json:"messages"`
+			Options struct {
+				NumCtx     int   `json:"num_ctx"`
+				NumPredict int64 `json:"num_predict"`
+			} `json:"options"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+
+		if body.Model != "qwen" || body.Stream || body.KeepAlive != "10m" ||
+			body.Options.NumCtx != 16384 || body.Options.NumPredict != 123 {
+			t.Errorf("unexpected request: %+v", body)
+		}
+
+		if len(body.Messages) != 3 || body.Messages[0].Role != "system" ||
+			!strings.Contains(body.Messages[1].Content, "the diff") {
+			t.Errorf("unexpected messages: %+v", body.Messages)
+		}
+
+		_, _ = io.WriteString(w, `{"model":"qwen","message":{"role":"assistant","content":"\nfeat: Add Ollama\n"},"done":true}`)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	var p = ai.NewOllama("qwen",
+		ai.WithOllamaBaseURL(srv.URL+"/"),
+		ai.WithOllamaNumCtx(16384),
+		ai.WithOllamaKeepAlive("10m"),
+	)
+
+	resp, err := p.Query(context.Background(), "the diff", "the log", ai.WithMaxOutputTokens(123))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Answer != "feat: Add Ollama" {
+		t.Errorf("unexpected answer: %q", resp.Answer)
+	}
+}
+
+func TestOllama_Stream(t *testing.T) {
+	t.Parallel()
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		_, _ = io.WriteString(w, `{"message":{"content":"feat: Add"},"done":false}`+"\n"+
+			`{"message":{"content":" streaming"},"done":false}`+"\n\n"+
+			`{"message":{"content":""},"done":true}`+"\n",
+		)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	var out strings.Builder
+
+	resp, err := ai.NewOllama("qwen", ai.WithOllamaBaseURL(srv.URL)).
+		Query(context.Background(), "diff", "log", ai.WithStream(&out))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Answer != "feat: Add streaming" || out.String() != "feat: Add streaming" {
+		t.Errorf("unexpected answer: %q (streamed: %q)", resp.Answer, out.String())
+	}
+}
+
+func TestOllama_AutoPull(t *testing.T) {
+	t.Parallel()
+
+	var (
+		pulled atomic.Bool
+		srv    = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/chat":
+				if !pulled.Load() {
+					w.WriteHeader(http.StatusNotFound)
+					_, _ = io.WriteString(w, `{"error":"model \"qwen\" not found, try pulling it first"}`)
+
+					return
+				}
+
+				_, _ = io.WriteString(w, `{"message":{"content":"feat: Pulled"},"done":true}`)
+			case "/api/pull":
+				pulled.Store(true)
+
+				_, _ = io.WriteString(w, `{"status":"pulling manifest"}`+"\n"+
+					`{"status":"pulling abc","total":100,"completed":5}`+"\n"+
+					`{"status":"pulling abc","total":100,"completed":7}`+"\n"+
+					`{"status":"pulling abc","total":100,"completed":100}`+"\n"+
+					`{"status":"success"}`+"\n",
+				)
+			default:
+				t.Errorf("unexpected path: %s", r.URL.Path)
+			}
+		}))
+	)
+
+	t.Cleanup(srv.Close)
+
+	t.Run("disabled", func(t *testing.T) {
+		_, err := ai.NewOllama("qwen", ai.WithOllamaBaseURL(srv.URL)).Query(context.Background(), "diff", "log")
+		if err == nil || !strings.Contains(err.Error(), "ollama pull qwen") || ai.IsRetryableError(err) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		var progress strings.Builder
+
+		resp, err := ai.NewOllama("qwen", ai.WithOllamaBaseURL(srv.URL), ai.WithOllamaAutoPull(true, &progress)).
+			Query(context.Background(), "diff", "log")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.Answer != "feat: Pulled" {
+			t.Errorf("unexpected answer: %q", resp.Answer)
+		}
+
+		const wantProgress = "ollama: qwen pulling manifest\n" +
+			"ollama: qwen pulling abc (0%)\n" +
+			"ollama: qwen pulling abc (100%)\n" +
+			"ollama: qwen success\n"
+
+		if progress.String() != wantProgress {
+			t.Errorf("unexpected progress:\n%s", progress.String())
+		}
+	})
+}
+
+func TestOllama_Models(t *testing.T) {
+	t.Parallel()
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/api/tags" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+
+		_, _ = io.WriteString(w, `{"models":[{"name":"llama3.2:latest","size":2019393189,`+
+			`"modified_at":"2025-01-01T10:00:00Z","details":{"parameter_size":"3.2B","quantization_level":"Q4_K_M"}}]}`)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	models, err := ai.NewOllama("", ai.WithOllamaBaseURL(srv.URL)).Models(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(models) != 1 || models[0].Name != "llama3.2:latest" || models[0].Details.ParameterSize != "3.2B" {
+		t.Errorf("unexpected models: %+v", models)
+	}
+}
+
+func TestOllama_RetryableError(t *testing.T) {
+	t.Parallel()
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = io.WriteString(w, `{"error":"server busy, please try again"}`)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	_, err := ai.NewOllama("qwen", ai.WithOllamaBaseURL(srv.URL)).Query(context.Background(), "diff", "log")
+	if !ai.IsRetryableError(err) || !strings.Contains(err.Error(), "server busy") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 	"time"
@@ -17,6 +18,8 @@ type OpenAI struct {
 	httpClient        httpClient
 	apiKey, modelName string
 	baseURL           string
+	headers           map[string]string // extra request headers
+	extraBody         map[string]any    // extra request body fields
 }
 
 var _ Provider = (*OpenAI)(nil)
@@ -25,6 +28,8 @@ type (
 	openaiOptions struct {
 		HttpClient httpClient
 		BaseURL    string
+		Headers    map[string]string
+		ExtraBody  map[string]any
 	}
 
 	// OpenAIOption allows to customize the OpenAI provider.
@@ -42,6 +47,17 @@ func WithOpenAIBaseURL(url string) OpenAIOption {
 	return func(o *openaiOptions) { o.BaseURL = url }
 }
 
+// WithOpenAIHeaders sets the extra HTTP headers sent with each request (e.g. for proxies or API gateways).
+func WithOpenAIHeaders(h map[string]string) OpenAIOption {
+	return func(o *openaiOptions) { o.Headers = h }
+}
+
+// WithOpenAIExtraBody sets the extra fields merged into the request body (the existing fields are overridden,
+// and the fields with nil values are removed).
+func WithOpenAIExtraBody(b map[string]any) OpenAIOption {
+	return func(o *openaiOptions) { o.ExtraBody = b }
+}
+
 // NewOpenAI creates a new OpenAI provider.
 func NewOpenAI(apiKey, model string, opt ...OpenAIOption) *OpenAI { //nolint:dupl
 	var opts openaiOptions
@@ -54,6 +70,8 @@ func NewOpenAI(apiKey, model string, opt ...OpenAIOption) *OpenAI { //nolint:dup
 		httpClient: opts.HttpClient,
 		apiKey:     apiKey,
 		modelName:  model,
+		headers:    opts.Headers,
+		extraBody:  opts.ExtraBody,
 	}
 
 	if p.httpClient == nil { // set default HTTP client
@@ -75,14 +93,7 @@ func (p *OpenAI) Query(
 	changes, commits string,
 	opts ...Option,
 ) (*Response, error) {
-	var (
-		opt          = options{}.Apply(opts...)
-		instructions = GeneratePrompt(opts...)
-	)
-
-	if opt.MaxOutputTokens == 0 {
-		opt.MaxOutputTokens = defaultMaxOutputTokens // set default value
-	}
+	var opt, instructions = requestOptions(opts...)
 
 	req, rErr := p.newRequest(ctx, instructions, changes, commits, opt)
 	if rErr != nil {
@@ -100,22 +111,37 @@ func (p *OpenAI) Query(
 		return nil, p.responseToError(resp)
 	}
 
-	answer, aErr := p.parseResponse(resp)
+	var (
+		answers []string
+		usage   Usage
+		aErr    error
+	)
+
+	if opt.Stream != nil {
+		var answer string
+
+		answer, usage, aErr = p.parseStream(resp, opt.Stream)
+		answers = []string{answer}
+	} else {
+		answers, usage, aErr = p.parseResponse(resp)
+	}
+
 	if aErr != nil {
 		return nil, aErr
 	}
 
-	if opt.ShortMessageOnly {
-		var parts = strings.Split(answer, "\n")
+	var response = newResponse(instructions, answers, opt)
 
-		if len(parts) == 0 {
-			return nil, errors.New("no response from the OpenAI API")
-		}
+	response.Model, response.Usage = p.modelName, usage
 
-		return &Response{Prompt: instructions, Answer: parts[0]}, nil
-	}
+	return response, nil
+}
+
+// Request renders the HTTP request that [OpenAI.Query] would send, without sending it.
+func (p *OpenAI) Request(ctx context.Context, changes, commits string, opts ...Option) (*http.Request, error) {
+	var opt, instructions = requestOptions(opts...)
 
-	return &Response{Prompt: instructions, Answer: answer}, nil
+	return p.newRequest(ctx, instructions, changes, commits, opt)
 }
 
 // newRequest creates a new HTTP request for the OpenAI API.
@@ -131,19 +157,23 @@ func (p *OpenAI) newRequest(
 
 	// https://platform.openai.com/docs/api-reference/chat
 	j, jErr := json.Marshal(struct {
-		Model               string    `json:"model"`
-		Messages            []message `json:"messages"`
-		Store               bool      `json:"store"`
-		Temperature         float64   `json:"temperature"`
-		TopP                float64   `json:"top_p"`
-		HowMany             int       `json:"n"` // How many chat completion choices to generate for each input message
-		MaxCompletionTokens int64     `json:"max_completion_tokens"`
+		Model               string               `json:"model"`
+		Messages            []message            `json:"messages"`
+		Store               bool                 `json:"store"`
+		Stream              bool                 `json:"stream,omitempty"`
+		StreamOptions       *openAIStreamOptions `json:"stream_options,omitempty"`
+		Temperature         float64              `json:"temperature"`
+		TopP                float64              `json:"top_p"`
+		HowMany             int                  `json:"n"` // How many chat completion choices to generate
+		MaxCompletionTokens int64                `json:"max_completion_tokens"`
 	}{
 		Model:               p.modelName,
 		Store:               false,
-		Temperature:         0.1, //nolint:mnd
-		TopP:                0.1, //nolint:mnd
-		HowMany:             1,
+		Stream:              o.Stream != nil,
+		StreamOptions:       newOpenAIStreamOptions(o),
+		Temperature:         o.temperature(),
+		TopP:                o.topP(),
+		HowMany:             max(o.Candidates, 1),
 		MaxCompletionTokens: o.MaxOutputTokens,
 		Messages: []message{
 			{Role: "system", Content: instructions},
@@ -155,6 +185,10 @@ func (p *OpenAI) newRequest(
 		return nil, jErr
 	}
 
+	if j, jErr = mergeExtraBody(j, p.extraBody); jErr != nil {
+		return nil, jErr
+	}
+
 	base := openAIDefaultBaseURL
 	if p.baseURL != "" {
 		base = p.baseURL
@@ -170,7 +204,12 @@ func (p *OpenAI) newRequest(
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", p.apiKey))
+
+	if p.apiKey != "" { // local OpenAI-compatible servers may not require the API key
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", p.apiKey))
+	}
+
+	setExtraHeaders(req, p.headers)
 
 	return req, nil
 }
@@ -199,38 +238,106 @@ func (p *OpenAI) responseToError(resp *http.Response) error {
 			resp.StatusCode, http.StatusText(resp.StatusCode))
 	}
 
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return newAuthError(err)
+	}
+
 	if retryable {
-		return newRetryableError(err)
+		return newRetryableError(err, retryAfterHeader(resp.Header))
 	}
 
 	return err
 }
 
-// parseResponse parses the response from the OpenAI API.
-func (p *OpenAI) parseResponse(resp *http.Response) (string, error) {
+// parseResponse parses the response from the OpenAI API. Each choice is returned as a separate answer.
+func (p *OpenAI) parseResponse(resp *http.Response) ([]string, Usage, error) {
 	var answer struct {
 		Choices []struct {
 			Message struct {
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
+		Usage openAIUsage `json:"usage"`
 	}
 
 	if dErr := json.NewDecoder(resp.Body).Decode(&answer); dErr != nil {
-		return "", dErr
+		return nil, Usage{}, dErr
 	}
 
 	if len(answer.Choices) == 0 {
-		return "", errors.New("no response from the OpenAI API")
+		return nil, Usage{}, errors.New("no response from the OpenAI API")
 	}
 
 	var texts = make([]string, 0, len(answer.Choices))
 
 	for _, choice := range answer.Choices {
-		if choice.Message.Content != "" {
-			texts = append(texts, choice.Message.Content)
+		if text := strings.Trim(choice.Message.Content, "\n\t "); text != "" {
+			texts = append(texts, text)
+		}
+	}
+
+	if len(texts) == 0 {
+		return nil, Usage{}, errors.New("no response from the OpenAI API")
+	}
+
+	return texts, answer.Usage.usage(), nil
+}
+
+// parseStream parses the streamed (server-sent events) response from the OpenAI API, writing the text
+// to w as it arrives.
+func (p *OpenAI) parseStream(resp *http.Response, w io.Writer) (string, Usage, error) {
+	var (
+		b     strings.Builder
+		usage Usage
+	)
+
+	if err := readSSE(resp.Body, func(_, data string) error {
+		if data == "[DONE]" { // the stream is terminated by the "[DONE]" message
+			return nil
+		}
+
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Usage *openAIUsage `json:"usage"` // sent in the last chunk
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
 		}
+
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return err
+		}
+
+		if chunk.Error != nil { // the error may be sent in the middle of the stream
+			return fmt.Errorf("OpenAI API error: %s", chunk.Error.Message)
+		}
+
+		if chunk.Usage != nil {
+			usage = chunk.Usage.usage()
+		}
+
+		for _, choice := range chunk.Choices {
+			if text := choice.Delta.Content; text != "" {
+				b.WriteString(text)
+
+				if _, err := io.WriteString(w, text); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return "", Usage{}, err
+	}
+
+	if b.Len() == 0 {
+		return "", Usage{}, errors.New("no response from the OpenAI API")
 	}
 
-	return strings.Trim(strings.Join(texts, "\n"), "\n\t "), nil
+	return strings.Trim(b.String(), "\n\t "), usage, nil
 }
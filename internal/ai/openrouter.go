@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 	"time"
@@ -76,14 +77,7 @@ func (p *OpenRouter) Query(
 	changes, commits string,
 	opts ...Option,
 ) (*Response, error) {
-	var (
-		opt          = options{}.Apply(opts...)
-		instructions = GeneratePrompt(opts...)
-	)
-
-	if opt.MaxOutputTokens == 0 {
-		opt.MaxOutputTokens = defaultMaxOutputTokens // set default value
-	}
+	var opt, instructions = requestOptions(opts...)
 
 	req, rErr := p.newRequest(ctx, instructions, changes, commits, opt)
 	if rErr != nil {
@@ -101,22 +95,37 @@ func (p *OpenRouter) Query(
 		return nil, p.responseToError(resp)
 	}
 
-	answer, aErr := p.parseResponse(resp)
+	var (
+		answers []string
+		usage   Usage
+		aErr    error
+	)
+
+	if opt.Stream != nil {
+		var answer string
+
+		answer, usage, aErr = p.parseStream(resp, opt.Stream)
+		answers = []string{answer}
+	} else {
+		answers, usage, aErr = p.parseResponse(resp)
+	}
+
 	if aErr != nil {
 		return nil, aErr
 	}
 
-	if opt.ShortMessageOnly {
-		var parts = strings.Split(answer, "\n")
+	var response = newResponse(instructions, answers, opt)
 
-		if len(parts) == 0 {
-			return nil, errors.New("no response from the OpenRouter API")
-		}
+	response.Model, response.Usage = p.modelName, usage
 
-		return &Response{Prompt: instructions, Answer: parts[0]}, nil
-	}
+	return response, nil
+}
 
-	return &Response{Prompt: instructions, Answer: answer}, nil
+// Request renders the HTTP request that [OpenRouter.Query] would send, without sending it.
+func (p *OpenRouter) Request(ctx context.Context, changes, commits string, opts ...Option) (*http.Request, error) {
+	var opt, instructions = requestOptions(opts...)
+
+	return p.newRequest(ctx, instructions, changes, commits, opt)
 }
 
 // newRequest creates a new HTTP request for the OpenRouter API.
@@ -132,18 +141,22 @@ func (p *OpenRouter) newRequest(
 
 	// https://openrouter.ai/docs/api-reference/parameters
 	j, jErr := json.Marshal(struct {
-		Model       string    `json:"model"`
-		Messages    []message `json:"messages"`
-		Temperature float64   `json:"temperature"`
-		TopP        float64   `json:"top_p"`
-		HowMany     int       `json:"n"` // How many chat completion choices to generate for each input message
-		MaxTokens   int64     `json:"max_tokens"`
+		Model         string               `json:"model"`
+		Messages      []message            `json:"messages"`
+		Stream        bool                 `json:"stream,omitempty"`
+		StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+		Temperature   float64              `json:"temperature"`
+		TopP          float64              `json:"top_p"`
+		HowMany       int                  `json:"n"` // How many chat completion choices to generate for each input message
+		MaxTokens     int64                `json:"max_tokens"`
 	}{
-		Model:       p.modelName,
-		Temperature: 0.1, //nolint:mnd
-		TopP:        0.1, //nolint:mnd
-		HowMany:     1,
-		MaxTokens:   o.MaxOutputTokens,
+		Model:         p.modelName,
+		Stream:        o.Stream != nil,
+		StreamOptions: newOpenAIStreamOptions(o),
+		Temperature:   o.temperature(),
+		TopP:          o.topP(),
+		HowMany:       max(o.Candidates, 1),
+		MaxTokens:     o.MaxOutputTokens,
 		Messages: []message{
 			{Role: "system", Content: instructions},
 			{Role: roleUser, Content: wrapChanges(changes)},
@@ -199,38 +212,106 @@ func (p *OpenRouter) responseToError(resp *http.Response) error {
 			resp.StatusCode, http.StatusText(resp.StatusCode))
 	}
 
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return newAuthError(err)
+	}
+
 	if retryable {
-		return newRetryableError(err)
+		return newRetryableError(err, retryAfterHeader(resp.Header))
 	}
 
 	return err
 }
 
-// parseResponse parses the response from the OpenRouter API.
-func (p *OpenRouter) parseResponse(resp *http.Response) (string, error) {
+// parseResponse parses the response from the OpenRouter API. Each choice is returned as a separate answer.
+func (p *OpenRouter) parseResponse(resp *http.Response) ([]string, Usage, error) {
 	var answer struct {
 		Choices []struct {
 			Message struct {
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
+		Usage openAIUsage `json:"usage"`
 	}
 
 	if dErr := json.NewDecoder(resp.Body).Decode(&answer); dErr != nil {
-		return "", dErr
+		return nil, Usage{}, dErr
 	}
 
 	if len(answer.Choices) == 0 || len(answer.Choices[0].Message.Content) == 0 {
-		return "", errors.New("no content found")
+		return nil, Usage{}, errors.New("no content found")
 	}
 
 	var texts = make([]string, 0, len(answer.Choices))
 
 	for _, choice := range answer.Choices {
-		if text := choice.Message.Content; text != "" {
+		if text := strings.Trim(choice.Message.Content, "\n\t "); text != "" {
 			texts = append(texts, text)
 		}
 	}
 
-	return strings.Trim(strings.Join(texts, "\n"), "\n\t "), nil
+	if len(texts) == 0 {
+		return nil, Usage{}, errors.New("no content found")
+	}
+
+	return texts, answer.Usage.usage(), nil
+}
+
+// parseStream parses the streamed (server-sent events) response from the OpenRouter API, writing the text
+// to w as it arrives.
+func (p *OpenRouter) parseStream(resp *http.Response, w io.Writer) (string, Usage, error) {
+	var (
+		b     strings.Builder
+		usage Usage
+	)
+
+	if err := readSSE(resp.Body, func(_, data string) error {
+		if data == "[DONE]" { // the stream is terminated by the "[DONE]" message
+			return nil
+		}
+
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Usage *openAIUsage `json:"usage"` // sent in the last chunk
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return err
+		}
+
+		if chunk.Error != nil { // the error may be sent in the middle of the stream
+			return fmt.Errorf("OpenRouter API error: %s", chunk.Error.Message)
+		}
+
+		if chunk.Usage != nil {
+			usage = chunk.Usage.usage()
+		}
+
+		for _, choice := range chunk.Choices {
+			if text := choice.Delta.Content; text != "" {
+				b.WriteString(text)
+
+				if _, err := io.WriteString(w, text); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return "", Usage{}, err
+	}
+
+	if b.Len() == 0 {
+		return "", Usage{}, errors.New("no response from the OpenRouter API")
+	}
+
+	return strings.Trim(b.String(), "\n\t "), usage, nil
 }
@@ -1,11 +1,24 @@
 package ai
 
+import "io"
+
 type (
 	// options is a set of options that can be applied to the AI provider.
 	options struct {
 		ShortMessageOnly bool
 		EnableEmoji      bool
 		MaxOutputTokens  int64
+		Stream           io.Writer
+		Candidates       int
+		Instructions     string // overrides the generated prompt (system instructions), if set
+		Summarized       bool   // the changes are summaries of the diff parts, not the diff itself
+		CommitTypes      []string
+		BranchName       string
+		IssueKey         string // the issue key the subject must start with (see [WithIssueKey])
+		IssueKeyFormat   string
+		Language         string // language code (see [SupportedLanguages])
+
+		diverse bool // use the sampling parameters for diverse answers (see [withSingleCandidate])
 	}
 
 	// Option is a function that modifies the options.
@@ -21,6 +34,43 @@ func (o options) Apply(opts ...Option) options {
 	return o
 }
 
+// temperature returns the sampling temperature: the low value makes the answer more deterministic, while the
+// higher one is used to get different alternatives when multiple candidates are requested.
+func (o options) temperature() float64 {
+	if o.Candidates > 1 || o.diverse {
+		return 0.7 //nolint:mnd
+	}
+
+	return 0.1 //nolint:mnd
+}
+
+// commitTypes returns the allowed commit types.
+func (o options) commitTypes() []string {
+	if len(o.CommitTypes) > 0 {
+		return o.CommitTypes
+	}
+
+	return DefaultCommitTypes
+}
+
+// languageName returns the name of the commit message language ([DefaultLanguage], if not set or unsupported).
+func (o options) languageName() string {
+	if name := LanguageName(o.Language); name != "" {
+		return name
+	}
+
+	return LanguageName(DefaultLanguage)
+}
+
+// topP returns the nucleus sampling value (see [options.temperature] for the details).
+func (o options) topP() float64 {
+	if o.Candidates > 1 || o.diverse {
+		return 0.95 //nolint:mnd
+	}
+
+	return 0.1 //nolint:mnd
+}
+
 // WithShortMessageOnly forces the provider to return only the short commit message (usually the first line).
 func WithShortMessageOnly(on bool) Option { return func(o *options) { o.ShortMessageOnly = on } }
 
@@ -29,3 +79,41 @@ func WithEmoji(on bool) Option { return func(o *options) { o.EnableEmoji = on }
 
 // WithMaxOutputTokens sets the maximum number of tokens in the output.
 func WithMaxOutputTokens(max int64) Option { return func(o *options) { o.MaxOutputTokens = max } }
+
+// WithStream enables the streaming mode: the provider requests a streamed response and writes the answer
+// text to the writer as it arrives. The complete answer is returned in the [Response] as usual.
+func WithStream(w io.Writer) Option { return func(o *options) { o.Stream = w } }
+
+// WithCandidates sets the number of alternative messages to generate. The providers without native support
+// for multiple candidates send several requests in parallel. The streaming mode is disabled when more than one
+// candidate is requested.
+func WithCandidates(n int) Option { return func(o *options) { o.Candidates = n } }
+
+// withSingleCandidate is used for the parallel queries (one per candidate): each query must return a single
+// answer, but with the sampling parameters for diverse answers, and without streaming.
+func withSingleCandidate() Option {
+	return func(o *options) { o.Candidates, o.diverse, o.Stream = 1, true, nil }
+}
+
+// WithInstructions overrides the prompt (system instructions) that is generated by [GeneratePrompt].
+func WithInstructions(s string) Option { return func(o *options) { o.Instructions = s } }
+
+// WithSummarizedChanges tells the AI that the provided changes are the summaries of the diff parts (see
+// [GenerateSummaryPrompt]) rather than the diff itself.
+func WithSummarizedChanges(on bool) Option { return func(o *options) { o.Summarized = on } }
+
+// WithCommitTypes sets the allowed commit types (the [DefaultCommitTypes] are used if empty).
+func WithCommitTypes(types ...string) Option { return func(o *options) { o.CommitTypes = types } }
+
+// WithBranchName sets the current branch name (it's available in the prompt templates).
+func WithBranchName(name string) Option { return func(o *options) { o.BranchName = name } }
+
+// WithIssueKey asks the AI to start the subject line with the issue key, formatted according to the format (see
+// [FormatIssueKey]). The key is available in the prompt templates too.
+func WithIssueKey(key, format string) Option {
+	return func(o *options) { o.IssueKey, o.IssueKeyFormat = key, format }
+}
+
+// WithLanguage sets the language of the commit message (see [SupportedLanguages]). The Conventional Commit types
+// stay in English regardless of the language.
+func WithLanguage(code string) Option { return func(o *options) { o.Language = code } }
@@ -20,7 +20,39 @@ func wrapCommits(log string) string {
 	return fmt.Sprintf("%s\n%s\n%s", gitLogBegin, log, gitLogEnd)
 }
 
-func GeneratePrompt(opts ...Option) string { //nolint:funlen
+// instructionsFor returns the prompt (system instructions) for the given options: the instructions set by
+// [WithInstructions], or the generated ones otherwise.
+func instructionsFor(opts ...Option) string {
+	if o := (options{}).Apply(opts...); o.Instructions != "" {
+		return o.Instructions
+	}
+
+	return GeneratePrompt(opts...)
+}
+
+// GenerateSummaryPrompt generates the prompt for summarizing a part of a large diff. The summaries are used
+// later to generate the commit message (see [WithSummarizedChanges]).
+func GenerateSummaryPrompt() string {
+	var b strings.Builder
+
+	b.WriteString("## Role\n")
+	b.WriteString("You are an AI assistant that summarizes code changes for writing Git commit messages.\n")
+	b.WriteRune('\n')
+	b.WriteString("## Task\n")
+	_, _ = fmt.Fprintf(&b, "You will receive a **PART** of the `git diff` output wrapped between `%s` and `%s` "+
+		"(the whole diff is too large to be processed at once). Summarize the changes in this part.\n",
+		gitDiffBegin, gitDiffEnd)
+	b.WriteRune('\n')
+	b.WriteString("## Output\n")
+	b.WriteString("- A short bullet list in plain text: one bullet per meaningful change.\n")
+	b.WriteString("- Mention the affected files, and describe **WHAT** was changed and, when it's clear, **WHY**.\n")
+	b.WriteString("- Do not include code snippets or sensitive data (passwords, API keys, etc.).\n")
+	b.WriteString("- Ignore the commit history block, if any.\n")
+
+	return b.String()
+}
+
+func GeneratePrompt(opts ...Option) string { //nolint:funlen,gocyclo
 	var (
 		opt = options{}.Apply(opts...)
 		b   strings.Builder
@@ -46,8 +78,14 @@ func GeneratePrompt(opts ...Option) string { //nolint:funlen
 	{ // input
 		b.WriteString("## Input\n")
 		b.WriteString("You will receive:\n")
-		_, _ = fmt.Fprintf(&b, "1. The output of `git diff`, showing the staged changes, is wrapped between `%s` and `%s`.\n",
-			gitDiffBegin, gitDiffEnd)
+		if !opt.Summarized {
+			_, _ = fmt.Fprintf(&b, "1. The output of `git diff`, showing the staged changes, is wrapped between `%s` and `%s`.\n", //nolint:lll
+				gitDiffBegin, gitDiffEnd)
+		} else {
+			_, _ = fmt.Fprintf(&b, "1. The summaries of the staged changes are wrapped between `%s` and `%s`. The `git diff` "+
+				"was too large, so it was split into parts, and each part was summarized separately.\n",
+				gitDiffBegin, gitDiffEnd)
+		}
 		_, _ = fmt.Fprintf(&b, "2. The output of `git log`, presenting recent commit history, is wrapped between `%s` and `%s`.\n", //nolint:lll
 			gitLogBegin, gitLogEnd)
 		b.WriteRune('\n')
@@ -58,6 +96,11 @@ func GeneratePrompt(opts ...Option) string { //nolint:funlen
 		b.WriteString("Produce a commit message in plain text without wrapping it in backticks, ")
 		b.WriteString("quotes, or code blocks.\n")
 
+		if lang := opt.languageName(); lang != LanguageName(DefaultLanguage) {
+			_, _ = fmt.Fprintf(&b, "Write the commit message in **%s**, but keep the Conventional Commit `<type>` "+
+				"keywords in English, exactly as listed below.\n", lang)
+		}
+
 		b.WriteRune('\n')
 	}
 
@@ -67,21 +110,22 @@ func GeneratePrompt(opts ...Option) string { //nolint:funlen
 
 		const (
 			convFormat = "<type>(<scope>): <message>"
-			convDesc   = "- `<type>`: Choose from 'feat', 'fix', 'docs', 'style', 'refactor', 'perf', 'test', " +
-				"'ci', 'chore'. Carefully analyze **ALL** changes made across all files in the provided diff to " +
-				"determine the primary impact. Use the lowercase form of the type.\n" +
+			convDesc   = "- `<type>`: Choose from %s. Carefully analyze **ALL** changes made across all files in " +
+				"the provided diff to determine the primary impact. Use the lowercase form of the type.\n" +
 				"- `<scope>`: (optional but recommended) Specify the affected module (e.g., 'auth', " +
 				"'api', 'ui'). If the changes span multiple areas, omit this.\n" +
 				"- `<message>`: Use **imperative tone** (max 72 characters), describe **WHAT** " +
 				"was changed and **WHY**. No periods at the end of the message.\n"
 		)
 
+		var types = strings.Join(quoteAll(opt.commitTypes()), ", ")
+
 		b.WriteString("Follow the Conventional Commit format: `")
 
 		if !opt.EnableEmoji {
 			b.WriteString(convFormat)
 			b.WriteString("`\n")
-			b.WriteString(convDesc)
+			_, _ = fmt.Fprintf(&b, convDesc, types)
 		} else {
 			b.WriteString("<emoji> ")
 			b.WriteString(convFormat)
@@ -97,7 +141,12 @@ func GeneratePrompt(opts ...Option) string { //nolint:funlen
 			b.WriteString("  - 🔧, Add or update configuration files\n")
 			b.WriteString("  - 🌐, Internationalization and localization\n")
 			b.WriteString("  - 💡, Add or update comments in source code\n")
-			b.WriteString(convDesc)
+			_, _ = fmt.Fprintf(&b, convDesc, types)
+		}
+
+		if prefix := FormatIssueKey(opt.IssueKeyFormat, opt.IssueKey); prefix != "" {
+			_, _ = fmt.Fprintf(&b, "- The subject line **MUST** start with `%s` (the issue key of the branch), "+
+				"followed by a space and the format above.\n", prefix)
 		}
 
 		if !opt.ShortMessageOnly {
@@ -170,3 +219,149 @@ func GeneratePrompt(opts ...Option) string { //nolint:funlen
 
 	return b.String()
 }
+
+// GeneratePullRequestPrompt generates the prompt for describing a pull request: the title on the first line,
+// followed by the Markdown description (see [ParsePullRequest]). The diff is expected to be the changes of the
+// branch since its merge-base with the target branch, and the log - the subjects of the branch commits.
+func GeneratePullRequestPrompt(opts ...Option) string {
+	var (
+		opt = options{}.Apply(opts...)
+		b   strings.Builder
+	)
+
+	b.WriteString("## Role\n")
+	b.WriteString("You are an AI assistant specializing in writing pull request descriptions.\n")
+	b.WriteRune('\n')
+
+	b.WriteString("## Task\n")
+	b.WriteString("Generate a title and a description of the pull request based on the provided input.\n")
+	b.WriteRune('\n')
+
+	b.WriteString("## Input\n")
+	b.WriteString("You will receive:\n")
+
+	if !opt.Summarized {
+		_, _ = fmt.Fprintf(&b, "1. The output of `git diff`, showing all the changes of the branch, is wrapped "+
+			"between `%s` and `%s`.\n", gitDiffBegin, gitDiffEnd)
+	} else {
+		_, _ = fmt.Fprintf(&b, "1. The summaries of the branch changes are wrapped between `%s` and `%s`. The "+
+			"`git diff` was too large, so it was split into parts, and each part was summarized separately.\n",
+			gitDiffBegin, gitDiffEnd)
+	}
+
+	_, _ = fmt.Fprintf(&b, "2. The subjects of the branch commits (newest first) are wrapped between `%s` and `%s`.\n",
+		gitLogBegin, gitLogEnd)
+	b.WriteRune('\n')
+
+	b.WriteString("## Output\n")
+	b.WriteString("- The first line is the title: plain text, **imperative tone**, max 72 characters, no period at ")
+	_, _ = fmt.Fprintf(&b, "the end. Follow the Conventional Commit format `<type>(<scope>): <message>`, where "+
+		"`<type>` is one of %s.\n", strings.Join(quoteAll(opt.commitTypes()), ", "))
+	b.WriteString("- Then an empty line, and the description in Markdown with exactly these sections:\n")
+	b.WriteString("  - `## Summary` - one or two sentences on **WHAT** the pull request does and **WHY**.\n")
+	b.WriteString("  - `## Changes` - a bullet list of the notable changes, grouped by the area when it helps.\n")
+	b.WriteString("  - `## Testing` - how the changes were (or can be) verified, based on the tests in the diff; ")
+	b.WriteString("suggest the manual checks if there are no tests.\n")
+	b.WriteString("- Do not wrap the output in code blocks, and do not add any other text.\n")
+
+	if lang := opt.languageName(); lang != LanguageName(DefaultLanguage) {
+		_, _ = fmt.Fprintf(&b, "- Write the title and the description in **%s**, but keep the `<type>` keywords and "+
+			"the section headings in English.\n", lang)
+	}
+
+	b.WriteRune('\n')
+
+	b.WriteString("**Example**:\n")
+	b.WriteRune('\n')
+	b.WriteString("```\n")
+	b.WriteString("feat(api): Add rate-limiting to endpoints\n")
+	b.WriteRune('\n')
+	b.WriteString("## Summary\n")
+	b.WriteString("Protects the API from abuse by limiting the number of requests per client.\n")
+	b.WriteRune('\n')
+	b.WriteString("## Changes\n")
+	b.WriteString("- Add the rate-limiting middleware to all the endpoints\n")
+	b.WriteString("- Track the requests in Redis\n")
+	b.WriteString("- Make the limits configurable via environment variables\n")
+	b.WriteRune('\n')
+	b.WriteString("## Testing\n")
+	b.WriteString("- Unit tests for the middleware\n")
+	b.WriteString("- Manually: send more requests than allowed and expect HTTP 429\n")
+	b.WriteString("```\n")
+	b.WriteRune('\n')
+
+	b.WriteString("## Security\n")
+	b.WriteString("- Exclude sensitive data (passwords, API keys, personal information, etc.) ")
+	b.WriteString("or code snippets from the description.\n")
+
+	return b.String()
+}
+
+// GenerateReleaseNotesPrompt generates the prompt for summarizing the commits of a changelog section (e.g.
+// "Added") into the user-facing release notes. The commit messages are expected in the log block, while the diff
+// block is left empty.
+func GenerateReleaseNotesPrompt(section string, opts ...Option) string {
+	var (
+		opt = options{}.Apply(opts...)
+		b   strings.Builder
+	)
+
+	b.WriteString("## Role\n")
+	b.WriteString("You are an AI assistant specializing in writing release notes for the end users.\n")
+	b.WriteRune('\n')
+
+	b.WriteString("## Task\n")
+	_, _ = fmt.Fprintf(&b, "Summarize the commits of the **%s** section of the changelog into the release notes.\n",
+		section)
+	b.WriteRune('\n')
+
+	b.WriteString("## Input\n")
+	_, _ = fmt.Fprintf(&b, "The commit messages are wrapped between `%s` and `%s`, one per line (the body, if any, "+
+		"is indented below), each subject ends with the short commit hash in parentheses. The block between `%s` "+
+		"and `%s` is empty and must be ignored.\n",
+		gitLogBegin, gitLogEnd, gitDiffBegin, gitDiffEnd)
+	b.WriteRune('\n')
+
+	b.WriteString("## Output\n")
+	b.WriteString("- A Markdown bullet list (`- ` items), without headings or any other text.\n")
+	b.WriteString("- One bullet per user-visible change; merge the related commits into a single bullet.\n")
+	b.WriteString("- Describe the effect for the user, not the implementation; skip the purely internal changes.\n")
+	b.WriteString("- Keep each bullet short, and end it with the hashes of the commits in parentheses, ")
+	b.WriteString("e.g. `(1a2b3c4, 5d6e7f8)`.\n")
+	b.WriteString("- Mark the breaking changes with the `**BREAKING:**` prefix.\n")
+
+	if lang := opt.languageName(); lang != LanguageName(DefaultLanguage) {
+		_, _ = fmt.Fprintf(&b, "- Write the release notes in **%s**.\n", lang)
+	}
+
+	b.WriteRune('\n')
+
+	b.WriteString("## Security\n")
+	b.WriteString("- Exclude sensitive data (passwords, API keys, personal information, etc.) from the notes.\n")
+
+	return b.String()
+}
+
+// ParsePullRequest splits the answer generated using the [GeneratePullRequestPrompt] into the title and the
+// description. The Markdown heading marker and the "Title:" label are removed from the title, if the model added
+// them anyway.
+func ParsePullRequest(answer string) (title, body string) {
+	answer = strings.TrimSpace(answer)
+
+	if s, ok := strings.CutPrefix(answer, "```"); ok && strings.HasSuffix(s, "```") { // wrapped in a code block
+		_, s, _ = strings.Cut(s, "\n") // the language identifier, if any
+		answer = strings.TrimSpace(strings.TrimSuffix(s, "```"))
+	}
+
+	title, body, _ = strings.Cut(answer, "\n")
+
+	title = strings.TrimSpace(strings.TrimLeft(title, "# "))
+
+	const label = "title:"
+
+	if len(title) >= len(label) && strings.EqualFold(title[:len(label)], label) {
+		title = strings.TrimSpace(title[len(label):])
+	}
+
+	return title, strings.TrimSpace(body)
+}
@@ -90,6 +90,29 @@ func TestGeneratePrompt(t *testing.T) {
 				"Implemented rate-limiting", "Enforces request limits",
 			},
 		},
+		"language": {
+			giveOpts: []ai.Option{
+				ai.WithLanguage("DE"),
+				ai.WithCommitTypes("feature", "bugfix"),
+			},
+			wantContains: []string{
+				"Write the commit message in **German**", "`<type>` keywords in English",
+				"Choose from 'feature', 'bugfix'.",
+			},
+			wantNot: []string{"'feat'"},
+		},
+		"english": {
+			giveOpts: []ai.Option{ai.WithLanguage("en")},
+			wantNot:  []string{"Write the commit message in"},
+		},
+		"issue key": {
+			giveOpts:     []ai.Option{ai.WithIssueKey("PROJ-123", "[{key}]")},
+			wantContains: []string{"The subject line **MUST** start with `[PROJ-123]`"},
+		},
+		"empty issue key": {
+			giveOpts: []ai.Option{ai.WithIssueKey("", "{key}")},
+			wantNot:  []string{"issue key"},
+		},
 	} {
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
@@ -110,3 +133,110 @@ func TestGeneratePrompt(t *testing.T) {
 		})
 	}
 }
+
+func TestGeneratePullRequestPrompt(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		giveOpts     []ai.Option
+		wantContains []string
+		wantNot      []string
+	}{
+		"default": {
+			wantContains: []string{
+				"pull request descriptions", "all the changes of the branch", "subjects of the branch commits",
+				"`## Summary`", "`## Changes`", "`## Testing`", "'feat', 'fix'",
+			},
+			wantNot: []string{"summaries of the branch changes", "Write the title and the description in"},
+		},
+		"summarized in german": {
+			giveOpts: []ai.Option{
+				ai.WithSummarizedChanges(true),
+				ai.WithLanguage("de"),
+				ai.WithCommitTypes("feature"),
+			},
+			wantContains: []string{
+				"summaries of the branch changes", "Write the title and the description in **German**", "'feature'",
+			},
+			wantNot: []string{"all the changes of the branch", "'feat'"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got := ai.GeneratePullRequestPrompt(tc.giveOpts...)
+
+			for _, want := range tc.wantContains {
+				if !strings.Contains(got, want) {
+					t.Errorf("want %q to contain %q", got, want)
+				}
+			}
+
+			for _, want := range tc.wantNot {
+				if strings.Contains(got, want) {
+					t.Errorf("want %q to not contain %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateReleaseNotesPrompt(t *testing.T) {
+	t.Parallel()
+
+	var got = ai.GenerateReleaseNotesPrompt("Fixed", ai.WithLanguage("ja"))
+
+	for _, want := range []string{"**Fixed** section", "Markdown bullet list", "`**BREAKING:**`", "**Japanese**"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("want %q to contain %q", got, want)
+		}
+	}
+
+	if got = ai.GenerateReleaseNotesPrompt("Added"); strings.Contains(got, "Write the release notes in") {
+		t.Errorf("want %q to not contain the language instruction", got)
+	}
+}
+
+func TestParsePullRequest(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		giveAnswer string
+		wantTitle  string
+		wantBody   string
+	}{
+		"plain": {
+			giveAnswer: "feat: Add PR mode\n\n## Summary\nAdds it.\n",
+			wantTitle:  "feat: Add PR mode",
+			wantBody:   "## Summary\nAdds it.",
+		},
+		"heading and label": {
+			giveAnswer: "# Title: feat: Add PR mode\n## Summary\nAdds it.",
+			wantTitle:  "feat: Add PR mode",
+			wantBody:   "## Summary\nAdds it.",
+		},
+		"code block": {
+			giveAnswer: "```markdown\nfix: Handle errors\n\n## Changes\n- One\n```",
+			wantTitle:  "fix: Handle errors",
+			wantBody:   "## Changes\n- One",
+		},
+		"title only": {
+			giveAnswer: "  chore: Bump deps  ",
+			wantTitle:  "chore: Bump deps",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			title, body := ai.ParsePullRequest(tc.giveAnswer)
+
+			if title != tc.wantTitle {
+				t.Errorf("unexpected title: %q, want %q", title, tc.wantTitle)
+			}
+
+			if body != tc.wantBody {
+				t.Errorf("unexpected body: %q, want %q", body, tc.wantBody)
+			}
+		})
+	}
+}
@@ -3,6 +3,10 @@ package ai
 import (
 	"context"
 	"net/http"
+	"slices"
+	"strings"
+
+	"gh.tarampamp.am/describe-commit/internal/errgroup"
 )
 
 type (
@@ -10,15 +14,38 @@ type (
 	Provider interface {
 		// Query the remote provider for the given string.
 		Query(_ context.Context, changes, commits string, _ ...Option) (*Response, error)
+
+		// Request renders the HTTP request that Query would send for the same arguments, without sending it
+		// (e.g. to inspect the exact payload).
+		Request(_ context.Context, changes, commits string, _ ...Option) (*http.Request, error)
 	}
 
 	// Response is a response from an AI provider.
 	Response struct {
-		Prompt string // used to generate the answer
-		Answer string // what the AI responded
+		Prompt  string   // used to generate the answer
+		Answer  string   // what the AI responded (the first of the answers)
+		Answers []string // all the alternative answers (see [WithCandidates]), at least one
+		Model   string   // the model (or the deployment) that generated the answers
+		Usage   Usage    // the number of tokens used to generate the answers
+	}
+
+	// Usage is the number of tokens used by the request (zero if not reported by the provider).
+	Usage struct {
+		InputTokens  int64 // the prompt tokens, including the cached ones
+		OutputTokens int64 // the generated tokens, including the reasoning ("thinking") ones
+		CachedTokens int64 // the prompt tokens read from the cache
 	}
 )
 
+// Add returns the sum of the usages.
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		InputTokens:  u.InputTokens + other.InputTokens,
+		OutputTokens: u.OutputTokens + other.OutputTokens,
+		CachedTokens: u.CachedTokens + other.CachedTokens,
+	}
+}
+
 const (
 	defaultMaxOutputTokens = 500
 	roleUser               = "user"
@@ -35,11 +62,15 @@ const (
 	ProviderOpenAI     = "openai"
 	ProviderOpenRouter = "openrouter"
 	ProviderAnthropic  = "anthropic"
+	ProviderOllama     = "ollama"
+	ProviderAzure      = "azure-openai"
 )
 
 // SupportedProviders returns a list of supported AI providers.
 func SupportedProviders() []string {
-	return []string{ProviderGemini, ProviderOpenAI, ProviderOpenRouter, ProviderAnthropic}
+	return []string{
+		ProviderGemini, ProviderOpenAI, ProviderOpenRouter, ProviderAnthropic, ProviderOllama, ProviderAzure,
+	}
 }
 
 // IsProviderSupported checks if the given provider is supported.
@@ -52,3 +83,74 @@ func IsProviderSupported(s string) bool {
 
 	return false
 }
+
+// requestOptions applies the options of a single request, setting the defaults, and returns them along with
+// the instructions (the prompt).
+func requestOptions(opts ...Option) (options, string) {
+	var opt = options{}.Apply(opts...)
+
+	if opt.MaxOutputTokens == 0 {
+		opt.MaxOutputTokens = defaultMaxOutputTokens // set default value
+	}
+
+	if opt.Candidates > 1 {
+		opt.Stream = nil // the streaming mode is not supported for multiple candidates
+	}
+
+	return opt, instructionsFor(opts...)
+}
+
+// newResponse creates a new response with the given answers (must not be empty), applying the options (e.g.
+// the short message only mode). Duplicated answers are removed.
+func newResponse(prompt string, answers []string, o options) *Response {
+	var unique = make([]string, 0, len(answers))
+
+	for _, answer := range answers {
+		if o.ShortMessageOnly {
+			answer, _, _ = strings.Cut(answer, "\n")
+		}
+
+		if !slices.Contains(unique, answer) {
+			unique = append(unique, answer)
+		}
+	}
+
+	return &Response{Prompt: prompt, Answer: unique[0], Answers: unique}
+}
+
+// queryInParallel runs n queries concurrently and merges their answers into a single response. It's used by
+// the providers that do not support multiple candidates natively.
+func queryInParallel(ctx context.Context, n int, query func(context.Context) (*Response, error)) (*Response, error) {
+	var (
+		eg, _     = errgroup.New(ctx)
+		responses = make([]*Response, n)
+	)
+
+	for i := range n {
+		eg.Go(func(ctx context.Context) (err error) {
+			responses[i], err = query(ctx)
+
+			return
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	var (
+		answers = make([]string, 0, n)
+		usage   Usage
+	)
+
+	for _, r := range responses {
+		answers = append(answers, r.Answers...)
+		usage = usage.Add(r.Usage)
+	}
+
+	var response = newResponse(responses[0].Prompt, answers, options{})
+
+	response.Model, response.Usage = responses[0].Model, usage
+
+	return response, nil
+}
@@ -0,0 +1,109 @@
+package ai_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+)
+
+func TestProviders_Request(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		giveAnswer  string // the response body for the Query call
+		newProvider func(baseURL string) ai.Provider
+	}{
+		"openai": {
+			giveAnswer:  `{"choices":[{"message":{"content":"feat: Add"}}]}`,
+			newProvider: func(u string) ai.Provider { return ai.NewOpenAI("key", "model", ai.WithOpenAIBaseURL(u)) },
+		},
+		"openrouter": {
+			giveAnswer:  `{"choices":[{"message":{"content":"feat: Add"}}]}`,
+			newProvider: func(u string) ai.Provider { return ai.NewOpenRouter("key", "model", ai.WithOpenRouterBaseURL(u)) },
+		},
+		"azure-openai": {
+			giveAnswer:  `{"choices":[{"message":{"content":"feat: Add"}}]}`,
+			newProvider: func(u string) ai.Provider { return ai.NewAzureOpenAI("key", u, "deployment") },
+		},
+		"anthropic": {
+			giveAnswer:  `{"content":[{"type":"text","text":"feat: Add"}]}`,
+			newProvider: func(u string) ai.Provider { return ai.NewAnthropic("key", "model", ai.WithAnthropicBaseURL(u)) },
+		},
+		"gemini": {
+			giveAnswer:  `{"candidates":[{"content":{"parts":[{"text":"feat: Add"}]}}]}`,
+			newProvider: func(u string) ai.Provider { return ai.NewGemini("key", "model", ai.WithGeminiBaseURL(u)) },
+		},
+		"ollama": {
+			giveAnswer:  `{"message":{"content":"feat: Add"},"done":true}`,
+			newProvider: func(u string) ai.Provider { return ai.NewOllama("model", ai.WithOllamaBaseURL(u)) },
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			type sentRequest struct {
+				*http.Request
+				body []byte
+			}
+
+			var (
+				received = make(chan sentRequest, 1)
+				srv      = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					received <- sentRequest{Request: r, body: must(io.ReadAll(r.Body))}
+
+					_, _ = io.WriteString(w, tc.giveAnswer)
+				}))
+				provider = tc.newProvider(srv.URL)
+				opts     = []ai.Option{ai.WithMaxOutputTokens(123), ai.WithShortMessageOnly(true)}
+			)
+
+			t.Cleanup(srv.Close)
+
+			req, err := provider.Request(context.Background(), "the diff", "the log", opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(received) > 0 {
+				t.Fatal("the request must not be sent")
+			}
+
+			if _, err = provider.Query(context.Background(), "the diff", "the log", opts...); err != nil {
+				t.Fatal(err)
+			}
+
+			var sent = <-received
+
+			if req.Method != sent.Method || req.URL.RequestURI() != sent.URL.RequestURI() {
+				t.Errorf("unexpected request: %s %s, sent %s %s", req.Method, req.URL, sent.Method, sent.URL)
+			}
+
+			for header := range sent.Header {
+				switch header {
+				case "User-Agent", "Accept-Encoding", "Content-Length": // set by the HTTP client
+					continue
+				}
+
+				if got, want := req.Header.Get(header), sent.Header.Get(header); got != want {
+					t.Errorf("header %s: %q, sent %q", header, got, want)
+				}
+			}
+
+			if body := must(io.ReadAll(req.Body)); string(body) != string(sent.body) {
+				t.Errorf("unexpected body:\n%s\nsent:\n%s", body, sent.body)
+			}
+		})
+	}
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
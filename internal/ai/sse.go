@@ -0,0 +1,67 @@
+package ai
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// readSSE reads the server-sent events stream (https://html.spec.whatwg.org/multipage/server-sent-events.html)
+// and calls fn for each event with the event name (empty if not specified) and the data. Reading stops when
+// the stream ends or fn returns an error (the error is returned as-is).
+func readSSE(r io.Reader, fn func(event, data string) error) error {
+	var (
+		scanner = bufio.NewScanner(r)
+		event   string
+		data    strings.Builder
+	)
+
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024) //nolint:mnd // some events may be quite large
+
+	var dispatch = func() error {
+		defer func() { event = ""; data.Reset() }()
+
+		if data.Len() == 0 {
+			return nil
+		}
+
+		return fn(event, data.String())
+	}
+
+	for scanner.Scan() {
+		var line = strings.TrimSuffix(scanner.Text(), "\r")
+
+		if line == "" { // an empty line dispatches the event
+			if err := dispatch(); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			continue // comment (e.g. keep-alive)
+		}
+
+		var field, value, _ = strings.Cut(line, ":")
+
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			if data.Len() > 0 {
+				data.WriteRune('\n')
+			}
+
+			data.WriteString(value)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return dispatch() // the last event may be not terminated by an empty line
+}
@@ -0,0 +1,131 @@
+package ai_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+)
+
+func TestProviders_Stream(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		giveBody    string // the streamed response body
+		newProvider func(baseURL string) ai.Provider
+		wantPath    string
+	}{
+		"anthropic": {
+			giveBody: "event: message_start\n" +
+				"data: {\"type\":\"message_start\"}\n\n" +
+				"event: content_block_delta\n" +
+				"data: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"feat: Add\"}}\n\n" +
+				": keep-alive\n\n" +
+				"event: content_block_delta\n" +
+				"data: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\" streaming\\n\\nbody\"}}\n\n" +
+				"event: message_stop\n" +
+				"data: {\"type\":\"message_stop\"}\n\n",
+			newProvider: func(u string) ai.Provider { return ai.NewAnthropic("key", "model", ai.WithAnthropicBaseURL(u)) },
+			wantPath:    "/v1/messages",
+		},
+		"openai": {
+			giveBody: "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\"}}]}\n\n" +
+				"data: {\"choices\":[{\"delta\":{\"content\":\"feat: Add\"}}]}\n\n" +
+				"data: {\"choices\":[{\"delta\":{\"content\":\" streaming\\n\\nbody\"}}]}\n\n" +
+				"data: [DONE]\n\n",
+			newProvider: func(u string) ai.Provider { return ai.NewOpenAI("key", "model", ai.WithOpenAIBaseURL(u)) },
+			wantPath:    "/v1/chat/completions",
+		},
+		"azure-openai": {
+			giveBody: "data: {\"choices\":[],\"prompt_filter_results\":[{\"prompt_index\":0}]}\n\n" +
+				"data: {\"choices\":[{\"delta\":{\"content\":\"feat: Add\"}}]}\n\n" +
+				"data: {\"choices\":[{\"delta\":{\"content\":\" streaming\\n\\nbody\"}}]}\n\n" +
+				"data: [DONE]\n\n",
+			newProvider: func(u string) ai.Provider { return ai.NewAzureOpenAI("key", u, "deployment") },
+			wantPath:    "/openai/deployments/deployment/chat/completions",
+		},
+		"openrouter": {
+			giveBody: ": OPENROUTER PROCESSING\n\n" +
+				"data: {\"choices\":[{\"delta\":{\"content\":\"feat: Add\"}}]}\n\n" +
+				"data: {\"choices\":[{\"delta\":{\"content\":\" streaming\\n\\nbody\"}}]}\n\n" +
+				"data: [DONE]\n\n",
+			newProvider: func(u string) ai.Provider { return ai.NewOpenRouter("key", "model", ai.WithOpenRouterBaseURL(u)) },
+			wantPath:    "/api/v1/chat/completions",
+		},
+		"gemini": {
+			giveBody: "data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"feat: Add\"}]}}]}\r\n\r\n" +
+				"data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\" streaming\\n\\nbody\"}]}}]}\r\n\r\n",
+			newProvider: func(u string) ai.Provider { return ai.NewGemini("key", "model", ai.WithGeminiBaseURL(u)) },
+			wantPath:    "/v1beta/models/model:streamGenerateContent",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tc.wantPath {
+					t.Errorf("unexpected path: %s", r.URL.Path)
+				}
+
+				if name != "gemini" { // gemini uses the dedicated endpoint instead of the body property
+					var body struct {
+						Stream bool `json:"stream"`
+					}
+
+					if err := json.NewDecoder(r.Body).Decode(&body); err != nil || !body.Stream {
+						t.Errorf("streaming is not requested (err: %v)", err)
+					}
+				}
+
+				w.Header().Set("Content-Type", "text/event-stream")
+				_, _ = io.WriteString(w, tc.giveBody)
+			}))
+
+			t.Cleanup(srv.Close)
+
+			var streamed strings.Builder
+
+			resp, err := tc.newProvider(srv.URL).Query(context.Background(), "diff", "log", ai.WithStream(&streamed))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if want := "feat: Add streaming\n\nbody"; resp.Answer != want {
+				t.Errorf("unexpected answer: %q, want %q", resp.Answer, want)
+			}
+
+			if want := "feat: Add streaming\n\nbody"; streamed.String() != want {
+				t.Errorf("unexpected streamed output: %q, want %q", streamed.String(), want)
+			}
+		})
+	}
+
+	t.Run("error in the middle of the stream", func(t *testing.T) {
+		t.Parallel()
+
+		var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = io.WriteString(w, "event: content_block_delta\n"+
+				"data: {\"delta\":{\"type\":\"text_delta\",\"text\":\"feat\"}}\n\n"+
+				"event: error\n"+
+				"data: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n",
+			)
+		}))
+
+		t.Cleanup(srv.Close)
+
+		_, err := ai.NewAnthropic("key", "model", ai.WithAnthropicBaseURL(srv.URL)).
+			Query(context.Background(), "diff", "log", ai.WithStream(io.Discard))
+		if err == nil || !strings.Contains(err.Error(), "Overloaded") {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !ai.IsRetryableError(err) {
+			t.Error("the overloaded error should be retryable")
+		}
+	})
+}
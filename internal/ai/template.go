@@ -0,0 +1,92 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// DefaultCommitTypes are the Conventional Commits types the AI chooses from by default.
+var DefaultCommitTypes = []string{ //nolint:gochecknoglobals
+	"feat", "fix", "docs", "style", "refactor", "perf", "test", "ci", "chore",
+}
+
+// PromptData is the data available in the custom prompt templates (see [NewPromptTemplate]).
+type PromptData struct {
+	Emoji            bool     // emoji are enabled
+	ShortMessageOnly bool     // only the subject line should be generated
+	Summarized       bool     // the changes are summaries of the diff parts, not the diff itself
+	Language         string   // the language name of the commit message (e.g. "English")
+	BranchName       string   // the current branch name (empty for the detached HEAD)
+	IssueKey         string   // the issue key the subject must start with (empty if not set)
+	IssueKeyPrefix   string   // the formatted issue key (e.g. "[PROJ-123]"), see [FormatIssueKey]
+	Types            []string // the allowed commit types
+
+	// the markers the changes and the commit history are wrapped between
+	DiffBegin, DiffEnd string
+	LogBegin, LogEnd   string
+}
+
+// NewPromptData returns the template data for the given options.
+func NewPromptData(opts ...Option) PromptData {
+	var opt = options{}.Apply(opts...)
+
+	return PromptData{
+		Emoji:            opt.EnableEmoji,
+		ShortMessageOnly: opt.ShortMessageOnly,
+		Summarized:       opt.Summarized,
+		Language:         opt.languageName(),
+		BranchName:       opt.BranchName,
+		IssueKey:         opt.IssueKey,
+		IssueKeyPrefix:   FormatIssueKey(opt.IssueKeyFormat, opt.IssueKey),
+		Types:            opt.commitTypes(),
+		DiffBegin:        gitDiffBegin,
+		DiffEnd:          gitDiffEnd,
+		LogBegin:         gitLogBegin,
+		LogEnd:           gitLogEnd,
+	}
+}
+
+// NewPromptTemplate parses the prompt template (Go text/template syntax). Besides the built-in template
+// functions, "join", "lower", "upper" and "quote" (wraps each item in single quotes) are available:
+//
+//	Choose the type from: {{ join (quote .Types) ", " }}
+//	{{ if .Emoji }}Start the message with an emoji.{{ end }}
+func NewPromptTemplate(text string) (*template.Template, error) {
+	t, err := template.New("prompt").
+		Option("missingkey=error").
+		Funcs(template.FuncMap{
+			"join":  strings.Join,
+			"lower": strings.ToLower,
+			"upper": strings.ToUpper,
+			"quote": quoteAll,
+		}).
+		Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the prompt template: %w", err)
+	}
+
+	return t, nil
+}
+
+// RenderPrompt renders the prompt template with the given data.
+func RenderPrompt(t *template.Template, data PromptData) (string, error) {
+	var b strings.Builder
+
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render the prompt template: %w", err)
+	}
+
+	return b.String(), nil
+}
+
+// quoteAll wraps each item in single quotes.
+func quoteAll(items []string) []string {
+	var quoted = make([]string, len(items))
+
+	for i, item := range items {
+		quoted[i] = "'" + item + "'"
+	}
+
+	return quoted
+}
@@ -0,0 +1,81 @@
+package ai_test
+
+import (
+	"strings"
+	"testing"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+)
+
+func TestRenderPrompt(t *testing.T) {
+	t.Parallel()
+
+	const text = `{{ if .BranchName }}Branch: {{ .BranchName }}{{ end }}
+{{- if .IssueKey }} ({{ .IssueKey }}, {{ .IssueKeyPrefix }}){{ end }}
+Types: {{ join (quote .Types) ", " }}
+{{- if .Emoji }}
+Emoji: yes{{ end }}
+{{- if .ShortMessageOnly }}
+Short: yes{{ end }}
+Diff: {{ .DiffBegin }}`
+
+	for name, tc := range map[string]struct {
+		giveOpts []ai.Option
+		want     string
+	}{
+		"defaults": {
+			want: "\nTypes: 'feat', 'fix', 'docs', 'style', 'refactor', 'perf', 'test', 'ci', 'chore'\n" +
+				"Diff: [---GIT-DIFF-BEGIN---]",
+		},
+		"all set": {
+			giveOpts: []ai.Option{
+				ai.WithBranchName("feature/ABC-123"),
+				ai.WithIssueKey("ABC-123", "{key}:"),
+				ai.WithCommitTypes("feature", "bugfix"),
+				ai.WithEmoji(true),
+				ai.WithShortMessageOnly(true),
+			},
+			want: "Branch: feature/ABC-123 (ABC-123, ABC-123:)\nTypes: 'feature', 'bugfix'\nEmoji: yes\nShort: yes\n" +
+				"Diff: [---GIT-DIFF-BEGIN---]",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpl, err := ai.NewPromptTemplate(text)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ai.RenderPrompt(tmpl, ai.NewPromptData(tc.giveOpts...))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tc.want {
+				t.Errorf("expected:\n%q\ngot:\n%q", tc.want, got)
+			}
+		})
+	}
+
+	t.Run("parse error", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := ai.NewPromptTemplate("{{ .Emoji "); err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		t.Parallel()
+
+		tmpl, err := ai.NewPromptTemplate("{{ .Unknown }}")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = ai.RenderPrompt(tmpl, ai.NewPromptData()); err == nil || !strings.Contains(err.Error(), "Unknown") {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package ai
+
+type (
+	// openAIUsage is the token usage reported by the OpenAI-compatible APIs (OpenAI, OpenRouter, Azure OpenAI).
+	openAIUsage struct {
+		PromptTokens        int64 `json:"prompt_tokens"` // including the cached ones
+		CompletionTokens    int64 `json:"completion_tokens"`
+		PromptTokensDetails struct {
+			CachedTokens int64 `json:"cached_tokens"`
+		} `json:"prompt_tokens_details"`
+	}
+
+	// openAIStreamOptions asks the OpenAI-compatible APIs to send the usage in the last chunk of the stream.
+	openAIStreamOptions struct {
+		IncludeUsage bool `json:"include_usage"`
+	}
+
+	// anthropicUsage is the token usage reported by the Anthropic API.
+	anthropicUsage struct {
+		InputTokens              int64 `json:"input_tokens"` // excluding the cached ones
+		OutputTokens             int64 `json:"output_tokens"`
+		CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+		CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+	}
+
+	// geminiUsage is the token usage reported by the Gemini API.
+	geminiUsage struct {
+		PromptTokenCount        int64 `json:"promptTokenCount"` // including the cached ones
+		CandidatesTokenCount    int64 `json:"candidatesTokenCount"`
+		ThoughtsTokenCount      int64 `json:"thoughtsTokenCount"` // billed as the output tokens
+		CachedContentTokenCount int64 `json:"cachedContentTokenCount"`
+	}
+
+	// ollamaUsage is the token usage reported by the Ollama API (in the last message).
+	ollamaUsage struct {
+		PromptEvalCount int64 `json:"prompt_eval_count"`
+		EvalCount       int64 `json:"eval_count"`
+	}
+)
+
+// newOpenAIStreamOptions returns the stream options for the request (nil if the streaming is not requested).
+func newOpenAIStreamOptions(o options) *openAIStreamOptions {
+	if o.Stream == nil {
+		return nil
+	}
+
+	return &openAIStreamOptions{IncludeUsage: true}
+}
+
+func (u openAIUsage) usage() Usage {
+	return Usage{
+		InputTokens:  u.PromptTokens,
+		OutputTokens: u.CompletionTokens,
+		CachedTokens: u.PromptTokensDetails.CachedTokens,
+	}
+}
+
+func (u anthropicUsage) usage() Usage {
+	return Usage{
+		InputTokens:  u.InputTokens + u.CacheReadInputTokens + u.CacheCreationInputTokens,
+		OutputTokens: u.OutputTokens,
+		CachedTokens: u.CacheReadInputTokens,
+	}
+}
+
+func (u geminiUsage) usage() Usage {
+	return Usage{
+		InputTokens:  u.PromptTokenCount,
+		OutputTokens: u.CandidatesTokenCount + u.ThoughtsTokenCount,
+		CachedTokens: u.CachedContentTokenCount,
+	}
+}
+
+func (u ollamaUsage) usage() Usage {
+	return Usage{InputTokens: u.PromptEvalCount, OutputTokens: u.EvalCount}
+}
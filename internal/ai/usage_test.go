@@ -0,0 +1,164 @@
+package ai_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+)
+
+func TestProviders_Usage(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		giveBody    string
+		giveStream  bool
+		newProvider func(baseURL string) ai.Provider
+		wantRequest string // a part of the request body
+		wantModel   string
+		wantUsage   ai.Usage
+	}{
+		"openai": {
+			giveBody: `{"choices":[{"message":{"content":"feat: Add usage"}}],
+				"usage":{"prompt_tokens":1200,"completion_tokens":30,"prompt_tokens_details":{"cached_tokens":1024}}}`,
+			newProvider: func(u string) ai.Provider { return ai.NewOpenAI("key", "gpt-x", ai.WithOpenAIBaseURL(u)) },
+			wantModel:   "gpt-x",
+			wantUsage:   ai.Usage{InputTokens: 1200, OutputTokens: 30, CachedTokens: 1024},
+		},
+		"openai (stream)": {
+			giveBody: "data: {\"choices\":[{\"delta\":{\"content\":\"feat: Add usage\"}}],\"usage\":null}\n\n" +
+				"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":100,\"completion_tokens\":5}}\n\n" +
+				"data: [DONE]\n\n",
+			giveStream:  true,
+			newProvider: func(u string) ai.Provider { return ai.NewOpenAI("key", "gpt-x", ai.WithOpenAIBaseURL(u)) },
+			wantRequest: `"stream_options":{"include_usage":true}`,
+			wantModel:   "gpt-x",
+			wantUsage:   ai.Usage{InputTokens: 100, OutputTokens: 5},
+		},
+		"openrouter": {
+			giveBody:    `{"choices":[{"message":{"content":"feat: Add usage"}}],"usage":{"prompt_tokens":10,"completion_tokens":2}}`,
+			newProvider: func(u string) ai.Provider { return ai.NewOpenRouter("key", "a/b", ai.WithOpenRouterBaseURL(u)) },
+			wantModel:   "a/b",
+			wantUsage:   ai.Usage{InputTokens: 10, OutputTokens: 2},
+		},
+		"azure-openai": {
+			giveBody:    `{"choices":[{"message":{"content":"feat: Add usage"}}],"usage":{"prompt_tokens":7,"completion_tokens":3}}`,
+			newProvider: func(u string) ai.Provider { return ai.NewAzureOpenAI("key", u, "my-gpt") },
+			wantModel:   "my-gpt",
+			wantUsage:   ai.Usage{InputTokens: 7, OutputTokens: 3},
+		},
+		"azure-openai (stream)": {
+			giveBody: "data: {\"choices\":[{\"delta\":{\"content\":\"feat: Add usage\"}}]}\n\n" +
+				"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":7,\"completion_tokens\":3}}\n\n" +
+				"data: [DONE]\n\n",
+			giveStream:  true,
+			newProvider: func(u string) ai.Provider { return ai.NewAzureOpenAI("key", u, "my-gpt") },
+			wantRequest: `"stream_options":{"include_usage":true}`,
+			wantModel:   "my-gpt",
+			wantUsage:   ai.Usage{InputTokens: 7, OutputTokens: 3},
+		},
+		"anthropic": {
+			giveBody: `{"content":[{"type":"text","text":"feat: Add usage"}],"usage":{"input_tokens":50,
+				"cache_creation_input_tokens":20,"cache_read_input_tokens":1000,"output_tokens":40}}`,
+			newProvider: func(u string) ai.Provider { return ai.NewAnthropic("key", "claude-x", ai.WithAnthropicBaseURL(u)) },
+			wantModel:   "claude-x",
+			wantUsage:   ai.Usage{InputTokens: 1070, OutputTokens: 40, CachedTokens: 1000},
+		},
+		"anthropic (stream)": {
+			giveBody: "event: message_start\n" +
+				"data: {\"type\":\"message_start\",\"message\":{\"usage\":{\"input_tokens\":25,\"cache_read_input_tokens\":5,\"output_tokens\":1}}}\n\n" +
+				"event: content_block_delta\n" +
+				"data: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"feat: Add usage\"}}\n\n" +
+				"event: message_delta\n" +
+				"data: {\"type\":\"message_delta\",\"usage\":{\"output_tokens\":15}}\n\n" +
+				"event: message_stop\n" +
+				"data: {\"type\":\"message_stop\"}\n\n",
+			giveStream:  true,
+			newProvider: func(u string) ai.Provider { return ai.NewAnthropic("key", "claude-x", ai.WithAnthropicBaseURL(u)) },
+			wantModel:   "claude-x",
+			wantUsage:   ai.Usage{InputTokens: 30, OutputTokens: 15, CachedTokens: 5},
+		},
+		"gemini": {
+			giveBody: `{"candidates":[{"content":{"parts":[{"text":"feat: Add usage"}]}}],"usageMetadata":{
+				"promptTokenCount":300,"candidatesTokenCount":20,"thoughtsTokenCount":80,"cachedContentTokenCount":256}}`,
+			newProvider: func(u string) ai.Provider { return ai.NewGemini("key", "gemini-x", ai.WithGeminiBaseURL(u)) },
+			wantModel:   "gemini-x",
+			wantUsage:   ai.Usage{InputTokens: 300, OutputTokens: 100, CachedTokens: 256},
+		},
+		"ollama": {
+			giveBody:    `{"message":{"content":"feat: Add usage"},"done":true,"prompt_eval_count":90,"eval_count":12}`,
+			newProvider: func(u string) ai.Provider { return ai.NewOllama("qwen", ai.WithOllamaBaseURL(u)) },
+			wantModel:   "qwen",
+			wantUsage:   ai.Usage{InputTokens: 90, OutputTokens: 12},
+		},
+		"ollama (stream)": {
+			giveBody: `{"message":{"content":"feat: Add usage"},"done":false}` + "\n" +
+				`{"message":{"content":""},"done":true,"prompt_eval_count":90,"eval_count":12}` + "\n",
+			giveStream:  true,
+			newProvider: func(u string) ai.Provider { return ai.NewOllama("qwen", ai.WithOllamaBaseURL(u)) },
+			wantModel:   "qwen",
+			wantUsage:   ai.Usage{InputTokens: 90, OutputTokens: 12},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if body, _ := io.ReadAll(r.Body); !strings.Contains(string(body), tc.wantRequest) {
+					t.Errorf("the request %s does not contain %s", body, tc.wantRequest)
+				}
+
+				_, _ = io.WriteString(w, tc.giveBody)
+			}))
+
+			t.Cleanup(srv.Close)
+
+			var opts []ai.Option
+
+			if tc.giveStream {
+				opts = append(opts, ai.WithStream(io.Discard))
+			}
+
+			resp, err := tc.newProvider(srv.URL).Query(context.Background(), "diff", "log", opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.Answer != "feat: Add usage" {
+				t.Errorf("unexpected answer: %q", resp.Answer)
+			}
+
+			if resp.Model != tc.wantModel {
+				t.Errorf("unexpected model: %q, want %q", resp.Model, tc.wantModel)
+			}
+
+			if resp.Usage != tc.wantUsage {
+				t.Errorf("unexpected usage: %+v, want %+v", resp.Usage, tc.wantUsage)
+			}
+		})
+	}
+}
+
+func TestProviders_UsageOfCandidates(t *testing.T) {
+	t.Parallel()
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"content":[{"type":"text","text":"feat: Add"}],"usage":{"input_tokens":10,"output_tokens":3}}`)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	resp, err := ai.NewAnthropic("key", "claude-x", ai.WithAnthropicBaseURL(srv.URL)).
+		Query(context.Background(), "diff", "log", ai.WithCandidates(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := (ai.Usage{InputTokens: 30, OutputTokens: 9}); resp.Usage != want {
+		t.Errorf("unexpected usage: %+v, want %+v", resp.Usage, want)
+	}
+}
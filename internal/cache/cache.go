@@ -0,0 +1,173 @@
+// Package cache implements a simple on-disk cache with the entries expiring after the TTL.
+//
+// Each entry is stored in a separate JSON file, named by the key. The files are written to a temporary file
+// first and then renamed, so the concurrent processes (e.g. several git hooks running at once) never read a
+// partially written entry, and the last writer wins.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	entryExt = ".json"
+	tempExt  = ".tmp"
+)
+
+// Cache is the on-disk cache. It's safe for concurrent use, including by several processes.
+type Cache struct {
+	dir string
+	ttl time.Duration
+}
+
+// entry is the content of the cache file.
+type entry struct {
+	Created time.Time       `json:"created"`
+	Value   json.RawMessage `json:"value"`
+}
+
+// New creates a new cache in the given directory (it's created on the first write). The entries older than
+// the TTL are treated as missing.
+func New(dir string, ttl time.Duration) *Cache { return &Cache{dir: dir, ttl: ttl} }
+
+// DefaultDir returns the directory for the cache of the application with the given name in the user cache
+// directory (e.g. ~/.cache/<name> on Linux). An empty string is returned if it's unknown.
+func DefaultDir(appName string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, appName)
+}
+
+// Dir returns the cache directory.
+func (c *Cache) Dir() string { return c.dir }
+
+// Key returns the cache key for the given parts (the hash of them, so the parts may be of any size).
+func Key(parts ...string) string {
+	var h = sha256.New()
+
+	for _, part := range parts {
+		_, _ = fmt.Fprintf(h, "%d:%s", len(part), part) // the length prefix avoids collisions like "ab"+"c" = "a"+"bc"
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get reads the entry with the given key into v. False is returned if the entry is missing or expired.
+func (c *Cache) Get(key string, v any) (bool, error) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	var e entry
+
+	if err = json.Unmarshal(data, &e); err != nil {
+		return false, fmt.Errorf("broken cache entry %s: %w", key, err)
+	}
+
+	if time.Since(e.Created) > c.ttl {
+		_ = os.Remove(c.path(key)) // the expired entry is not needed anymore
+
+		return false, nil
+	}
+
+	if err = json.Unmarshal(e.Value, v); err != nil {
+		return false, fmt.Errorf("broken cache entry %s: %w", key, err)
+	}
+
+	return true, nil
+}
+
+// Set stores v (encoded to JSON) with the given key, replacing the existing entry.
+func (c *Cache) Set(key string, v any) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(entry{Created: time.Now(), Value: value})
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(c.dir, 0o700); err != nil { //nolint:mnd
+		return err
+	}
+
+	f, err := os.CreateTemp(c.dir, key+".*"+tempExt)
+	if err != nil {
+		return err
+	}
+
+	if _, err = f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+
+		return err
+	}
+
+	if err = f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+
+		return err
+	}
+
+	if err = os.Rename(f.Name(), c.path(key)); err != nil { // atomic replacement of the existing entry
+		_ = os.Remove(f.Name())
+
+		return err
+	}
+
+	return nil
+}
+
+// Clear removes all the entries (and the leftover temporary files) and returns the number of removed entries.
+func (c *Cache) Clear() (int, error) {
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, nil
+		}
+
+		return 0, err
+	}
+
+	var removed int
+
+	for _, file := range files {
+		var name = file.Name()
+
+		if file.IsDir() || (!strings.HasSuffix(name, entryExt) && !strings.HasSuffix(name, tempExt)) {
+			continue // not ours
+		}
+
+		if err = os.Remove(filepath.Join(c.dir, name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return removed, err
+		}
+
+		if strings.HasSuffix(name, entryExt) {
+			removed++
+		}
+	}
+
+	return removed, nil
+}
+
+// path returns the path to the entry file with the given key.
+func (c *Cache) path(key string) string { return filepath.Join(c.dir, key+entryExt) }
@@ -0,0 +1,142 @@
+package cache_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"gh.tarampamp.am/describe-commit/internal/cache"
+)
+
+type value struct {
+	Answer string
+	Tokens int
+}
+
+func TestCache_SetGet(t *testing.T) {
+	t.Parallel()
+
+	var c = cache.New(filepath.Join(t.TempDir(), "nested", "dir"), time.Hour)
+
+	var got value
+
+	if ok, err := c.Get(cache.Key("foo"), &got); err != nil || ok {
+		t.Fatalf("expected a miss, got %t (%v)", ok, err)
+	}
+
+	if err := c.Set(cache.Key("foo"), value{Answer: "feat: Add cache", Tokens: 42}); err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := c.Get(cache.Key("foo"), &got); err != nil || !ok {
+		t.Fatalf("expected a hit, got %t (%v)", ok, err)
+	}
+
+	if want := (value{Answer: "feat: Add cache", Tokens: 42}); got != want {
+		t.Errorf("unexpected value: %+v, want %+v", got, want)
+	}
+
+	if ok, _ := c.Get(cache.Key("bar"), &got); ok {
+		t.Error("expected a miss for another key")
+	}
+}
+
+func TestCache_TTL(t *testing.T) {
+	t.Parallel()
+
+	var dir = t.TempDir()
+
+	if err := cache.New(dir, time.Hour).Set("key", value{Answer: "old"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var got value
+
+	if ok, err := cache.New(dir, time.Nanosecond).Get("key", &got); err != nil || ok {
+		t.Fatalf("expected the entry to be expired, got %t (%v)", ok, err)
+	}
+
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("the expired entry is not removed: %v", files)
+	}
+}
+
+func TestCache_Clear(t *testing.T) {
+	t.Parallel()
+
+	var (
+		dir = t.TempDir()
+		c   = cache.New(dir, time.Hour)
+	)
+
+	for i := range 3 {
+		if err := c.Set(cache.Key(fmt.Sprint(i)), value{Tokens: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "foreign.txt"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := c.Clear()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if removed != 3 {
+		t.Errorf("unexpected number of removed entries: %d", removed)
+	}
+
+	if _, err = os.Stat(filepath.Join(dir, "foreign.txt")); err != nil {
+		t.Errorf("the foreign file must be kept: %v", err)
+	}
+
+	if removed, err = cache.New(filepath.Join(dir, "missing"), time.Hour).Clear(); err != nil || removed != 0 {
+		t.Errorf("clearing the missing directory: %d, %v", removed, err)
+	}
+}
+
+func TestCache_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	var (
+		c  = cache.New(t.TempDir(), time.Hour)
+		wg sync.WaitGroup
+	)
+
+	for i := range 20 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if err := c.Set("same-key", value{Answer: "answer", Tokens: i}); err != nil {
+				t.Error(err)
+			}
+
+			var got value
+
+			if ok, err := c.Get("same-key", &got); err != nil || !ok || got.Answer != "answer" {
+				t.Errorf("unexpected read: %t, %+v, %v", ok, got, err)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestKey(t *testing.T) {
+	t.Parallel()
+
+	if cache.Key("ab", "c") == cache.Key("a", "bc") {
+		t.Error("the keys must differ")
+	}
+
+	if cache.Key("a", "b") != cache.Key("a", "b") {
+		t.Error("the keys must be stable")
+	}
+}
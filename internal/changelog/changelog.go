@@ -0,0 +1,215 @@
+// Package changelog groups the Conventional Commits (https://www.conventionalcommits.org) into the release
+// sections of the Keep a Changelog format (https://keepachangelog.com) and renders them as Markdown.
+package changelog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Unreleased is the version of the changes that are not released yet.
+const Unreleased = "Unreleased"
+
+// The Keep a Changelog sections, in the order they are rendered.
+const (
+	SectionAdded      = "Added"
+	SectionChanged    = "Changed"
+	SectionDeprecated = "Deprecated"
+	SectionRemoved    = "Removed"
+	SectionFixed      = "Fixed"
+	SectionSecurity   = "Security"
+)
+
+// sectionsOrder is the order of the sections in the changelog.
+var sectionsOrder = []string{ //nolint:gochecknoglobals
+	SectionAdded, SectionChanged, SectionDeprecated, SectionRemoved, SectionFixed, SectionSecurity,
+}
+
+// typeSections maps the commit types to the sections. The internal types (docs, tests, CI, etc.) are not
+// user-facing, so they are omitted from the changelog by default.
+var typeSections = map[string]string{ //nolint:gochecknoglobals
+	"feat":       SectionAdded,
+	"fix":        SectionFixed,
+	"perf":       SectionChanged,
+	"refactor":   SectionChanged,
+	"revert":     SectionChanged,
+	"deprecate":  SectionDeprecated,
+	"deprecated": SectionDeprecated,
+	"remove":     SectionRemoved,
+	"removed":    SectionRemoved,
+	"security":   SectionSecurity,
+	"docs":       "",
+	"style":      "",
+	"test":       "",
+	"ci":         "",
+	"build":      "",
+	"chore":      "",
+}
+
+type (
+	// Entry is the commit parsed by [ParseCommit].
+	Entry struct {
+		Hash        string `json:"hash"`
+		Type        string `json:"type,omitempty"` // empty if the commit does not follow the Conventional Commits
+		Scope       string `json:"scope,omitempty"`
+		Description string `json:"description"`
+		Body        string `json:"body,omitempty"`
+		Breaking    bool   `json:"breaking,omitempty"`
+	}
+
+	// Section is the group of the entries under the same heading (e.g. "Added").
+	Section struct {
+		Name    string  `json:"name"`
+		Entries []Entry `json:"entries"`
+		Notes   string  `json:"notes,omitempty"` // the release notes, rendered instead of the entries if set
+	}
+
+	// Release is the changelog of a single version.
+	Release struct {
+		Version  string    `json:"version"`
+		Date     string    `json:"date,omitempty"` // YYYY-MM-DD
+		Sections []Section `json:"sections"`
+	}
+)
+
+// subjectRe matches the Conventional Commit subject: "<type>(<scope>)!: <description>".
+var subjectRe = regexp.MustCompile(`^([A-Za-z]+)(?:\(([^()]*)\))?(!)?:\s*(.+)$`) //nolint:gochecknoglobals
+
+// ParseCommit parses the commit message. The GitMoji prefix (e.g. "✨ feat: ...") is ignored, and the commit is
+// breaking if the type is followed by "!" or the body has the "BREAKING CHANGE" footer. The non-conventional
+// commits are returned with the empty type and the subject as the description.
+func ParseCommit(hash, subject, body string) Entry {
+	var entry = Entry{Hash: hash, Description: strings.TrimSpace(subject), Body: strings.TrimSpace(body)}
+
+	for _, line := range strings.Split(entry.Body, "\n") {
+		if strings.HasPrefix(line, "BREAKING CHANGE:") || strings.HasPrefix(line, "BREAKING-CHANGE:") {
+			entry.Breaking = true
+		}
+	}
+
+	var s = entry.Description
+
+	if first, rest, ok := strings.Cut(s, " "); ok && !strings.ContainsFunc(first, isASCIILetter) { // GitMoji
+		s = strings.TrimSpace(rest)
+	}
+
+	if m := subjectRe.FindStringSubmatch(s); m != nil {
+		entry.Type = strings.ToLower(m[1])
+		entry.Scope = strings.TrimSpace(m[2])
+		entry.Breaking = entry.Breaking || m[3] != ""
+		entry.Description = m[4]
+	}
+
+	return entry
+}
+
+// isASCIILetter reports whether the rune is a Latin letter.
+func isASCIILetter(r rune) bool { return r < unicode.MaxASCII && unicode.IsLetter(r) }
+
+// SectionOf returns the name of the section the entry belongs to. An empty string is returned for the internal
+// changes (docs, tests, CI, etc.), unless they are breaking. The non-conventional commits and the unknown types
+// are treated as changes.
+func SectionOf(e Entry) string {
+	section, known := typeSections[e.Type]
+
+	switch {
+	case !known:
+		return SectionChanged
+	case section == "" && e.Breaking:
+		return SectionChanged
+	}
+
+	return section
+}
+
+// New groups the entries (in the given order) into the sections. The internal changes are included into the
+// "Changed" section if includeInternal is true, and omitted otherwise.
+func New(version, date string, entries []Entry, includeInternal bool) *Release {
+	var (
+		release = Release{Version: version, Date: date}
+		grouped = make(map[string][]Entry, len(sectionsOrder))
+	)
+
+	for _, e := range entries {
+		var section = SectionOf(e)
+
+		if section == "" {
+			if !includeInternal {
+				continue
+			}
+
+			section = SectionChanged
+		}
+
+		grouped[section] = append(grouped[section], e)
+	}
+
+	for _, name := range sectionsOrder {
+		if len(grouped[name]) > 0 {
+			release.Sections = append(release.Sections, Section{Name: name, Entries: grouped[name]})
+		}
+	}
+
+	return &release
+}
+
+// Markdown renders the release in the Keep a Changelog format.
+func (r *Release) Markdown() string {
+	var b strings.Builder
+
+	if r.Version == Unreleased || r.Date == "" {
+		_, _ = fmt.Fprintf(&b, "## [%s]\n", r.Version)
+	} else {
+		_, _ = fmt.Fprintf(&b, "## [%s] - %s\n", r.Version, r.Date)
+	}
+
+	if len(r.Sections) == 0 {
+		b.WriteString("\nNo notable changes.\n")
+	}
+
+	for _, section := range r.Sections {
+		_, _ = fmt.Fprintf(&b, "\n### %s\n\n", section.Name)
+
+		if section.Notes != "" {
+			b.WriteString(strings.TrimSpace(section.Notes) + "\n")
+
+			continue
+		}
+
+		for _, e := range section.Entries {
+			b.WriteString("- " + e.String() + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// String returns the entry as a changelog line (without the list marker): the breaking marker, the scope, the
+// description and the abbreviated commit hash.
+func (e Entry) String() string {
+	const hashLength = 7
+
+	var b strings.Builder
+
+	if e.Breaking {
+		b.WriteString("**BREAKING:** ")
+	}
+
+	if e.Scope != "" {
+		b.WriteString("**" + e.Scope + ":** ")
+	}
+
+	b.WriteString(e.Description)
+
+	if hash := e.Hash; hash != "" {
+		if len(hash) > hashLength {
+			hash = hash[:hashLength]
+		}
+
+		b.WriteString(" (" + hash + ")")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,150 @@
+package changelog_test
+
+import (
+	"reflect"
+	"testing"
+
+	"gh.tarampamp.am/describe-commit/internal/changelog"
+)
+
+func TestParseCommit(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		giveSubject, giveBody string
+		want                  changelog.Entry
+	}{
+		"with scope": {
+			giveSubject: "feat(api): Add rate limiting",
+			want:        changelog.Entry{Hash: "h", Type: "feat", Scope: "api", Description: "Add rate limiting"},
+		},
+		"without scope": {
+			giveSubject: "Fix: Handle nil",
+			want:        changelog.Entry{Hash: "h", Type: "fix", Description: "Handle nil"},
+		},
+		"breaking marker": {
+			giveSubject: "refactor(cli)!: Drop the old flags",
+			want: changelog.Entry{
+				Hash: "h", Type: "refactor", Scope: "cli", Description: "Drop the old flags", Breaking: true,
+			},
+		},
+		"breaking footer": {
+			giveSubject: "feat: New config",
+			giveBody:    "Details\n\nBREAKING CHANGE: the old keys are removed\n",
+			want: changelog.Entry{
+				Hash: "h", Type: "feat", Description: "New config", Breaking: true,
+				Body: "Details\n\nBREAKING CHANGE: the old keys are removed",
+			},
+		},
+		"gitmoji": {
+			giveSubject: "✨ feat(ui): Add dark mode",
+			want:        changelog.Entry{Hash: "h", Type: "feat", Scope: "ui", Description: "Add dark mode"},
+		},
+		"non-conventional": {
+			giveSubject: "Update README.md",
+			want:        changelog.Entry{Hash: "h", Description: "Update README.md"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := changelog.ParseCommit("h", tc.giveSubject, tc.giveBody); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("unexpected entry: %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	var entries = []changelog.Entry{
+		changelog.ParseCommit("1111111aaa", "fix(git): Skip merges", ""),
+		changelog.ParseCommit("2222222bbb", "feat: Add changelog", ""),
+		changelog.ParseCommit("3333333ccc", "docs: Update README", ""),
+		changelog.ParseCommit("4444444ddd", "chore!: Require Go 1.25", ""),
+		changelog.ParseCommit("5555555eee", "Tweak things", ""),
+		changelog.ParseCommit("6666666fff", "feat(cli): Add JSON output", ""),
+	}
+
+	for name, tc := range map[string]struct {
+		giveInternal bool
+		wantSections map[string][]string // section name => hashes
+		wantOrder    []string
+	}{
+		"user-facing only": {
+			wantSections: map[string][]string{
+				changelog.SectionAdded:   {"2222222bbb", "6666666fff"},
+				changelog.SectionChanged: {"4444444ddd", "5555555eee"},
+				changelog.SectionFixed:   {"1111111aaa"},
+			},
+			wantOrder: []string{changelog.SectionAdded, changelog.SectionChanged, changelog.SectionFixed},
+		},
+		"including internal": {
+			giveInternal: true,
+			wantSections: map[string][]string{
+				changelog.SectionAdded:   {"2222222bbb", "6666666fff"},
+				changelog.SectionChanged: {"3333333ccc", "4444444ddd", "5555555eee"},
+				changelog.SectionFixed:   {"1111111aaa"},
+			},
+			wantOrder: []string{changelog.SectionAdded, changelog.SectionChanged, changelog.SectionFixed},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var release = changelog.New("1.0.0", "2026-10-17", entries, tc.giveInternal)
+
+			var order []string
+
+			for _, section := range release.Sections {
+				order = append(order, section.Name)
+
+				var hashes []string
+
+				for _, e := range section.Entries {
+					hashes = append(hashes, e.Hash)
+				}
+
+				if want := tc.wantSections[section.Name]; !reflect.DeepEqual(hashes, want) {
+					t.Errorf("section %s: %v, want %v", section.Name, hashes, want)
+				}
+			}
+
+			if !reflect.DeepEqual(order, tc.wantOrder) {
+				t.Errorf("unexpected sections: %v, want %v", order, tc.wantOrder)
+			}
+		})
+	}
+}
+
+func TestRelease_Markdown(t *testing.T) {
+	t.Parallel()
+
+	var release = changelog.New("v1.2.0", "2026-10-17", []changelog.Entry{
+		changelog.ParseCommit("1111111aaa", "feat(api)!: Drop v1", ""),
+		changelog.ParseCommit("2222222bbb", "fix: Handle nil", ""),
+	}, false)
+
+	const want = "## [v1.2.0] - 2026-10-17\n\n" +
+		"### Added\n\n- **BREAKING:** **api:** Drop v1 (1111111)\n\n" +
+		"### Fixed\n\n- Handle nil (2222222)\n"
+
+	if got := release.Markdown(); got != want {
+		t.Errorf("unexpected markdown:\n%s\nwant:\n%s", got, want)
+	}
+
+	release.Sections[1].Notes = "- Nil values no longer crash the server\n"
+
+	const wantNotes = "## [v1.2.0] - 2026-10-17\n\n" +
+		"### Added\n\n- **BREAKING:** **api:** Drop v1 (1111111)\n\n" +
+		"### Fixed\n\n- Nil values no longer crash the server\n"
+
+	if got := release.Markdown(); got != wantNotes {
+		t.Errorf("unexpected markdown with notes:\n%s\nwant:\n%s", got, wantNotes)
+	}
+
+	if got := changelog.New(changelog.Unreleased, "", nil, false).Markdown(); got != "## [Unreleased]\n\nNo notable changes.\n" {
+		t.Errorf("unexpected empty release: %q", got)
+	}
+}
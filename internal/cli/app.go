@@ -1,11 +1,14 @@
 package cli
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
@@ -21,9 +24,13 @@ import (
 
 //go:generate go run ./generate/readme.go
 
+// errNoChanges is returned when there are no changes to describe.
+var errNoChanges = errors.New("no changes found")
+
 type App struct {
-	cmd cmd.Command
-	opt options
+	cmd   cmd.Command
+	opt   options
+	usage usageTracker // the token usage of the AI provider requests
 }
 
 func NewApp(name string) *App { //nolint:funlen
@@ -31,7 +38,7 @@ func NewApp(name string) *App { //nolint:funlen
 		cmd: cmd.Command{
 			Name:        name,
 			Description: "This tool leverages AI to generate commit messages based on changes made in a Git repository.",
-			Usage:       "[<options>] [<git-dir-path>]",
+			Usage:       "[<options>] [<command>] [<git-dir-path>]",
 			Version:     version.Version(),
 		},
 		opt: newOptionsWithDefaults(),
@@ -56,6 +63,43 @@ func NewApp(name string) *App { //nolint:funlen
 			EnvVars: []string{"COMMIT_HISTORY_LENGTH"},
 			Default: app.opt.CommitHistoryLength,
 		}
+		commitHistoryBodies = cmd.Flag[bool]{
+			Names:   []string{"commit-history-bodies"},
+			Usage:   "Include the message bodies (with trailers) of the previous commits, not only the subjects",
+			EnvVars: []string{"COMMIT_HISTORY_BODIES"},
+			Default: app.opt.CommitHistoryBodies,
+		}
+		commitHistoryAuthors = cmd.Flag[bool]{
+			Names:   []string{"commit-history-authors"},
+			Usage:   "Include the author names of the previous commits",
+			EnvVars: []string{"COMMIT_HISTORY_AUTHORS"},
+			Default: app.opt.CommitHistoryAuthors,
+		}
+		commitHistoryFiles = cmd.Flag[bool]{
+			Names:   []string{"commit-history-files"},
+			Usage:   "Include the files changed by the previous commits",
+			EnvVars: []string{"COMMIT_HISTORY_FILES"},
+			Default: app.opt.CommitHistoryFiles,
+		}
+		commitHistorySamePaths = cmd.Flag[bool]{
+			Names:   []string{"commit-history-same-paths"},
+			Usage:   "Prefer the previous commits that touched the same files as the changes (the rest are the latest ones)",
+			EnvVars: []string{"COMMIT_HISTORY_SAME_PATHS"},
+			Default: app.opt.CommitHistorySamePaths,
+		}
+		commitHistoryMaxChars = cmd.Flag[int]{
+			Names:   []string{"commit-history-max-chars"},
+			Usage:   "Limit the size of the commit history in characters, the older commits are omitted (0 = unlimited)",
+			EnvVars: []string{"COMMIT_HISTORY_MAX_CHARS"},
+			Default: app.opt.CommitHistoryMaxChars,
+			Validator: func(_ *cmd.Command, i int) error {
+				if i < 0 {
+					return errors.New("commit history limit must not be negative")
+				}
+
+				return nil
+			},
+		}
 		enableEmoji = cmd.Flag[bool]{
 			Names:   []string{"enable-emoji", "e"},
 			Usage:   "Enable emoji in the commit message",
@@ -87,19 +131,264 @@ func NewApp(name string) *App { //nolint:funlen
 			EnvVars: []string{"RETRY_DELAY"},
 			Default: app.opt.RetryDelay,
 		}
+		retryBackoff = cmd.Flag[float64]{
+			Names:   []string{"retry-backoff"},
+			Usage:   "Multiplier of the delay after each retry attempt (1 = constant delay)",
+			EnvVars: []string{"RETRY_BACKOFF"},
+			Default: app.opt.RetryBackoff,
+			Validator: func(_ *cmd.Command, f float64) error {
+				if f < 1 {
+					return errors.New("retry backoff must be greater than or equal to 1")
+				}
+
+				return nil
+			},
+		}
+		retryMaxDelay = cmd.Flag[time.Duration]{
+			Names:   []string{"retry-max-delay"},
+			Usage:   "Maximum delay between retry attempts, including the one suggested by the server (0 = unlimited)",
+			EnvVars: []string{"RETRY_MAX_DELAY"},
+			Default: app.opt.RetryMaxDelay,
+		}
+		retryJitter = cmd.Flag[bool]{
+			Names:   []string{"retry-jitter"},
+			Usage:   "Randomize the delay between retry attempts (full jitter)",
+			EnvVars: []string{"RETRY_JITTER"},
+			Default: app.opt.RetryJitter,
+		}
+		retryDeadline = cmd.Flag[time.Duration]{
+			Names:   []string{"retry-deadline"},
+			Usage:   "Overall time limit for the retry attempts of a single request (e.g. 2m; 0 = unlimited)",
+			EnvVars: []string{"RETRY_DEADLINE"},
+			Default: app.opt.RetryDeadline,
+		}
+		interactive = cmd.Flag[bool]{
+			Names:   []string{"interactive", "i"},
+			Usage:   "Review the generated message and commit it, edit, regenerate, or cancel (requires a terminal)",
+			EnvVars: []string{"INTERACTIVE"},
+			Default: app.opt.Interactive,
+		}
+		candidates = cmd.Flag[int]{
+			Names:   []string{"candidates", "n"},
+			Usage:   "Number of alternative commit messages to generate",
+			EnvVars: []string{"CANDIDATES"},
+			Default: app.opt.Candidates,
+			Validator: func(_ *cmd.Command, i int) error {
+				if i < 1 || i > maxCandidates {
+					return fmt.Errorf("candidates must be between 1 and %d", maxCandidates)
+				}
+
+				return nil
+			},
+		}
+		stream = cmd.Flag[bool]{
+			Names:   []string{"stream"},
+			Usage:   "Print the answer to stderr as it is generated (useful for slow models)",
+			EnvVars: []string{"STREAM"},
+			Default: app.opt.Stream,
+		}
+		maxDiffChars = cmd.Flag[int]{
+			Names:     []string{"max-diff-chars"},
+			Usage:     "Summarize the diff by parts when it is larger than this number of characters (0 = unlimited)",
+			EnvVars:   []string{"MAX_DIFF_CHARS"},
+			Default:   app.opt.MaxDiffChars,
+			Validator: validateDiffLimit,
+		}
+		maxDiffTokens = cmd.Flag[int]{
+			Names:     []string{"max-diff-tokens"},
+			Usage:     "The same as --max-diff-chars, but in estimated tokens (as for --max-input-tokens; 0 = unlimited)",
+			EnvVars:   []string{"MAX_DIFF_TOKENS"},
+			Default:   app.opt.MaxDiffTokens,
+			Validator: validateDiffLimit,
+		}
+		maxInputTokens = cmd.Flag[int]{
+			Names:   []string{"max-input-tokens"},
+			Usage:   "Budget of the estimated input tokens of the request (prompt, diff and log; 0 = unlimited)",
+			EnvVars: []string{"MAX_INPUT_TOKENS"},
+			Default: app.opt.MaxInputTokens,
+			Validator: func(_ *cmd.Command, i int) error {
+				if i < 0 {
+					return errors.New("max input tokens must not be negative")
+				}
+
+				return nil
+			},
+		}
+		inputBudgetAction = cmd.Flag[string]{
+			Names: []string{"input-budget-action"},
+			Usage: fmt.Sprintf("What to do when the estimated input tokens exceed the budget (%s)",
+				strings.Join(budgetActions(), "|"),
+			),
+			EnvVars: []string{"INPUT_BUDGET_ACTION"},
+			Default: app.opt.InputBudgetAction,
+			Validator: func(_ *cmd.Command, s string) error {
+				if !isBudgetActionSupported(s) {
+					return fmt.Errorf("unsupported input budget action: %s", s)
+				}
+
+				return nil
+			},
+		}
+		dryRun = cmd.Flag[bool]{
+			Names:   []string{"dry-run"},
+			Usage:   "Print the estimated input tokens and the exact HTTP request (secrets redacted) without sending it",
+			EnvVars: []string{"DRY_RUN"},
+		}
+		revision = cmd.Flag[string]{
+			Names:   []string{"rev"},
+			Usage:   "Describe the existing commit or range (e.g. HEAD~3..HEAD) instead of the staged changes",
+			EnvVars: []string{"REVISION"},
+		}
+		amend = cmd.Flag[bool]{
+			Names:   []string{"amend"},
+			Usage:   "Reword the HEAD commit with the generated message (its content is not changed)",
+			EnvVars: []string{"AMEND"},
+		}
+		noCache = cmd.Flag[bool]{
+			Names:   []string{"no-cache"},
+			Usage:   "Always ask the AI provider, do not read (or write) the cached responses for the same requests",
+			EnvVars: []string{"NO_CACHE"},
+		}
+		cacheTTL = cmd.Flag[time.Duration]{
+			Names:   []string{"cache-ttl"},
+			Usage:   "How long the cached AI provider responses are valid (e.g. 1h, 30m)",
+			EnvVars: []string{"CACHE_TTL"},
+			Default: app.opt.CacheTTL,
+			Validator: func(_ *cmd.Command, d time.Duration) error {
+				if d <= 0 {
+					return errors.New("cache TTL must be positive")
+				}
+
+				return nil
+			},
+		}
+		diffSource = cmd.Flag[string]{
+			Names: []string{"diff-source", "ds"},
+			Usage: fmt.Sprintf("Source of the changes to describe (%s) or the base revision to compare with "+
+				"(e.g. origin/main...HEAD)", strings.Join([]string{git.SourceStaged, git.SourceUnstaged, git.SourceAll}, "|"),
+			),
+			EnvVars: []string{"DIFF_SOURCE"},
+			Default: app.opt.DiffSource,
+			Validator: func(_ *cmd.Command, s string) error {
+				if strings.TrimSpace(s) == "" {
+					return errors.New("diff source must not be empty")
+				}
+
+				return nil
+			},
+		}
+		exclude = cmd.Flag[string]{
+			Names:   []string{"exclude", "x"},
+			Usage:   "Comma-separated glob patterns of the files to exclude from the diff (e.g. \"*.pb.go,vendor/\")",
+			EnvVars: []string{"EXCLUDE"},
+		}
+		include = cmd.Flag[string]{
+			Names:   []string{"include"},
+			Usage:   "Comma-separated glob patterns of the files to keep in the diff even if excluded (e.g. \"*.lock\")",
+			EnvVars: []string{"INCLUDE"},
+		}
+		promptTemplate = cmd.Flag[string]{
+			Names:   []string{"prompt-template", "pt"},
+			Usage:   "Path to the custom prompt template file (Go text/template syntax)",
+			EnvVars: []string{"PROMPT_TEMPLATE"},
+			Default: app.opt.PromptTemplate,
+		}
+		commitTypes = cmd.Flag[string]{
+			Names: []string{"commit-types"},
+			Usage: fmt.Sprintf("Comma-separated list of the allowed commit types (default: %s)",
+				strings.Join(ai.DefaultCommitTypes, ","),
+			),
+			EnvVars: []string{"COMMIT_TYPES"},
+		}
+		issueKeyPattern = cmd.Flag[string]{
+			Names:   []string{"issue-key-pattern"},
+			Usage:   "Regex to extract the issue key from the branch name (e.g. \"[A-Z]+-[0-9]+\"; the first group, if any)",
+			EnvVars: []string{"ISSUE_KEY_PATTERN"},
+			Default: app.opt.IssueKeyPattern,
+			Validator: func(_ *cmd.Command, s string) error {
+				if _, err := regexp.Compile(s); err != nil {
+					return fmt.Errorf("wrong issue key pattern: %w", err)
+				}
+
+				return nil
+			},
+		}
+		issueKeyFormat = cmd.Flag[string]{
+			Names: []string{"issue-key-format"},
+			Usage: fmt.Sprintf("Format of the issue key in the subject line (e.g. \"[%[1]s]\" or \"%[1]s:\")",
+				ai.IssueKeyPlaceholder,
+			),
+			EnvVars: []string{"ISSUE_KEY_FORMAT"},
+			Default: app.opt.IssueKeyFormat,
+			Validator: func(_ *cmd.Command, s string) error {
+				if !strings.Contains(s, ai.IssueKeyPlaceholder) {
+					return fmt.Errorf("issue key format must contain the %s placeholder", ai.IssueKeyPlaceholder)
+				}
+
+				return nil
+			},
+		}
+		issueKeyMode = cmd.Flag[string]{
+			Names: []string{"issue-key-mode"},
+			Usage: fmt.Sprintf("How to add the issue key: ask the AI, prepend it to the answer, or both (%s)",
+				strings.Join(issueKeyModes(), "|"),
+			),
+			EnvVars: []string{"ISSUE_KEY_MODE"},
+			Default: app.opt.IssueKeyMode,
+			Validator: func(_ *cmd.Command, s string) error {
+				if !isIssueKeyModeSupported(s) {
+					return fmt.Errorf("unsupported issue key mode: %s", s)
+				}
+
+				return nil
+			},
+		}
+		language = cmd.Flag[string]{
+			Names:   []string{"language", "lang"},
+			Usage:   "Language of the commit message (ISO 639-1 code, e.g. de, ja, pt-br)",
+			EnvVars: []string{"COMMIT_LANGUAGE"}, // not LANGUAGE, it is used by gettext
+			Default: app.opt.Language,
+			Validator: func(_ *cmd.Command, s string) error {
+				if !ai.IsLanguageSupported(s) {
+					return fmt.Errorf("unsupported language: %s (supported: %s)", s, strings.Join(ai.SupportedLanguages(), ", "))
+				}
+
+				return nil
+			},
+		}
 		aiProviderName = cmd.Flag[string]{
-			Names:   []string{"ai-provider", "ai"},
-			Usage:   fmt.Sprintf("AI provider name (%s)", strings.Join(ai.SupportedProviders(), "|")),
+			Names: []string{"ai-provider", "ai"},
+			Usage: fmt.Sprintf("AI provider name (%s) or the name of the provider profile from the config file",
+				strings.Join(ai.SupportedProviders(), "|"),
+			),
 			EnvVars: []string{"AI_PROVIDER"},
 			Default: app.opt.AIProviderName,
 			Validator: func(_ *cmd.Command, s string) error {
-				if !ai.IsProviderSupported(s) {
-					return fmt.Errorf("unsupported AI provider: %s", s)
+				if s == "" { // the profile names are validated after the config file is loaded
+					return errors.New("AI provider name must not be empty")
 				}
 
 				return nil
 			},
 		}
+		fallback = cmd.Flag[string]{
+			Names: []string{"fallback"},
+			Usage: "Comma-separated list of the AI providers (or profiles) to fail over to, in order, when the " +
+				"previous one keeps failing (\"provider[:model]\", e.g. \"openai,ollama:qwen2.5-coder:7b\")",
+			EnvVars: []string{"AI_FALLBACK"},
+		}
+		showUsage = cmd.Flag[bool]{
+			Names:   []string{"show-usage"},
+			Usage:   "Print the token usage and the estimated cost (see the prices in the config file) to stderr",
+			EnvVars: []string{"SHOW_USAGE"},
+			Default: app.opt.ShowUsage,
+		}
+		usageLedger = cmd.Flag[string]{
+			Names:   []string{"usage-ledger"},
+			Usage:   "Path to the JSONL file to append the token usage and the estimated cost to (one line per model)",
+			EnvVars: []string{"USAGE_LEDGER"},
+			Default: app.opt.UsageLedger,
+		}
 		geminiApiKey = cmd.Flag[string]{
 			Names:   []string{"gemini-api-key", "ga"},
 			Usage:   "Gemini API key (https://aistudio.google.com/app/api-keys, as of February 2025 it's free)",
@@ -172,17 +461,100 @@ func NewApp(name string) *App { //nolint:funlen
 			EnvVars: []string{"ANTHROPIC_BASE_URL"},
 			Default: app.opt.Providers.Anthropic.BaseURL,
 		}
+		ollamaModelName = cmd.Flag[string]{
+			Names:   []string{"ollama-model-name", "olm"},
+			Usage:   "Ollama model name (https://ollama.com/search)",
+			EnvVars: []string{"OLLAMA_MODEL_NAME"},
+			Default: app.opt.Providers.Ollama.ModelName,
+		}
+		ollamaBaseURL = cmd.Flag[string]{
+			Names:   []string{"ollama-base-url"},
+			Usage:   "Ollama API base URL (default: http://localhost:11434)",
+			EnvVars: []string{"OLLAMA_BASE_URL"},
+			Default: app.opt.Providers.Ollama.BaseURL,
+		}
+		ollamaNumCtx = cmd.Flag[int]{
+			Names:   []string{"ollama-num-ctx"},
+			Usage:   "Ollama context window size in tokens (0 = the model default; increase it for large diffs)",
+			EnvVars: []string{"OLLAMA_NUM_CTX"},
+			Default: app.opt.Providers.Ollama.NumCtx,
+		}
+		ollamaKeepAlive = cmd.Flag[string]{
+			Names:   []string{"ollama-keep-alive"},
+			Usage:   "How long the Ollama model stays loaded after the request (e.g. 10m, -1 = forever)",
+			EnvVars: []string{"OLLAMA_KEEP_ALIVE"},
+			Default: app.opt.Providers.Ollama.KeepAlive,
+		}
+		ollamaAutoPull = cmd.Flag[bool]{
+			Names:   []string{"ollama-auto-pull"},
+			Usage:   "Pull the Ollama model if it is not found on the server",
+			EnvVars: []string{"OLLAMA_AUTO_PULL"},
+			Default: app.opt.Providers.Ollama.AutoPull,
+		}
+		azureApiKey = cmd.Flag[string]{
+			Names:   []string{"azure-openai-api-key", "aza"},
+			Usage:   "Azure OpenAI API key (Azure portal - your resource - Keys and Endpoint)",
+			EnvVars: []string{"AZURE_OPENAI_API_KEY"},
+			Default: app.opt.Providers.AzureOpenAI.ApiKey,
+		}
+		azureEndpoint = cmd.Flag[string]{
+			Names:   []string{"azure-openai-endpoint"},
+			Usage:   "Azure OpenAI resource endpoint (e.g. https://<resource>.openai.azure.com)",
+			EnvVars: []string{"AZURE_OPENAI_ENDPOINT"},
+			Default: app.opt.Providers.AzureOpenAI.Endpoint,
+		}
+		azureDeploymentName = cmd.Flag[string]{
+			Names:   []string{"azure-openai-deployment", "azd"},
+			Usage:   "Azure OpenAI deployment name (the model is selected by the deployment)",
+			EnvVars: []string{"AZURE_OPENAI_DEPLOYMENT"},
+			Default: app.opt.Providers.AzureOpenAI.DeploymentName,
+		}
+		azureAPIVersion = cmd.Flag[string]{
+			Names:   []string{"azure-openai-api-version"},
+			Usage:   "Azure OpenAI API version",
+			EnvVars: []string{"AZURE_OPENAI_API_VERSION"},
+			Default: app.opt.Providers.AzureOpenAI.APIVersion,
+		}
 	)
 
-	app.cmd.Flags = []cmd.Flagger{
+	app.cmd.PersistentFlags = []cmd.Flagger{
 		&configFile,
 		&shortMessageOnly,
 		&commitHistoryLength,
+		&commitHistoryBodies,
+		&commitHistoryAuthors,
+		&commitHistoryFiles,
+		&commitHistorySamePaths,
+		&commitHistoryMaxChars,
 		&enableEmoji,
 		&maxOutputTokens,
 		&retryAttempts,
 		&retryDelay,
+		&retryBackoff,
+		&retryMaxDelay,
+		&retryJitter,
+		&retryDeadline,
+		&candidates,
+		&stream,
+		&maxDiffChars,
+		&maxDiffTokens,
+		&maxInputTokens,
+		&inputBudgetAction,
+		&noCache,
+		&cacheTTL,
+		&diffSource,
+		&exclude,
+		&include,
+		&promptTemplate,
+		&commitTypes,
+		&issueKeyPattern,
+		&issueKeyFormat,
+		&issueKeyMode,
+		&language,
 		&aiProviderName,
+		&fallback,
+		&showUsage,
+		&usageLedger,
 		&geminiApiKey,
 		&geminiModelName,
 		&geminiBaseURL,
@@ -195,28 +567,86 @@ func NewApp(name string) *App { //nolint:funlen
 		&anthropicApiKey,
 		&anthropicModelName,
 		&anthropicBaseURL,
+		&ollamaModelName,
+		&ollamaBaseURL,
+		&ollamaNumCtx,
+		&ollamaKeepAlive,
+		&ollamaAutoPull,
+		&azureApiKey,
+		&azureEndpoint,
+		&azureDeploymentName,
+		&azureAPIVersion,
 	}
 
-	app.cmd.Action = func(ctx context.Context, c *cmd.Command, args []string) error {
-		// determine the working directory
-		var wd, wdErr = app.getWorkingDir(args)
-		if wdErr != nil {
-			return fmt.Errorf("wrong working directory: %w", wdErr)
-		}
-
+	// loadOptions updates the options from the configuration file(s) and the command-line flags
+	var loadOptions = func(wd string) error {
 		// update the options from the configuration file(s)
-		if err := app.opt.UpdateFromConfigFile(append([]string{*configFile.Value}, config.FindIn(wd)...)); err != nil {
+		if err := app.opt.UpdateFromConfigFile(*configFile.Value, config.FindIn(wd)...); err != nil {
 			return err
 		}
 
 		{ // override the options with the command-line flags
 			setIfFlagIsSet(&app.opt.ShortMessageOnly, shortMessageOnly)
 			setIfFlagIsSet(&app.opt.CommitHistoryLength, commitHistoryLength)
+			setIfFlagIsSet(&app.opt.CommitHistoryBodies, commitHistoryBodies)
+			setIfFlagIsSet(&app.opt.CommitHistoryAuthors, commitHistoryAuthors)
+			setIfFlagIsSet(&app.opt.CommitHistoryFiles, commitHistoryFiles)
+			setIfFlagIsSet(&app.opt.CommitHistorySamePaths, commitHistorySamePaths)
+			setIfFlagIsSet(&app.opt.CommitHistoryMaxChars, commitHistoryMaxChars)
 			setIfFlagIsSet(&app.opt.EnableEmoji, enableEmoji)
 			setIfFlagIsSet(&app.opt.MaxOutputTokens, maxOutputTokens)
 			setIfFlagIsSet(&app.opt.MaxRetries, retryAttempts)
 			setIfFlagIsSet(&app.opt.RetryDelay, retryDelay)
+			setIfFlagIsSet(&app.opt.RetryBackoff, retryBackoff)
+			setIfFlagIsSet(&app.opt.RetryMaxDelay, retryMaxDelay)
+			setIfFlagIsSet(&app.opt.RetryJitter, retryJitter)
+			setIfFlagIsSet(&app.opt.RetryDeadline, retryDeadline)
+			setIfFlagIsSet(&app.opt.Interactive, interactive)
+			setIfFlagIsSet(&app.opt.Candidates, candidates)
+			setIfFlagIsSet(&app.opt.Stream, stream)
+			setIfFlagIsSet(&app.opt.MaxDiffChars, maxDiffChars)
+			setIfFlagIsSet(&app.opt.MaxDiffTokens, maxDiffTokens)
+			setIfFlagIsSet(&app.opt.MaxInputTokens, maxInputTokens)
+			setIfFlagIsSet(&app.opt.InputBudgetAction, inputBudgetAction)
+			setIfFlagIsSet(&app.opt.DryRun, dryRun)
+			setIfFlagIsSet(&app.opt.Revision, revision)
+			setIfFlagIsSet(&app.opt.Amend, amend)
+
+			if noCache.IsSet() && *noCache.Value {
+				app.opt.Cache = false
+			}
+
+			setIfFlagIsSet(&app.opt.CacheTTL, cacheTTL)
+
+			setIfFlagIsSet(&app.opt.DiffSource, diffSource)
+
+			if exclude.IsSet() {
+				app.opt.Exclude = splitList(*exclude.Value)
+			}
+
+			if include.IsSet() {
+				app.opt.Include = splitList(*include.Value)
+			}
+
+			setIfFlagIsSet(&app.opt.PromptTemplate, promptTemplate)
+			setIfFlagIsSet(&app.opt.Language, language)
+
+			if commitTypes.IsSet() {
+				app.opt.CommitTypes = splitList(*commitTypes.Value)
+			}
+
+			setIfFlagIsSet(&app.opt.IssueKeyPattern, issueKeyPattern)
+			setIfFlagIsSet(&app.opt.IssueKeyFormat, issueKeyFormat)
+			setIfFlagIsSet(&app.opt.IssueKeyMode, issueKeyMode)
+
 			setIfFlagIsSet(&app.opt.AIProviderName, aiProviderName)
+
+			if fallback.IsSet() {
+				app.opt.Fallback = splitList(*fallback.Value)
+			}
+
+			setIfFlagIsSet(&app.opt.ShowUsage, showUsage)
+			setIfFlagIsSet(&app.opt.UsageLedger, usageLedger)
 			setIfFlagIsSet(&app.opt.Providers.Gemini.ApiKey, geminiApiKey)
 			setIfFlagIsSet(&app.opt.Providers.Gemini.ModelName, geminiModelName)
 			setIfFlagIsSet(&app.opt.Providers.Gemini.BaseURL, geminiBaseURL)
@@ -229,18 +659,86 @@ func NewApp(name string) *App { //nolint:funlen
 			setIfFlagIsSet(&app.opt.Providers.Anthropic.ApiKey, anthropicApiKey)
 			setIfFlagIsSet(&app.opt.Providers.Anthropic.ModelName, anthropicModelName)
 			setIfFlagIsSet(&app.opt.Providers.Anthropic.BaseURL, anthropicBaseURL)
+			setIfFlagIsSet(&app.opt.Providers.Ollama.ModelName, ollamaModelName)
+			setIfFlagIsSet(&app.opt.Providers.Ollama.BaseURL, ollamaBaseURL)
+			setIfFlagIsSet(&app.opt.Providers.Ollama.NumCtx, ollamaNumCtx)
+			setIfFlagIsSet(&app.opt.Providers.Ollama.KeepAlive, ollamaKeepAlive)
+			setIfFlagIsSet(&app.opt.Providers.Ollama.AutoPull, ollamaAutoPull)
+			setIfFlagIsSet(&app.opt.Providers.AzureOpenAI.ApiKey, azureApiKey)
+			setIfFlagIsSet(&app.opt.Providers.AzureOpenAI.Endpoint, azureEndpoint)
+			setIfFlagIsSet(&app.opt.Providers.AzureOpenAI.DeploymentName, azureDeploymentName)
+			setIfFlagIsSet(&app.opt.Providers.AzureOpenAI.APIVersion, azureAPIVersion)
 		}
 
 		if err := app.opt.Validate(); err != nil {
 			return fmt.Errorf("invalid options: %w", err)
 		}
 
+		return nil
+	}
+
+	// generate is the default action (the "generate" command is also executed when no command is specified)
+	var generate = func(ctx context.Context, _ *cmd.Command, args []string) error {
+		// determine the working directory
+		var wd, wdErr = app.getWorkingDir(args)
+		if wdErr != nil {
+			return fmt.Errorf("wrong working directory: %w", wdErr)
+		}
+
+		if err := loadOptions(wd); err != nil {
+			return err
+		}
+
+		defer app.reportUsage(wd)
+
 		return app.run(ctx, wd)
 	}
 
+	app.cmd.Flags = []cmd.Flagger{&interactive, &dryRun, &revision, &amend}
+	app.cmd.Action = generate
+	app.cmd.Commands = []*cmd.Command{
+		{
+			Name:        "generate",
+			Description: "Generate the commit message for the staged changes (default command)",
+			Usage:       "[<options>] [<git-dir-path>]",
+			Flags:       []cmd.Flagger{&interactive, &dryRun, &revision, &amend},
+			Action:      generate,
+		},
+		app.newHookCommand(loadOptions),
+		app.newPromptCommand(loadOptions),
+		app.newModelsCommand(loadOptions),
+		app.newPRCommand(loadOptions),
+		app.newChangelogCommand(loadOptions),
+		app.newCacheCommand(),
+	}
+
 	return &app
 }
 
+// The output formats of the pr and changelog commands.
+const (
+	formatMarkdown = "markdown"
+	formatJSON     = "json"
+)
+
+// validateFormat validates the output format flag value.
+func validateFormat(_ *cmd.Command, s string) error {
+	if s != formatMarkdown && s != formatJSON {
+		return fmt.Errorf("unsupported output format: %s", s)
+	}
+
+	return nil
+}
+
+// validateDiffLimit validates the diff size limit flag value.
+func validateDiffLimit(_ *cmd.Command, i int) error {
+	if i < 0 {
+		return errors.New("diff limit must not be negative")
+	}
+
+	return nil
+}
+
 // setIfFlagIsSet sets the value from the flag to the option if the flag is set and the value is not nil.
 func setIfFlagIsSet[T cmd.FlagType](target *T, source cmd.Flag[T]) {
 	if target == nil || source.Value == nil || !source.IsSet() {
@@ -250,6 +748,19 @@ func setIfFlagIsSet[T cmd.FlagType](target *T, source cmd.Flag[T]) {
 	*target = *source.Value
 }
 
+// splitList splits the comma-separated list, ignoring empty items.
+func splitList(s string) []string {
+	var list []string
+
+	for item := range strings.SplitSeq(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
 // getWorkingDir returns the working directory to use for the application.
 func (*App) getWorkingDir(args []string) (string, error) {
 	var dir string
@@ -297,88 +808,418 @@ func (a *App) Run(ctx context.Context, args []string) error { return a.cmd.Run(c
 func (a *App) Help() string { return a.cmd.Help() }
 
 // run in the main logic of the application.
-func (a *App) run(ctx context.Context, workingDir string) error { //nolint:funlen
-	debug.Printf("AI provider: %s", a.opt.AIProviderName)
+func (a *App) run(ctx context.Context, workingDir string) error {
+	if a.opt.Revision != "" || a.opt.Amend {
+		return a.runRevisions(ctx, workingDir)
+	}
 
-	var provider ai.Provider
+	if a.opt.DryRun {
+		return a.dryRun(ctx, os.Stdout, workingDir, "")
+	}
+
+	if a.opt.Interactive {
+		return a.runInteractive(ctx, workingDir, "")
+	}
+
+	response, err := a.generate(ctx, workingDir, "")
+	if err != nil {
+		return err
+	}
+
+	return writeAnswers(os.Stdout, response)
+}
+
+// writeAnswers writes the answer to w. The alternatives are written as a numbered list.
+func writeAnswers(w io.Writer, response *ai.Response) error {
+	if len(response.Answers) > 1 { // print the alternatives as a numbered list
+		for i, answer := range response.Answers {
+			if i > 0 {
+				if _, err := fmt.Fprintln(w); err != nil {
+					return err
+				}
+			}
+
+			var prefix = fmt.Sprintf("%d. ", i+1)
+
+			if _, err := fmt.Fprintln(w, prefix+strings.ReplaceAll(
+				strings.TrimRight(answer, "\n"), "\n", "\n"+strings.Repeat(" ", len(prefix)),
+			)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	_, err := fmt.Fprintln(w, response.Answer)
+
+	return err
+}
+
+// newProvider creates the AI provider based on the options. If the fallback providers are set, the
+// [providerChain] is returned.
+func (a *App) newProvider() (ai.Provider, error) {
+	if err := a.opt.ValidateProvider(); err != nil {
+		return nil, fmt.Errorf("invalid options: %w", err)
+	}
+
+	provider, err := a.newProviderByName(a.opt.AIProviderName, "")
+	if err != nil || len(a.opt.Fallback) == 0 {
+		return provider, err
+	}
+
+	var chain = providerChain{{Provider: provider, name: a.opt.AIProviderName}}
+
+	for _, entry := range a.opt.Fallback {
+		fallback, fErr := a.newProviderByName(splitFallback(entry))
+		if fErr != nil {
+			return nil, fErr
+		}
+
+		chain = append(chain, namedProvider{Provider: fallback, name: entry})
+	}
 
-	switch a.opt.AIProviderName {
+	return chain, nil
+}
+
+// newProviderByName creates the AI provider (built-in or defined by the profile) with the given name. The
+// model overrides the configured one, if set.
+func (a *App) newProviderByName(name, model string) (ai.Provider, error) { //nolint:funlen
+	if profile, ok := a.opt.Profiles[name]; ok {
+		profile.ModelName = cmp.Or(model, profile.ModelName)
+
+		return profile.newProvider()
+	}
+
+	switch name {
 	case ai.ProviderGemini:
-		provider = ai.NewGemini(
+		return ai.NewGemini(
 			a.opt.Providers.Gemini.ApiKey,
-			a.opt.Providers.Gemini.ModelName,
+			cmp.Or(model, a.opt.Providers.Gemini.ModelName),
 			ai.WithGeminiBaseURL(a.opt.Providers.Gemini.BaseURL),
-		)
+		), nil
 	case ai.ProviderOpenAI:
-		provider = ai.NewOpenAI(
+		return ai.NewOpenAI(
 			a.opt.Providers.OpenAI.ApiKey,
-			a.opt.Providers.OpenAI.ModelName,
+			cmp.Or(model, a.opt.Providers.OpenAI.ModelName),
 			ai.WithOpenAIBaseURL(a.opt.Providers.OpenAI.BaseURL),
-		)
+		), nil
 	case ai.ProviderOpenRouter:
-		provider = ai.NewOpenRouter(
+		return ai.NewOpenRouter(
 			a.opt.Providers.OpenRouter.ApiKey,
-			a.opt.Providers.OpenRouter.ModelName,
+			cmp.Or(model, a.opt.Providers.OpenRouter.ModelName),
 			ai.WithOpenRouterBaseURL(a.opt.Providers.OpenRouter.BaseURL),
-		)
+		), nil
 	case ai.ProviderAnthropic:
-		provider = ai.NewAnthropic(
+		return ai.NewAnthropic(
 			a.opt.Providers.Anthropic.ApiKey,
-			a.opt.Providers.Anthropic.ModelName,
+			cmp.Or(model, a.opt.Providers.Anthropic.ModelName),
 			ai.WithAnthropicBaseURL(a.opt.Providers.Anthropic.BaseURL),
-		)
-	default:
-		return fmt.Errorf("unsupported AI provider: %s", a.opt.AIProviderName)
+		), nil
+	case ai.ProviderOllama:
+		return ai.NewOllama(
+			cmp.Or(model, a.opt.Providers.Ollama.ModelName),
+			ai.WithOllamaBaseURL(a.opt.Providers.Ollama.BaseURL),
+			ai.WithOllamaNumCtx(a.opt.Providers.Ollama.NumCtx),
+			ai.WithOllamaKeepAlive(a.opt.Providers.Ollama.KeepAlive),
+			ai.WithOllamaAutoPull(a.opt.Providers.Ollama.AutoPull, os.Stderr),
+		), nil
+	case ai.ProviderAzure:
+		return ai.NewAzureOpenAI(
+			a.opt.Providers.AzureOpenAI.ApiKey,
+			a.opt.Providers.AzureOpenAI.Endpoint,
+			cmp.Or(model, a.opt.Providers.AzureOpenAI.DeploymentName),
+			ai.WithAzureOpenAIAPIVersion(a.opt.Providers.AzureOpenAI.APIVersion),
+		), nil
+	}
+
+	return nil, fmt.Errorf("unsupported AI provider: %s", name)
+}
+
+// generate collects the changes and the commit history from the repository and asks the AI provider to
+// describe them. If the revision is set, the changes introduced by the commit are described instead of the
+// staged ones.
+func (a *App) generate(ctx context.Context, workingDir, rev string) (*ai.Response, error) {
+	provider, pErr := a.newProvider()
+	if pErr != nil {
+		return nil, pErr
 	}
 
+	changes, commits, opts, err := a.prepare(ctx, provider, workingDir, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := a.query(ctx, provider, changes, commits, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = a.prependIssueKey(ctx, workingDir, rev, response); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// prepare collects the changes and the commit history, summarizes the changes if they are too large (see
+// [App.summarize]), checks the input budget (see [App.checkInputBudget]), and returns them along with the
+// prompt options for [App.query].
+func (a *App) prepare(
+	ctx context.Context,
+	provider ai.Provider,
+	workingDir, rev string,
+) (changes, commits string, _ []ai.Option, _ error) {
+	changes, commits, err := a.collect(ctx, workingDir, rev)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	changes, summarized, err := a.summarize(ctx, provider, changes)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	opts, prompt, err := a.prompt(ctx, workingDir, rev, summarized)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	var estimate = a.estimateInput(prompt, changes, commits)
+
+	debug.Printf("estimated input: %s", estimate)
+
+	if err = a.checkInputBudget(estimate); err != nil {
+		return "", "", nil, err
+	}
+
+	return changes, commits, opts, nil
+}
+
+// collect returns the changes (git diff) and the commit history (git log) from the repository. If the
+// revision is set, the changes introduced by the commit and the history preceding it are returned.
+func (a *App) collect(ctx context.Context, workingDir, rev string) (changes, commits string, _ error) {
 	debug.Printf("working directory: %s", workingDir)
 
 	var (
-		eg, _            = errgroup.New(ctx)
-		changes, commits string
+		eg, _    = errgroup.New(ctx)
+		diffOpts = a.diffOptions()
+		logOpts  = a.logOptions()
+	)
+
+	if rev != "" {
+		debug.Printf("revision: %s", rev)
+
+		diffOpts, logOpts = append(diffOpts, git.WithRevision(rev)), append(logOpts, git.WithLogBefore(rev))
+	}
+
+	var (
+		histLen   = int(a.opt.CommitHistoryLength)
+		samePaths = histLen > 0 && a.opt.CommitHistorySamePaths
 	)
 
 	eg.Go(func(ctx context.Context) (err error) {
-		changes, err = git.Diff(ctx, workingDir)
+		if changes, err = git.Diff(ctx, workingDir, diffOpts...); err != nil || !samePaths {
+			return
+		}
+
+		// the history depends on the changed files, so it's read after the diff
+		commits, err = git.Log(ctx, workingDir, histLen, append(logOpts, git.WithLogPaths(changedPaths(changes)...))...)
 
 		return
 	})
 
-	if histLen := int(a.opt.CommitHistoryLength); histLen > 0 {
+	switch {
+	case histLen <= 0:
+		commits = "NO COMMITS"
+	case !samePaths:
 		eg.Go(func(ctx context.Context) (err error) {
-			commits, err = git.Log(ctx, workingDir, histLen)
+			commits, err = git.Log(ctx, workingDir, histLen, logOpts...)
 
 			return
 		})
-	} else {
-		commits = "NO COMMITS"
 	}
 
 	if err := eg.Wait(); err != nil {
-		return err
+		return "", "", err
 	}
 
 	debug.Printf("changes:\n%s", changes)
 	debug.Printf("commits:\n%s", commits)
 
 	if changes == "" {
-		return fmt.Errorf("no changes found in %s (probably nothing staged; try `git add -A`)", workingDir)
+		if rev != "" {
+			return "", "", fmt.Errorf("%w in the commit %s", errNoChanges, rev)
+		}
+
+		if a.opt.DiffSource == git.SourceStaged {
+			return "", "", fmt.Errorf("%w in %s (probably nothing staged; try `git add -A` or `--diff-source all`)",
+				errNoChanges, workingDir,
+			)
+		}
+
+		return "", "", fmt.Errorf("%w in %s (diff source: %s)", errNoChanges, workingDir, a.opt.DiffSource)
 	}
 
-	var response *ai.Response
+	return changes, commits, nil
+}
 
-	if retryErr := retry.Do(ctx, func(ctx context.Context, attempt uint) (bool, error) {
-		if attempt > 0 {
-			debug.Printf("retrying after error (attempt %d of %d)", attempt, a.opt.MaxRetries)
+// The limits of the commit history details.
+const (
+	historyBodyMaxChars   = 500 // per commit message body
+	historyFilesPerCommit = 10  // the rest of the changed files are counted only
+	historyMaxPaths       = 100 // the changed files to look for in the history
+)
+
+// logOptions returns the options for [git.Log] (the revision and the paths are set by the caller).
+func (a *App) logOptions() []git.LogOption {
+	var opts = []git.LogOption{git.WithLogMaxChars(a.opt.CommitHistoryMaxChars)}
+
+	if a.opt.CommitHistoryBodies {
+		opts = append(opts, git.WithLogBodies(historyBodyMaxChars))
+	}
+
+	if a.opt.CommitHistoryAuthors {
+		opts = append(opts, git.WithLogAuthors())
+	}
+
+	if a.opt.CommitHistoryFiles {
+		opts = append(opts, git.WithLogFiles(historyFilesPerCommit))
+	}
+
+	return opts
+}
+
+// changedPaths returns the paths of the files touched by the diff, which have the history (the added files are
+// skipped, and the renamed ones are tracked by their old paths), up to [historyMaxPaths].
+func changedPaths(diff string) []string {
+	files, err := git.ParseDiff(diff)
+	if err != nil {
+		debug.Printf("failed to parse the diff to get the changed paths: %v", err)
+
+		return nil
+	}
+
+	var paths = make([]string, 0, min(len(files), historyMaxPaths))
+
+	for _, f := range files {
+		if len(paths) == historyMaxPaths {
+			break
 		}
 
+		switch f.Status {
+		case git.FileAdded:
+			continue
+		case git.FileRenamed, git.FileCopied:
+			paths = append(paths, f.OldPath)
+		default:
+			paths = append(paths, f.Path())
+		}
+	}
+
+	debug.Printf("commit history paths: %v", paths)
+
+	return paths
+}
+
+// diffOptions returns the options for [git.Diff].
+func (a *App) diffOptions() []git.DiffOption {
+	var opts = []git.DiffOption{
+		git.WithSource(a.opt.DiffSource),
+		git.WithExclude(a.opt.Exclude...),
+		git.WithInclude(a.opt.Include...),
+	}
+
+	if !a.opt.DefaultExcludes {
+		opts = append(opts, git.WithoutDefaultExcludes())
+	}
+
+	if !a.opt.GitAttributes {
+		opts = append(opts, git.WithoutAttributes())
+	}
+
+	return opts
+}
+
+// query asks the AI provider to describe the changes, retrying on retryable errors. The options returned by
+// [App.prepare] are expected to be passed.
+func (a *App) query(
+	ctx context.Context,
+	provider ai.Provider,
+	changes, commits string,
+	extra ...ai.Option,
+) (*ai.Response, error) {
+	debug.Printf("AI provider: %s", a.opt.AIProviderName)
+
+	var out *streamWriter
+
+	if a.streaming() {
+		out = &streamWriter{w: os.Stderr}
+
+		defer func() { _ = out.Close() }()
+	}
+
+	response, err := a.ask(ctx, provider, out, changes, commits, a.queryOptions(extra...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	debug.Printf("prompt:\n%s", response.Prompt)
+	for i, answer := range response.Answers {
+		debug.Printf("answer #%d:\n%s\n", i+1, answer)
+	}
+
+	return response, nil
+}
+
+// queryOptions returns the options of the request to the AI provider (except the streaming one, see
+// [App.streaming]) followed by the extra ones.
+func (a *App) queryOptions(extra ...ai.Option) []ai.Option {
+	return append([]ai.Option{
+		ai.WithMaxOutputTokens(a.opt.MaxOutputTokens),
+		ai.WithCandidates(a.opt.Candidates),
+	}, extra...)
+}
+
+// streaming reports whether the answer is streamed (it's not supported for multiple candidates).
+func (a *App) streaming() bool { return a.opt.Stream && a.opt.Candidates <= 1 }
+
+// ask sends the request to the AI provider with the given options, retrying on retryable errors. For the
+// [providerChain], the next provider is asked when the previous one fails. The responses are cached (see
+// [App.responseCache]). The answer is streamed to out, if set.
+func (a *App) ask(
+	ctx context.Context,
+	provider ai.Provider,
+	out *streamWriter,
+	changes, commits string,
+	opts ...ai.Option,
+) (*ai.Response, error) {
+	if chain, ok := provider.(providerChain); ok { // each provider of the chain is retried separately
+		return chain.query(func(p ai.Provider) (*ai.Response, error) {
+			return a.ask(ctx, p, out, changes, commits, opts...)
+		})
+	}
+
+	var store = a.responseCache()
+
+	key, response := a.cachedResponse(ctx, store, provider, changes, commits, opts...)
+	if response != nil {
+		return response, nil // nothing is spent, so the usage is not recorded
+	}
+
+	if out != nil {
+		opts = append(opts[:len(opts):len(opts)], ai.WithStream(out))
+	}
+
+	if retryErr := retry.Do(ctx, func(ctx context.Context, _ uint) (bool, error) {
 		var queryErr error
 
-		response, queryErr = provider.Query(ctx, changes, commits,
-			ai.WithShortMessageOnly(a.opt.ShortMessageOnly),
-			ai.WithEmoji(a.opt.EnableEmoji),
-			ai.WithMaxOutputTokens(a.opt.MaxOutputTokens),
-		)
+		// the previous attempt (or provider) may have streamed a part of the answer before failing
+		if err := out.Interrupt(); err != nil {
+			return true, err
+		}
+
+		response, queryErr = provider.Query(ctx, changes, commits, opts...)
 		if queryErr == nil {
 			return false, nil
 		}
@@ -393,16 +1234,65 @@ func (a *App) run(ctx context.Context, workingDir string) error { //nolint:funle
 			return a.opt.MaxRetries + 1
 		}()),
 		retry.WithDelay(a.opt.RetryDelay),
+		retry.WithBackoff(a.opt.RetryBackoff),
+		retry.WithMaxDelay(a.opt.RetryMaxDelay),
+		retry.WithJitter(a.opt.RetryJitter),
+		retry.WithDeadline(a.opt.RetryDeadline),
+		retry.WithNotify(func(attempt uint, err error, delay time.Duration) {
+			debug.Printf("retrying in %s after error (attempt %d of %d): %v",
+				delay.Round(time.Millisecond), attempt, a.opt.MaxRetries, err,
+			)
+		}),
 	); retryErr != nil {
-		return retryErr
+		return nil, retryErr
 	}
 
-	debug.Printf("prompt:\n%s", response.Prompt)
-	debug.Printf("answer:\n%s\n", response.Answer)
+	a.usage.add(response.Model, response.Usage)
 
-	if _, err := fmt.Fprintln(os.Stdout, response.Answer); err != nil {
-		return err
+	if key != "" {
+		if err := store.Set(key, response); err != nil {
+			debug.Printf("failed to cache the response: %v", err)
+		}
 	}
 
-	return nil
+	return response, nil
+}
+
+// streamWriter writes the streamed answer and terminates it with a line break on close (if anything was
+// written), so the following output starts on a new line.
+type streamWriter struct {
+	w       io.Writer
+	written bool
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		s.written = true
+	}
+
+	return s.w.Write(p)
+}
+
+// Interrupt terminates the partially streamed answer (if anything was written) with a notice, so the answer of
+// the next attempt is not mixed with it. It's safe to call on the nil writer.
+func (s *streamWriter) Interrupt() error {
+	if s == nil || !s.written {
+		return nil
+	}
+
+	s.written = false
+
+	_, err := io.WriteString(s.w, "\n[interrupted, retrying]\n")
+
+	return err
+}
+
+func (s *streamWriter) Close() error {
+	if !s.written {
+		return nil
+	}
+
+	_, err := io.WriteString(s.w, "\n")
+
+	return err
 }
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+)
+
+func TestApp_Ask_StreamRetry(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		if calls.Add(1) == 1 { // the first attempt fails in the middle of the stream
+			_, _ = io.WriteString(w, `data: {"candidates":[{"content":{"parts":[{"text":"feat: Par"}]}}]}`+"\n\n"+
+				`data: {"error":{"message":"overloaded","status":"UNAVAILABLE"}}`+"\n\n")
+
+			return
+		}
+
+		_, _ = io.WriteString(w, `data: {"candidates":[{"content":{"parts":[{"text":"feat: Full answer"}]}}]}`+"\n\n")
+	}))
+
+	t.Cleanup(srv.Close)
+
+	var (
+		app = NewApp("app")
+		buf bytes.Buffer
+		out = &streamWriter{w: &buf}
+	)
+
+	app.opt.Cache = false
+	app.opt.RetryDelay, app.opt.RetryJitter = time.Millisecond, false
+
+	response, err := app.ask(t.Context(), ai.NewGemini("key", "model", ai.WithGeminiBaseURL(srv.URL)), out, "diff", "log")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err = out.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Answer != "feat: Full answer" {
+		t.Errorf("unexpected answer: %q", response.Answer)
+	}
+
+	if want := "feat: Par\n[interrupted, retrying]\nfeat: Full answer\n"; buf.String() != want {
+		t.Errorf("unexpected streamed output:\n%q\nwant:\n%q", buf.String(), want)
+	}
+}
+
+func TestStreamWriter_Interrupt(t *testing.T) {
+	t.Parallel()
+
+	if err := (*streamWriter)(nil).Interrupt(); err != nil {
+		t.Errorf("the nil writer must be ignored, got %v", err)
+	}
+
+	var (
+		buf bytes.Buffer
+		out = &streamWriter{w: &buf}
+	)
+
+	if err := out.Interrupt(); err != nil || buf.Len() != 0 {
+		t.Errorf("nothing must be written before the answer, got %q (%v)", buf.String(), err)
+	}
+
+	_, _ = io.WriteString(out, "partial")
+	_ = out.Interrupt()
+	_ = out.Interrupt() // the second call does nothing, since nothing is written since the first one
+
+	if want := "partial\n[interrupted, retrying]\n"; buf.String() != want {
+		t.Errorf("unexpected output: %q, want %q", buf.String(), want)
+	}
+}
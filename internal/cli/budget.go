@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"gh.tarampamp.am/describe-commit/internal/tokens"
+)
+
+// The actions to take when the estimated number of input tokens exceeds the budget.
+const (
+	budgetActionWarn  = "warn"
+	budgetActionAbort = "abort"
+)
+
+// budgetActions returns the list of supported input budget actions.
+func budgetActions() []string { return []string{budgetActionWarn, budgetActionAbort} }
+
+// isBudgetActionSupported checks if the given input budget action is supported.
+func isBudgetActionSupported(s string) bool { return slices.Contains(budgetActions(), s) }
+
+// errInputBudgetExceeded is returned when the request is too large and the budget action is "abort".
+var errInputBudgetExceeded = errors.New("input budget exceeded")
+
+// inputEstimate is the estimated number of the input tokens of the request, by its parts.
+type inputEstimate struct{ Prompt, Changes, Commits int }
+
+// Total returns the estimated number of the input tokens of the whole request.
+func (e inputEstimate) Total() int { return e.Prompt + e.Changes + e.Commits }
+
+func (e inputEstimate) String() string {
+	return fmt.Sprintf("~%d tokens (prompt ~%d, diff ~%d, log ~%d)", e.Total(), e.Prompt, e.Changes, e.Commits)
+}
+
+// estimateInput estimates (offline) the number of the input tokens of the request to the selected AI provider.
+func (a *App) estimateInput(prompt, changes, commits string) inputEstimate {
+	var provider = a.opt.providerType(a.opt.AIProviderName)
+
+	return inputEstimate{
+		Prompt:  tokens.EstimateFor(provider, prompt),
+		Changes: tokens.EstimateFor(provider, changes),
+		Commits: tokens.EstimateFor(provider, commits),
+	}
+}
+
+// checkInputBudget prints a warning to stderr, or returns an error (depending on the budget action), when
+// the estimated number of the input tokens exceeds the budget.
+func (a *App) checkInputBudget(e inputEstimate) error {
+	if a.opt.MaxInputTokens == 0 || e.Total() <= a.opt.MaxInputTokens {
+		return nil
+	}
+
+	var msg = fmt.Sprintf("the request is estimated at %s, which exceeds the budget of %d tokens",
+		e, a.opt.MaxInputTokens,
+	)
+
+	if a.opt.InputBudgetAction == budgetActionAbort {
+		return fmt.Errorf("%w: %s (use --max-diff-tokens to summarize large diffs, or --exclude to skip files)",
+			errInputBudgetExceeded, msg,
+		)
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "%s: warning: %s\n", a.cmd.Name, msg)
+
+	return nil
+}
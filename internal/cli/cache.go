@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+	"gh.tarampamp.am/describe-commit/internal/cache"
+	"gh.tarampamp.am/describe-commit/internal/cli/cmd"
+	"gh.tarampamp.am/describe-commit/internal/debug"
+)
+
+// cacheAppName is the name of the cache directory in the user cache directory.
+const cacheAppName = "describe-commit"
+
+// newCacheCommand creates the `cache` command with the `clear` subcommand.
+func (a *App) newCacheCommand() *cmd.Command {
+	return &cmd.Command{
+		Name:        "cache",
+		Description: "Manage the cache of the AI provider responses",
+		Commands: []*cmd.Command{
+			{
+				Name:        "clear",
+				Description: "Remove all the cached responses",
+				Action: func(_ context.Context, c *cmd.Command, _ []string) error {
+					var dir = cache.DefaultDir(cacheAppName)
+					if dir == "" {
+						return errors.New("failed to determine the cache directory")
+					}
+
+					removed, err := cache.New(dir, a.opt.CacheTTL).Clear()
+					if err != nil {
+						return fmt.Errorf("failed to clear the cache: %w", err)
+					}
+
+					_, err = fmt.Fprintf(c.Output, "removed %d cached response(s) from %s\n", removed, dir)
+
+					return err
+				},
+			},
+		},
+	}
+}
+
+// responseCache returns the cache of the AI provider responses (nil if the caching is disabled).
+func (a *App) responseCache() *cache.Cache {
+	if !a.opt.Cache {
+		return nil
+	}
+
+	var dir = cache.DefaultDir(cacheAppName)
+	if dir == "" {
+		return nil
+	}
+
+	return cache.New(dir, a.opt.CacheTTL)
+}
+
+// cachedResponse returns the key of the request in the cache (empty if the caching is disabled or the request
+// can not be rendered), and the cached response (nil if missing or expired).
+func (a *App) cachedResponse(
+	ctx context.Context,
+	store *cache.Cache,
+	provider ai.Provider,
+	changes, commits string,
+	opts ...ai.Option,
+) (string, *ai.Response) {
+	if store == nil {
+		return "", nil
+	}
+
+	// the key is the hash of the exact request (the provider URL, the model, the prompt, the diff and the log), the
+	// streaming mode is ignored since it does not affect the answer
+	req, err := provider.Request(ctx, changes, commits, append(opts[:len(opts):len(opts)], ai.WithStream(nil))...)
+	if err != nil {
+		debug.Printf("the response is not cached, failed to render the request: %v", err)
+
+		return "", nil
+	}
+
+	var body []byte
+
+	if req.Body != nil {
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return "", nil
+		}
+	}
+
+	var (
+		key      = cache.Key(req.Method, req.URL.String(), string(body), strconv.Itoa(a.opt.Candidates))
+		response ai.Response
+	)
+
+	if ok, gErr := store.Get(key, &response); gErr != nil {
+		debug.Printf("failed to read the cached response: %v", gErr)
+	} else if ok {
+		debug.Printf("the answer is served from the cache (key %s)", key)
+
+		return key, &response
+	}
+
+	return key, nil
+}
@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+	"gh.tarampamp.am/describe-commit/internal/cache"
+)
+
+func TestApp_CachedResponse_Key(t *testing.T) {
+	t.Parallel()
+
+	var (
+		openAI = func(model string) ai.Provider {
+			return ai.NewOpenAI("key", model, ai.WithOpenAIBaseURL("http://127.0.0.1:1"))
+		}
+		keyOf = func(t *testing.T, provider ai.Provider, candidates int, opts ...ai.Option) string {
+			t.Helper()
+
+			var app = NewApp("app")
+
+			app.opt.Candidates = candidates
+
+			key, response := app.cachedResponse(t.Context(), cache.New(t.TempDir(), time.Hour), provider, "diff", "log", opts...)
+			if key == "" || response != nil {
+				t.Fatalf("expected the key without the cached response, got %q and %v", key, response)
+			}
+
+			return key
+		}
+		base = keyOf(t, openAI("gpt"), 1)
+	)
+
+	for name, tc := range map[string]struct {
+		giveKey  string
+		wantSame bool
+	}{
+		"same request":        {giveKey: keyOf(t, openAI("gpt"), 1), wantSame: true},
+		"streaming":           {giveKey: keyOf(t, openAI("gpt"), 1, ai.WithStream(io.Discard)), wantSame: true},
+		"another provider":    {giveKey: keyOf(t, ai.NewAnthropic("key", "gpt"), 1)},
+		"another base URL":    {giveKey: keyOf(t, ai.NewOpenAI("key", "gpt", ai.WithOpenAIBaseURL("http://127.0.0.1:2")), 1)},
+		"another model":       {giveKey: keyOf(t, openAI("gpt-mini"), 1)},
+		"another option":      {giveKey: keyOf(t, openAI("gpt"), 1, ai.WithEmoji(true))},
+		"another max tokens":  {giveKey: keyOf(t, openAI("gpt"), 1, ai.WithMaxOutputTokens(42))},
+		"another candidates":  {giveKey: keyOf(t, openAI("gpt"), 3)},
+		"another instruction": {giveKey: keyOf(t, openAI("gpt"), 1, ai.WithInstructions("be brief"))},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if same := tc.giveKey == base; same != tc.wantSame {
+				t.Errorf("expected the same key: %t, got %t (%s vs %s)", tc.wantSame, same, tc.giveKey, base)
+			}
+		})
+	}
+}
+
+func TestApp_CachedResponse(t *testing.T) {
+	t.Parallel()
+
+	var (
+		app      = NewApp("app")
+		store    = cache.New(t.TempDir(), time.Hour)
+		provider = ai.NewOpenAI("key", "gpt", ai.WithOpenAIBaseURL("http://127.0.0.1:1"))
+	)
+
+	if key, response := app.cachedResponse(t.Context(), nil, provider, "diff", "log"); key != "" || response != nil {
+		t.Errorf("nothing is expected without the cache, got %q and %v", key, response)
+	}
+
+	key, _ := app.cachedResponse(t.Context(), store, provider, "diff", "log")
+
+	if err := store.Set(key, &ai.Response{Answer: "feat: Cached"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, response := app.cachedResponse(t.Context(), store, provider, "diff", "log"); response == nil ||
+		response.Answer != "feat: Cached" {
+		t.Errorf("expected the cached response, got %v", response)
+	}
+
+	if _, response := app.cachedResponse(t.Context(), store, provider, "another diff", "log"); response != nil {
+		t.Errorf("the response for another diff must not be served from the cache, got %v", response)
+	}
+}
+
+func TestApp_Ask_Cache(t *testing.T) { //nolint:paralleltest // modifies the environment
+	var calls atomic.Int32
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/tags" { // the models list of Ollama
+			_, _ = io.WriteString(w, `{"models":[]}`)
+
+			return
+		}
+
+		calls.Add(1)
+
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"content":"feat: Answer"}}]}`)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir()) // the default cache directory on Linux
+	t.Setenv("HOME", t.TempDir())           // and on macOS
+
+	var provider = ai.NewOpenAI("key", "gpt", ai.WithOpenAIBaseURL(srv.URL))
+
+	for name, tc := range map[string]struct {
+		giveArgs  []string
+		wantCalls int32
+	}{
+		"cached":   {wantCalls: 1},
+		"no cache": {giveArgs: []string{"--no-cache"}, wantCalls: 2},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var (
+				app = NewApp("app")
+				out bytes.Buffer
+			)
+
+			app.cmd.Output = &out
+
+			// the options are loaded by the command, any of them loading the options fits
+			if err := app.Run(t.Context(), append(append([]string{
+				"--config-file", filepath.Join(t.TempDir(), "missing.yml"),
+				"--ai", ai.ProviderOllama,
+				"--ollama-base-url", srv.URL,
+			}, tc.giveArgs...), "models")); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if (app.responseCache() == nil) != (tc.wantCalls > 1) {
+				t.Fatalf("unexpected cache: %v", app.responseCache())
+			}
+
+			calls.Store(0)
+
+			for range 2 {
+				response, err := app.ask(t.Context(), provider, nil, name, "log")
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if response.Answer != "feat: Answer" {
+					t.Errorf("unexpected answer: %q", response.Answer)
+				}
+			}
+
+			if got := calls.Load(); got != tc.wantCalls {
+				t.Errorf("expected %d request(s) to the provider, got %d", tc.wantCalls, got)
+			}
+		})
+	}
+}
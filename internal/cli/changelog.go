@@ -0,0 +1,229 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+	"gh.tarampamp.am/describe-commit/internal/changelog"
+	"gh.tarampamp.am/describe-commit/internal/cli/cmd"
+	"gh.tarampamp.am/describe-commit/internal/debug"
+	"gh.tarampamp.am/describe-commit/internal/errgroup"
+	"gh.tarampamp.am/describe-commit/internal/git"
+)
+
+// releaseNotesMinOutputTokens is the minimal output tokens limit for the release notes of a single section (the
+// larger configured limit is used as is).
+const releaseNotesMinOutputTokens = 1000
+
+// newChangelogCommand creates the `changelog` command, which groups the commits between two revisions (tags, by
+// default) into the Keep a Changelog sections.
+func (a *App) newChangelogCommand(loadOptions func(wd string) error) *cmd.Command { //nolint:funlen
+	var (
+		from = cmd.Flag[string]{
+			Names: []string{"from"},
+			Usage: "The revision (exclusive) to start from (default: the previous tag; the whole history if there " +
+				"are no tags)",
+		}
+		to = cmd.Flag[string]{
+			Names:   []string{"to"},
+			Usage:   "The revision (inclusive) to end at",
+			Default: "HEAD",
+		}
+		release = cmd.Flag[string]{
+			Names: []string{"release"},
+			Usage: "The version in the heading (default: the tag of the --to revision, or \"Unreleased\")",
+		}
+		summarize = cmd.Flag[bool]{
+			Names: []string{"summarize"},
+			Usage: "Ask the AI provider to summarize each section into the user-facing release notes",
+		}
+		all = cmd.Flag[bool]{
+			Names: []string{"all"},
+			Usage: "Include the internal changes (docs, tests, CI, chores, etc.) into the \"Changed\" section",
+		}
+		format = cmd.Flag[string]{
+			Names:     []string{"format", "f"},
+			Usage:     fmt.Sprintf("Output format (%s|%s)", formatMarkdown, formatJSON),
+			Default:   formatMarkdown,
+			Validator: validateFormat,
+		}
+	)
+
+	return &cmd.Command{
+		Name:        "changelog",
+		Description: "Generate the changelog (Keep a Changelog) from the Conventional Commits between two tags",
+		Usage:       "[<options>] [<git-dir-path>]",
+		Flags:       []cmd.Flagger{&from, &to, &release, &summarize, &all, &format},
+		Action: func(ctx context.Context, c *cmd.Command, args []string) error {
+			var wd, wdErr = a.getWorkingDir(args)
+			if wdErr != nil {
+				return fmt.Errorf("wrong working directory: %w", wdErr)
+			}
+
+			if err := loadOptions(wd); err != nil {
+				return err
+			}
+
+			rel, err := a.changelog(ctx, wd, *from.Value, *to.Value, *release.Value, *all.Value)
+			if err != nil {
+				return err
+			}
+
+			if *summarize.Value {
+				defer a.reportUsage(wd)
+
+				if err = a.summarizeRelease(ctx, rel); err != nil {
+					return err
+				}
+			}
+
+			if *format.Value == formatJSON {
+				var enc = json.NewEncoder(c.Output)
+
+				enc.SetIndent("", "  ")
+
+				return enc.Encode(rel)
+			}
+
+			_, err = io.WriteString(c.Output, rel.Markdown())
+
+			return err
+		},
+	}
+}
+
+// changelog reads the commits between the revisions and groups them into the release sections. If the start
+// revision is empty, the previous tag is used. The version is the tag of the end revision (with the tag date),
+// unless it's set explicitly (with the current date).
+func (a *App) changelog(
+	ctx context.Context,
+	workingDir, from, to, version string,
+	includeInternal bool,
+) (*changelog.Release, error) {
+	tag, err := git.TagAt(ctx, workingDir, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if from == "" {
+		var base = to
+
+		if tag != "" { // the release itself is tagged, so the previous tag is looked up starting from its parent
+			base = to + "^"
+		}
+
+		if from, err = git.LatestTag(ctx, workingDir, base); err != nil {
+			return nil, err
+		}
+	}
+
+	var rangeRev = to
+
+	if from != "" {
+		rangeRev = from + ".." + to
+	}
+
+	debug.Printf("changelog: %s", rangeRev)
+
+	entries, err := git.LogEntries(ctx, workingDir, rangeRev)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed = make([]changelog.Entry, 0, len(entries))
+
+	for _, e := range entries {
+		parsed = append(parsed, changelog.ParseCommit(e.Hash, e.Subject, e.Body))
+	}
+
+	var date string
+
+	switch {
+	case version != "":
+		date = time.Now().Format(time.DateOnly)
+	case tag != "":
+		version = tag
+
+		if date, err = git.CommitDate(ctx, workingDir, to); err != nil {
+			return nil, err
+		}
+	default:
+		version = changelog.Unreleased
+	}
+
+	return changelog.New(version, date, parsed, includeInternal), nil
+}
+
+// summarizeRelease asks the AI provider to write the release notes for each section of the release (the sections
+// are summarized concurrently).
+func (a *App) summarizeRelease(ctx context.Context, release *changelog.Release) error {
+	if len(release.Sections) == 0 {
+		return nil
+	}
+
+	provider, err := a.newProvider()
+	if err != nil {
+		return err
+	}
+
+	var (
+		eg, _ = errgroup.New(ctx)
+		sem   = make(chan struct{}, maxConcurrentSummaries)
+	)
+
+	for i := range release.Sections {
+		var (
+			section = &release.Sections[i] // each goroutine writes its own section only
+			prompt  = ai.GenerateReleaseNotesPrompt(section.Name, ai.WithLanguage(a.opt.Language))
+			input   = releaseNotesInput(section.Entries)
+		)
+
+		if err = a.checkInputBudget(a.estimateInput(prompt, "", input)); err != nil {
+			return err
+		}
+
+		eg.Go(func(ctx context.Context) error {
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			response, qErr := a.ask(ctx, provider, nil, "", input,
+				ai.WithInstructions(prompt),
+				ai.WithMaxOutputTokens(max(a.opt.MaxOutputTokens, releaseNotesMinOutputTokens)),
+			)
+			if qErr != nil {
+				return fmt.Errorf("failed to summarize the %q section: %w", section.Name, qErr)
+			}
+
+			section.Notes = strings.TrimSpace(response.Answer)
+
+			return nil
+		})
+	}
+
+	return eg.Wait()
+}
+
+// releaseNotesInput formats the entries for the [ai.GenerateReleaseNotesPrompt]: one commit per line, with the
+// message body (if any) indented below.
+func releaseNotesInput(entries []changelog.Entry) string {
+	var b strings.Builder
+
+	for _, e := range entries {
+		b.WriteString(e.String() + "\n")
+
+		if e.Body != "" {
+			b.WriteString("  " + strings.ReplaceAll(e.Body, "\n", "\n  ") + "\n")
+		}
+	}
+
+	return b.String()
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -14,15 +15,21 @@ import (
 
 // Command represents a CLI command with flags, description, usage, and an action function.
 type Command struct {
-	Name        string    // Name of the command.
-	Description string    // Brief description of the command.
-	Usage       string    // Usage example of the command.
-	Version     string    // Version of the command.
-	Flags       []Flagger // Collection of flags associated with the command.
-	Output      io.Writer // Output writer, defaults to os.Stdout if not set.
+	Name        string     // Name of the command.
+	Description string     // Brief description of the command.
+	Usage       string     // Usage example of the command.
+	Version     string     // Version of the command.
+	Flags       []Flagger  // Collection of flags associated with the command.
+	Commands    []*Command // Collection of subcommands.
+	Output      io.Writer  // Output writer, defaults to the parent's output (or os.Stdout) if not set.
+
+	// PersistentFlags are available for the command and all of its subcommands (they can be set both before and
+	// after the subcommand name).
+	PersistentFlags []Flagger
 
 	Action func(_ context.Context, _ *Command, args []string) error // Action function executed when the command runs.
 
+	parent                *Command  // parent command (nil for the root one)
 	initOnce              sync.Once // to ensure initialization is done only once
 	showHelp, showVersion bool      // built-in flags for displaying help and version
 }
@@ -31,11 +38,38 @@ func (c *Command) init() {
 	c.initOnce.Do(func() {
 		c.Flags = append(c.Flags, // append built-in flags
 			&Flag[bool]{Names: []string{"help", "h"}, Usage: "Show help", Value: &c.showHelp},
-			&Flag[bool]{Names: []string{"version", "v"}, Usage: "Print the version", Value: &c.showVersion},
 		)
+
+		if c.parent == nil { // the version flag is available for the root command only
+			c.Flags = append(c.Flags,
+				&Flag[bool]{Names: []string{"version", "v"}, Usage: "Print the version", Value: &c.showVersion},
+			)
+		}
+
+		for _, sub := range c.Commands {
+			sub.parent = c
+		}
 	})
 }
 
+// FullName returns the command name prefixed with the names of all its parents (e.g. "app hook install").
+func (c *Command) FullName() string {
+	if c.parent == nil || c.parent.FullName() == "" {
+		return c.Name
+	}
+
+	return c.parent.FullName() + " " + c.Name
+}
+
+// inheritedFlags returns the persistent flags of all the parent commands.
+func (c *Command) inheritedFlags() (flags []Flagger) {
+	for p := c.parent; p != nil; p = p.parent {
+		flags = append(flags, p.PersistentFlags...)
+	}
+
+	return
+}
+
 // Help generates and returns a formatted help message for the command.
 func (c *Command) Help() string { //nolint:funlen
 	c.init()
@@ -44,7 +78,7 @@ func (c *Command) Help() string { //nolint:funlen
 
 	var b strings.Builder
 
-	b.Grow(len(c.Description) + len(c.Name) + len(c.Version) + len(c.Flags)*64)
+	b.Grow(len(c.Description) + len(c.Name) + len(c.Version) + (len(c.Flags)+len(c.PersistentFlags))*64)
 
 	// append the description if available
 	if c.Description != "" {
@@ -54,18 +88,20 @@ func (c *Command) Help() string { //nolint:funlen
 	}
 
 	// append usage information
-	if c.Name != "" {
+	if name := c.FullName(); name != "" {
 		if b.Len() > 0 {
 			b.WriteString("\n\n")
 		}
 
 		b.WriteString("Usage:\n")
 		b.WriteString(offset)
-		b.WriteString(c.Name)
+		b.WriteString(name)
 
 		if c.Usage != "" {
 			b.WriteRune(' ')
 			b.WriteString(c.Usage)
+		} else if len(c.Commands) > 0 {
+			b.WriteString(" <command> [<options>]")
 		}
 	}
 
@@ -80,52 +116,100 @@ func (c *Command) Help() string { //nolint:funlen
 		b.WriteString(c.Version)
 	}
 
-	// append flags if any exist
-	if len(c.Flags) > 0 {
+	// append subcommands if any exist
+	if len(c.Commands) > 0 {
 		if b.Len() > 0 {
 			b.WriteString("\n\n")
 		}
 
-		b.WriteString("Options:\n")
-
-		var (
-			longest               int // stores the length of the longest flag name for alignment
-			flagNames, flagUsages = make([]string, len(c.Flags)), make([]string, len(c.Flags))
-		)
+		b.WriteString("Commands:\n")
 
-		// iterate through flags to determine the longest name
-		for i, f := range c.Flags {
-			flagNames[i], flagUsages[i] = f.Help()
+		var longest int
 
-			if l := utf8.RuneCountInString(flagNames[i]); l > longest {
+		for _, sub := range c.Commands {
+			if l := utf8.RuneCountInString(sub.Name); l > longest {
 				longest = l
 			}
 		}
 
-		// append flag information to the help message
-		for i, flagName := range flagNames {
+		for i, sub := range c.Commands {
 			if i > 0 {
 				b.WriteRune('\n')
 			}
 
 			b.WriteString(offset)
-			b.WriteString(flagName)
+			b.WriteString(sub.Name)
 
-			// align flag descriptions
-			for j := utf8.RuneCountInString(flagName); j < longest; j++ {
+			// align command descriptions
+			for j := utf8.RuneCountInString(sub.Name); j < longest; j++ {
 				b.WriteRune(' ')
 			}
 
 			b.WriteString("  ")
-			b.WriteString(flagUsages[i])
+			b.WriteString(sub.Description)
 		}
 	}
 
+	// append flags if any exist
+	writeFlags(&b, "Options", offset, append(append([]Flagger{}, c.PersistentFlags...), c.Flags...))
+	writeFlags(&b, "Global options", offset, c.inheritedFlags())
+
 	return b.String()
 }
 
-// Run executes the command with the provided arguments.
-func (c *Command) Run(ctx context.Context, args []string) error { //nolint:funlen,contextcheck
+// writeFlags appends the section with the flags information to the help message.
+func writeFlags(b *strings.Builder, title, offset string, flags []Flagger) {
+	if len(flags) == 0 {
+		return
+	}
+
+	if b.Len() > 0 {
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString(title)
+	b.WriteString(":\n")
+
+	var (
+		longest               int // stores the length of the longest flag name for alignment
+		flagNames, flagUsages = make([]string, len(flags)), make([]string, len(flags))
+	)
+
+	// iterate through flags to determine the longest name
+	for i, f := range flags {
+		flagNames[i], flagUsages[i] = f.Help()
+
+		if l := utf8.RuneCountInString(flagNames[i]); l > longest {
+			longest = l
+		}
+	}
+
+	// append flag information to the help message
+	for i, flagName := range flagNames {
+		if i > 0 {
+			b.WriteRune('\n')
+		}
+
+		b.WriteString(offset)
+		b.WriteString(flagName)
+
+		// align flag descriptions
+		for j := utf8.RuneCountInString(flagName); j < longest; j++ {
+			b.WriteRune(' ')
+		}
+
+		b.WriteString("  ")
+		b.WriteString(flagUsages[i])
+	}
+}
+
+// Run executes the command with the provided arguments. If the first non-flag argument matches the name of
+// a subcommand, the subcommand is executed with the rest of the arguments.
+func (c *Command) Run(ctx context.Context, args []string) error { return c.run(ctx, args, nil) }
+
+// run executes the command. The inherited flag set contains the persistent flags of the parent commands, which
+// are already applied (applying them again would reset the values set by the parent command).
+func (c *Command) run(ctx context.Context, args []string, inherited *flag.FlagSet) error { //nolint:funlen,gocognit,gocyclo,lll,contextcheck
 	if ctx == nil {
 		ctx = context.Background()
 	} else if err := ctx.Err(); err != nil {
@@ -135,14 +219,18 @@ func (c *Command) Run(ctx context.Context, args []string) error { //nolint:funle
 	c.init()
 
 	// create a new flag set for parsing command-line flags
-	var set = flag.NewFlagSet(c.Name, flag.ContinueOnError)
+	var set, persistent = flag.NewFlagSet(c.Name, flag.ContinueOnError), flag.NewFlagSet(c.Name, flag.ContinueOnError)
 
 	// suppress output from the standard flag library to avoid unnecessary messages
 	set.SetOutput(io.Discard)
 
 	// set default output if not defined
 	if c.Output == nil {
-		c.Output = os.Stdout
+		if c.parent != nil && c.parent.Output != nil {
+			c.Output = c.parent.Output
+		} else {
+			c.Output = os.Stdout
+		}
 	}
 
 	// register flags in the flag set
@@ -150,6 +238,17 @@ func (c *Command) Run(ctx context.Context, args []string) error { //nolint:funle
 		f.Apply(set)
 	}
 
+	// collect the persistent flags (inherited and own) to pass them down to the subcommands
+	if inherited != nil {
+		inherited.VisitAll(func(f *flag.Flag) { persistent.Var(f.Value, f.Name, f.Usage) })
+	}
+
+	for _, f := range c.PersistentFlags {
+		f.Apply(persistent)
+	}
+
+	persistent.VisitAll(func(f *flag.Flag) { set.Var(f.Value, f.Name, f.Usage) })
+
 	// parse command-line arguments
 	if err := set.Parse(args); err != nil {
 		// display help message in case of a parsing error
@@ -185,8 +284,17 @@ func (c *Command) Run(ctx context.Context, args []string) error { //nolint:funle
 		return err
 	}
 
+	// pass the control to the subcommand, if any
+	if rest := set.Args(); len(rest) > 0 {
+		for _, sub := range c.Commands {
+			if sub.Name == rest[0] {
+				return sub.run(ctx, rest[1:], persistent)
+			}
+		}
+	}
+
 	// validate and execute any flag-specific actions
-	for _, f := range c.Flags {
+	for _, f := range append(append(append([]Flagger{}, c.inheritedFlags()...), c.PersistentFlags...), c.Flags...) {
 		if !f.IsSet() {
 			continue
 		}
@@ -202,10 +310,94 @@ func (c *Command) Run(ctx context.Context, args []string) error { //nolint:funle
 		}
 	}
 
+	var suggestion string
+
+	if rest := set.Args(); len(rest) > 0 && len(c.Commands) > 0 {
+		suggestion = c.suggest(rest[0])
+	}
+
 	// execute the main command action if set
 	if c.Action != nil {
-		return c.Action(ctx, c, set.Args())
+		err := c.Action(ctx, c, set.Args())
+		if err != nil && suggestion != "" {
+			err = fmt.Errorf("%w (did you mean %q?)", err, suggestion)
+		}
+
+		return err
+	}
+
+	if len(c.Commands) > 0 {
+		if rest := set.Args(); len(rest) > 0 {
+			if suggestion != "" {
+				return fmt.Errorf("unknown command %q, did you mean %q?", rest[0], suggestion)
+			}
+
+			return fmt.Errorf("unknown command %q", rest[0])
+		}
+
+		// no action and no subcommand - show the help message
+		if _, err := fmt.Fprintf(c.Output, "%s\n", c.Help()); err != nil {
+			return err
+		}
+
+		return errors.New("missing command")
 	}
 
 	return nil
 }
+
+// suggest returns the name of the subcommand that is the most similar to the given one, or an empty string
+// if there is no similar subcommand.
+func (c *Command) suggest(name string) string {
+	const maxDistance = 2
+
+	var (
+		best         string
+		bestDistance = maxDistance + 1
+	)
+
+	for _, sub := range c.Commands {
+		if d := levenshtein(strings.ToLower(name), strings.ToLower(sub.Name)); d < bestDistance {
+			best, bestDistance = sub.Name, d
+		}
+	}
+
+	if best == "" {
+		for _, sub := range c.Commands { // fallback to the prefix match (e.g. "gen" for "generate")
+			if len(name) > 1 && strings.HasPrefix(sub.Name, name) {
+				return sub.Name
+			}
+		}
+	}
+
+	return best
+}
+
+// levenshtein calculates the Levenshtein (edit) distance between two strings.
+func levenshtein(a, b string) int {
+	var ra, rb = []rune(a), []rune(b)
+
+	var prev, curr = make([]int, len(rb)+1), make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(rb); j++ {
+			var cost = 1
+
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
@@ -297,3 +297,151 @@ func TestCommand_Run(t *testing.T) {
 		assertEqual(t, executed, true)
 	})
 }
+
+func TestCommand_Subcommands(t *testing.T) {
+	t.Parallel()
+
+	var ctx = context.Background()
+
+	// newApp creates a command tree for the tests: "app [--global] sub [--local] <args>"
+	var newApp = func(out *strings.Builder) (root, sub *cmd.Command, global, local *string, gotArgs *[]string) {
+		global, local, gotArgs = new(string), new(string), new([]string)
+
+		sub = &cmd.Command{
+			Name:        "sub",
+			Description: "Subcommand description",
+			Usage:       "[<args>]",
+			Flags: []cmd.Flagger{
+				&cmd.Flag[string]{Names: []string{"local", "l"}, Usage: "Local flag", Value: local},
+			},
+			Action: func(_ context.Context, _ *cmd.Command, args []string) error { *gotArgs = args; return nil },
+		}
+
+		root = &cmd.Command{
+			Name:    "app",
+			Version: "1.2.3",
+			Output:  out,
+			PersistentFlags: []cmd.Flagger{
+				&cmd.Flag[string]{Names: []string{"global", "g"}, Usage: "Global flag", Default: "def", Value: global},
+			},
+			Commands: []*cmd.Command{sub, {Name: "other", Description: "Other command"}},
+		}
+
+		return
+	}
+
+	t.Run("persistent flags before and after the subcommand", func(t *testing.T) {
+		t.Parallel()
+
+		for _, args := range [...][]string{
+			{"--global=foo", "sub", "--local=bar", "arg1", "arg2"},
+			{"sub", "--global=foo", "--local=bar", "arg1", "arg2"},
+			{"-g", "foo", "sub", "-l", "bar", "arg1", "arg2"},
+		} {
+			var root, _, global, local, gotArgs = newApp(new(strings.Builder))
+
+			assertNoError(t, root.Run(ctx, args))
+			assertEqual(t, *global, "foo")
+			assertEqual(t, *local, "bar")
+			assertEqual(t, strings.Join(*gotArgs, " "), "arg1 arg2")
+		}
+	})
+
+	t.Run("persistent flag default value", func(t *testing.T) {
+		t.Parallel()
+
+		var root, _, global, _, _ = newApp(new(strings.Builder))
+
+		assertNoError(t, root.Run(ctx, []string{"sub"}))
+		assertEqual(t, *global, "def")
+	})
+
+	t.Run("local flags are not inherited", func(t *testing.T) {
+		t.Parallel()
+
+		var root, _, _, _, _ = newApp(new(strings.Builder))
+
+		assertErrorContains(t, root.Run(ctx, []string{"--local=bar", "sub"}), "flag provided but not defined")
+	})
+
+	t.Run("help", func(t *testing.T) {
+		t.Parallel()
+
+		var out strings.Builder
+
+		var root, sub, _, _, _ = newApp(&out)
+
+		assertNoError(t, root.Run(ctx, []string{"sub", "--help"}))
+		assertEqual(t, out.String(), `Description:
+   Subcommand description
+
+Usage:
+   app sub [<args>]
+
+Options:
+   --local="…", -l="…"  Local flag
+   --help, -h           Show help
+
+Global options:
+   --global="…", -g="…"  Global flag (default: def)
+`)
+		assertEqual(t, sub.Help()+"\n", out.String())
+
+		out.Reset()
+
+		assertNoError(t, root.Run(ctx, []string{"--help"}))
+		assertEqual(t, out.String(), `Usage:
+   app <command> [<options>]
+
+Version:
+   1.2.3
+
+Commands:
+   sub    Subcommand description
+   other  Other command
+
+Options:
+   --global="…", -g="…"  Global flag (default: def)
+   --help, -h            Show help
+   --version, -v         Print the version
+`)
+	})
+
+	t.Run("missing command", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			out              strings.Builder
+			root, _, _, _, _ = newApp(&out)
+		)
+
+		assertErrorContains(t, root.Run(ctx, nil), "missing command")
+		assertEqual(t, out.String(), root.Help()+"\n")
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		t.Parallel()
+
+		var root, _, _, _, _ = newApp(new(strings.Builder))
+
+		assertErrorContains(t, root.Run(ctx, []string{"sbu"}), `unknown command "sbu", did you mean "sub"?`)
+		assertErrorContains(t, root.Run(ctx, []string{"oth"}), `did you mean "other"?`)
+		assertEqual(t, root.Run(ctx, []string{"foobar"}).Error(), `unknown command "foobar"`)
+	})
+
+	t.Run("suggestion for the command with action", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			testErr          = errors.New("test error")
+			root, _, _, _, _ = newApp(new(strings.Builder))
+		)
+
+		root.Action = func(context.Context, *cmd.Command, []string) error { return testErr }
+
+		var err = root.Run(ctx, []string{"sbu"})
+
+		assertErrorContains(t, err, `test error (did you mean "sub"?)`)
+		assertEqual(t, errors.Is(err, testErr), true)
+	})
+}
@@ -0,0 +1,203 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+	"gh.tarampamp.am/describe-commit/internal/git"
+)
+
+// redacted replaces the secrets (API keys, tokens) in the dry-run output.
+const redacted = "REDACTED"
+
+// dryRun prints what would be sent to the AI provider without calling it: the estimated number of the input
+// tokens (compared to the budget, if set), and the exact HTTP request with the secrets redacted. If the revision
+// is set, the request describing the commit is printed.
+//
+// If the diff exceeds the limit (see [App.summarize]), the request summarizing the first part is printed, followed
+// by the final request with the placeholders instead of the summaries (they are unknown without calling the AI).
+func (a *App) dryRun(ctx context.Context, out io.Writer, workingDir, rev string) error { //nolint:funlen
+	changes, commits, err := a.collect(ctx, workingDir, rev)
+	if err != nil {
+		return err
+	}
+
+	// the API keys are not validated, the request is rendered even if they are missing
+	provider, err := a.newProviderByName(a.opt.AIProviderName, "")
+	if err != nil {
+		return err
+	}
+
+	var (
+		diffSize   = len(changes)
+		limit      = a.opt.diffLimit(changes)
+		summaryReq *http.Request
+		parts      []string
+	)
+
+	if limit > 0 && diffSize > limit {
+		parts = git.SplitDiff(changes, limit)
+
+		if summaryReq, err = provider.Request(ctx, parts[0], "", a.summaryOptions()...); err != nil {
+			return fmt.Errorf("failed to render the summary request: %w", err)
+		}
+
+		var placeholders = make([]string, len(parts))
+
+		for i := range parts {
+			placeholders[i] = fmt.Sprintf("[the summary of the part %d]", i+1)
+		}
+
+		changes = joinSummaries(placeholders)
+	}
+
+	promptOpts, prompt, err := a.prompt(ctx, workingDir, rev, parts != nil)
+	if err != nil {
+		return err
+	}
+
+	var opts = a.queryOptions(promptOpts...)
+
+	if a.streaming() {
+		opts = append(opts, ai.WithStream(io.Discard))
+	}
+
+	req, err := provider.Request(ctx, changes, commits, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to render the request: %w", err)
+	}
+
+	var (
+		estimate = a.estimateInput(prompt, changes, commits)
+		tw       = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0) //nolint:mnd
+	)
+
+	_, _ = fmt.Fprintf(tw, "AI provider:\t%s\n", a.opt.AIProviderName)
+	_, _ = fmt.Fprintf(tw, "Estimated input:\t%s\n", estimate)
+
+	switch budget := a.opt.MaxInputTokens; {
+	case budget == 0:
+		_, _ = fmt.Fprintln(tw, "Input budget:\tunlimited")
+	case estimate.Total() > budget:
+		var outcome = "sent with a warning"
+
+		if a.opt.InputBudgetAction == budgetActionAbort {
+			outcome = "aborted"
+		}
+
+		_, _ = fmt.Fprintf(tw, "Input budget:\t%d tokens (exceeded, the request would be %s)\n", budget, outcome)
+	default:
+		_, _ = fmt.Fprintf(tw, "Input budget:\t%d tokens (ok)\n", budget)
+	}
+
+	if parts != nil {
+		_, _ = fmt.Fprintf(tw, "Diff:\t%d chars, exceeds the limit of %d and would be summarized by %d parts first "+
+			"(the estimate above excludes the summaries)\n", diffSize, limit, len(parts),
+		)
+	}
+
+	if err = tw.Flush(); err != nil {
+		return err
+	}
+
+	if summaryReq != nil {
+		_, _ = fmt.Fprintf(out, "\nThe request summarizing the part 1 of %d (the other parts are the same):\n\n",
+			len(parts),
+		)
+
+		if err = writeRequest(out, summaryReq); err != nil {
+			return err
+		}
+
+		_, _ = fmt.Fprint(out, "\nThe final request:\n")
+	}
+
+	_, _ = fmt.Fprintln(out)
+
+	return writeRequest(out, req)
+}
+
+// writeRequest writes the HTTP request (the method, URL, headers and the indented JSON body) to w, redacting
+// the secrets in the headers and the URL query.
+func writeRequest(w io.Writer, req *http.Request) error {
+	var u = *req.URL
+
+	if q := u.Query(); len(q) > 0 {
+		for name := range q {
+			if isSecretName(name) {
+				q.Set(name, redacted)
+			}
+		}
+
+		u.RawQuery = q.Encode()
+	}
+
+	if _, err := fmt.Fprintf(w, "%s %s\n", req.Method, u.String()); err != nil {
+		return err
+	}
+
+	var names = make([]string, 0, len(req.Header))
+
+	for name := range req.Header {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	for _, name := range names {
+		for _, value := range req.Header.Values(name) {
+			if isSecretName(name) && value != "" {
+				if scheme, _, ok := strings.Cut(value, " "); ok { // keep the scheme, like "Bearer"
+					value = scheme + " " + redacted
+				} else {
+					value = redacted
+				}
+			}
+
+			if _, err := fmt.Fprintf(w, "%s: %s\n", name, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	if req.Body == nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+
+	if json.Indent(&buf, body, "", "  ") != nil { // not a JSON, print as is
+		buf.Reset()
+		buf.Write(body)
+	}
+
+	_, err = fmt.Fprintf(w, "\n%s\n", bytes.TrimRight(buf.Bytes(), "\n"))
+
+	return err
+}
+
+// isSecretName reports whether the header (or the URL query parameter) with the given name carries a secret.
+func isSecretName(name string) bool {
+	name = strings.ToLower(name)
+
+	for _, s := range []string{"auth", "key", "token", "secret", "cookie"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+)
+
+func TestWriteRequest(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		giveURL     string
+		giveHeaders map[string]string
+		giveBody    string
+		want        string
+	}{
+		"bearer token": {
+			giveURL:     "https://api.example.com/v1/chat",
+			giveHeaders: map[string]string{"Authorization": "Bearer sk-secret", "Content-Type": "application/json"},
+			giveBody:    `{"model":"gpt"}`,
+			want: "POST https://api.example.com/v1/chat\n" +
+				"Authorization: Bearer REDACTED\n" +
+				"Content-Type: application/json\n" +
+				"\n{\n  \"model\": \"gpt\"\n}\n",
+		},
+		"api key headers": {
+			giveURL: "https://api.example.com/",
+			giveHeaders: map[string]string{
+				"api-key":        "azure-secret",
+				"x-goog-api-key": "gemini-secret",
+				"X-Auth-Token":   "proxy-secret",
+				"Cookie":         "session=secret",
+				"X-Trace-Id":     "not a secret",
+			},
+			want: "POST https://api.example.com/\n" +
+				"Api-Key: REDACTED\n" +
+				"Cookie: REDACTED\n" +
+				"X-Auth-Token: REDACTED\n" +
+				"X-Goog-Api-Key: REDACTED\n" +
+				"X-Trace-Id: not a secret\n",
+		},
+		"query parameters": {
+			giveURL: "https://generativelanguage.googleapis.com/v1beta/models/gemini:generateContent" +
+				"?alt=sse&key=gemini-secret&access_token=secret",
+			want: "POST https://generativelanguage.googleapis.com/v1beta/models/gemini:generateContent" +
+				"?access_token=REDACTED&alt=sse&key=REDACTED\n",
+		},
+		"not a JSON body": {
+			giveURL:  "https://api.example.com/",
+			giveBody: "plain text\n",
+			want:     "POST https://api.example.com/\n\nplain text\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var body io.Reader
+
+			if tc.giveBody != "" {
+				body = strings.NewReader(tc.giveBody)
+			}
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, tc.giveURL, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for k, v := range tc.giveHeaders {
+				req.Header.Set(k, v)
+			}
+
+			var buf bytes.Buffer
+
+			if err = writeRequest(&buf, req); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if buf.String() != tc.want {
+				t.Errorf("unexpected output:\n%s\nwant:\n%s", buf.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestWriteRequest_ProviderHeaders(t *testing.T) {
+	t.Parallel()
+
+	// the extra headers of the profile are redacted the same way as the API key of the provider
+	var provider = ai.NewOpenAI("sk-secret", "gpt", ai.WithOpenAIHeaders(map[string]string{
+		"X-Gateway-Key": "gateway-secret",
+		"X-Team":        "backend",
+	}))
+
+	req, err := provider.Request(t.Context(), "diff", "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	if err = writeRequest(&buf, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var out = buf.String()
+
+	for _, secret := range []string{"sk-secret", "gateway-secret"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("the secret %q is not redacted:\n%s", secret, out)
+		}
+	}
+
+	for _, want := range []string{"Authorization: Bearer REDACTED\n", "X-Gateway-Key: REDACTED\n", "X-Team: backend\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in the output:\n%s", want, out)
+		}
+	}
+}
+
+func TestIsSecretName(t *testing.T) {
+	t.Parallel()
+
+	for name, want := range map[string]bool{
+		"Authorization":       true,
+		"Proxy-Authorization": true,
+		"api-key":             true,
+		"x-api-key":           true,
+		"key":                 true,
+		"access_token":        true,
+		"Client-Secret":       true,
+		"Set-Cookie":          true,
+		"Content-Type":        false,
+		"anthropic-version":   false,
+		"alt":                 false,
+	} {
+		if got := isSecretName(name); got != want {
+			t.Errorf("isSecretName(%q) = %t, want %t", name, got, want)
+		}
+	}
+}
+
+func TestApp_DryRun(t *testing.T) {
+	t.Parallel()
+
+	var repo = newTestRepo(t)
+
+	runGit(t, repo, "commit", "--quiet", "--allow-empty", "--message", "first")
+
+	for name, content := range map[string]string{"a.txt": "foo\n", "b.txt": "bar\n"} {
+		if err := os.WriteFile(filepath.Join(repo, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runGit(t, repo, "add", "a.txt", "b.txt")
+
+	for name, tc := range map[string]struct {
+		giveMaxChars int
+		want         []string
+		wantNot      []string
+	}{
+		"verbatim": {
+			want:    []string{"+foo", "+bar"},
+			wantNot: []string{"The final request", "part 1"},
+		},
+		"summarized": {
+			giveMaxChars: 120, // one file per part
+			want: []string{
+				"would be summarized by 2 parts first",
+				"The request summarizing the part 1 of 2",
+				"summarizes code changes", // the summary prompt
+				"+foo",
+				"The final request",
+				`Part 1 of 2:\n[the summary of the part 1]\n\nPart 2 of 2:\n[the summary of the part 2]`,
+			},
+			wantNot: []string{"+bar"}, // neither the second part, nor the whole diff are printed
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var app = NewApp("app")
+
+			app.opt.AIProviderName, app.opt.MaxDiffChars = ai.ProviderOpenAI, tc.giveMaxChars
+
+			var out bytes.Buffer
+
+			if err := app.dryRun(t.Context(), &out, repo, ""); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, want := range tc.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("expected %q in the output:\n%s", want, out.String())
+				}
+			}
+
+			for _, unwanted := range tc.wantNot {
+				if strings.Contains(out.String(), unwanted) {
+					t.Errorf("unexpected %q in the output:\n%s", unwanted, out.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+	"gh.tarampamp.am/describe-commit/internal/debug"
+)
+
+type (
+	// namedProvider is an AI provider along with the name it was selected by.
+	namedProvider struct {
+		ai.Provider
+		name string
+	}
+
+	// providerChain is an ordered list of AI providers: the first one is asked first, and the next ones are
+	// tried in turn when the previous one keeps failing with retryable or authentication errors.
+	providerChain []namedProvider
+)
+
+var _ ai.Provider = providerChain(nil) // ensure the interface is implemented
+
+// Query asks the providers in turn (without retries; [App.ask] retries each provider of the chain).
+func (c providerChain) Query(ctx context.Context, changes, commits string, opts ...ai.Option) (*ai.Response, error) {
+	return c.query(func(p ai.Provider) (*ai.Response, error) { return p.Query(ctx, changes, commits, opts...) })
+}
+
+// Request renders the request of the first provider (the fallback ones are asked only if it fails).
+func (c providerChain) Request(ctx context.Context, changes, commits string, opts ...ai.Option) (*http.Request, error) {
+	return c[0].Request(ctx, changes, commits, opts...)
+}
+
+// query calls fn for each provider of the chain until it succeeds or fails with an error that is not a reason
+// to fail over (e.g. the context is canceled or the request is malformed).
+func (c providerChain) query(fn func(ai.Provider) (*ai.Response, error)) (*ai.Response, error) {
+	var lastErr error
+
+	for i, p := range c {
+		if i > 0 {
+			debug.Printf("falling back to the %s provider due to the error: %v", p.name, lastErr)
+		}
+
+		response, err := fn(p.Provider)
+		if err == nil {
+			debug.Printf("the answer is provided by the %s provider", p.name)
+
+			return response, nil
+		}
+
+		if !ai.IsRetryableError(err) && !ai.IsAuthError(err) {
+			return nil, err
+		}
+
+		lastErr = err
+	}
+
+	return nil, lastErr
+}
+
+// splitFallback splits the fallback chain entry in the "provider[:model]" format. The model may contain
+// colons itself (e.g. "ollama:qwen2.5-coder:7b"), so the entry is split by the first one.
+func splitFallback(entry string) (name, model string) {
+	name, model, _ = strings.Cut(entry, ":")
+
+	return strings.TrimSpace(name), strings.TrimSpace(model)
+}
@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+)
+
+// stubProvider is the AI provider that returns the preset answer or error, counting the queries.
+type stubProvider struct {
+	answer string
+	err    error
+	calls  int
+}
+
+func (p *stubProvider) Query(context.Context, string, string, ...ai.Option) (*ai.Response, error) {
+	p.calls++
+
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	return &ai.Response{Answer: p.answer, Answers: []string{p.answer}}, nil
+}
+
+func (p *stubProvider) Request(ctx context.Context, _, _ string, _ ...ai.Option) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, http.MethodPost, "http://stub/"+p.answer, http.NoBody)
+}
+
+// providerError returns the error of the real provider for the HTTP status code, so the error kinds (retryable,
+// authentication) are the same as in production.
+func providerError(t *testing.T, status int, message string) error {
+	t.Helper()
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+		_, _ = fmt.Fprintf(w, `{"error":{"message":%q}}`, message)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	_, err := ai.NewOpenAI("key", "model", ai.WithOpenAIBaseURL(srv.URL)).Query(t.Context(), "diff", "log")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	return err
+}
+
+func TestProviderChain_Query(t *testing.T) {
+	t.Parallel()
+
+	var (
+		rateLimited = providerError(t, http.StatusTooManyRequests, "rate limited")
+		overloaded  = providerError(t, http.StatusServiceUnavailable, "overloaded")
+		badKey      = providerError(t, http.StatusUnauthorized, "bad key")
+		badRequest  = providerError(t, http.StatusBadRequest, "bad request")
+	)
+
+	for name, tc := range map[string]struct {
+		giveErrs   []error // of the providers in the chain order (nil = answers with its index)
+		wantAnswer string
+		wantErr    error
+		wantCalls  []int
+	}{
+		"first succeeds": {
+			giveErrs:   []error{nil, nil},
+			wantAnswer: "0",
+			wantCalls:  []int{1, 0},
+		},
+		"fails over on the retryable error": {
+			giveErrs:   []error{rateLimited, nil},
+			wantAnswer: "1",
+			wantCalls:  []int{1, 1},
+		},
+		"fails over on the auth error": {
+			giveErrs:   []error{badKey, overloaded, nil},
+			wantAnswer: "2",
+			wantCalls:  []int{1, 1, 1},
+		},
+		"stops on the other error": {
+			giveErrs:  []error{badRequest, nil},
+			wantErr:   badRequest,
+			wantCalls: []int{1, 0},
+		},
+		"stops on the other error of the fallback": {
+			giveErrs:  []error{rateLimited, badRequest, nil},
+			wantErr:   badRequest,
+			wantCalls: []int{1, 1, 0},
+		},
+		"returns the last error": {
+			giveErrs:  []error{rateLimited, badKey, overloaded},
+			wantErr:   overloaded,
+			wantCalls: []int{1, 1, 1},
+		},
+		"context canceled": {
+			giveErrs:  []error{context.Canceled, nil},
+			wantErr:   context.Canceled,
+			wantCalls: []int{1, 0},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				chain = make(providerChain, len(tc.giveErrs))
+				stubs = make([]*stubProvider, len(tc.giveErrs))
+			)
+
+			for i, err := range tc.giveErrs {
+				stubs[i] = &stubProvider{answer: fmt.Sprint(i), err: err}
+				chain[i] = namedProvider{Provider: stubs[i], name: fmt.Sprint("provider", i)}
+			}
+
+			response, err := chain.Query(t.Context(), "diff", "log")
+
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("expected the error %v, got %v", tc.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			} else if response.Answer != tc.wantAnswer {
+				t.Errorf("expected the answer %q, got %q", tc.wantAnswer, response.Answer)
+			}
+
+			for i, want := range tc.wantCalls {
+				if stubs[i].calls != want {
+					t.Errorf("provider %d: expected %d call(s), got %d", i, want, stubs[i].calls)
+				}
+			}
+		})
+	}
+}
+
+func TestProviderChain_Request(t *testing.T) {
+	t.Parallel()
+
+	var chain = providerChain{
+		{Provider: &stubProvider{answer: "first"}, name: "first"},
+		{Provider: &stubProvider{answer: "second"}, name: "second"},
+	}
+
+	req, err := chain.Request(t.Context(), "diff", "log")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req.URL.Path != "/first" {
+		t.Errorf("the request of the first provider is expected, got %s", req.URL)
+	}
+}
+
+func TestApp_Models_FallbackChain(t *testing.T) {
+	t.Parallel()
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		_, _ = io.WriteString(w, `{"models":[{"name":"qwen2.5-coder:7b","size":4700000000,`+
+			`"details":{"parameter_size":"7.6B","quantization_level":"Q4_K_M"}}]}`)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	var (
+		app = NewApp("app")
+		out bytes.Buffer
+	)
+
+	app.cmd.Output = &out
+
+	// the primary provider of the chain is listed, the fallback ones are ignored
+	if err := app.Run(t.Context(), []string{
+		"--config-file", filepath.Join(t.TempDir(), "missing.yml"),
+		"--ai", ai.ProviderOllama,
+		"--ollama-base-url", srv.URL,
+		"--fallback", ai.ProviderOpenAI,
+		"--openai-api-key", "key",
+		"models",
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "qwen2.5-coder:7b") || !strings.Contains(out.String(), "Q4_K_M") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestOptions_ValidateProvider_FallbackModel(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		giveFallback []string
+		wantErr      string
+	}{
+		"model override":             {giveFallback: []string{"openai:gpt-4o", "ollama:qwen2.5-coder:7b"}},
+		"no model":                   {giveFallback: []string{"openai"}, wantErr: "OpenAI model name is required"},
+		"no model of another":        {giveFallback: []string{"openai:gpt-4o", "ollama"}, wantErr: "Ollama model name"},
+		"model override without key": {giveFallback: []string{"anthropic:claude"}, wantErr: "Anthropic API key"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var opt = newOptionsWithDefaults()
+
+			opt.AIProviderName, opt.Providers.Gemini.ApiKey = ai.ProviderGemini, "key"
+			opt.Providers.OpenAI.ApiKey = "key"
+			opt.Providers.OpenAI.ModelName, opt.Providers.Ollama.ModelName = "", ""
+			opt.Fallback = tc.giveFallback
+
+			var err = opt.ValidateProvider()
+
+			if tc.wantErr == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			} else if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+				t.Errorf("expected the %q error, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
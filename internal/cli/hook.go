@@ -0,0 +1,259 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"gh.tarampamp.am/describe-commit/internal/cli/cmd"
+	"gh.tarampamp.am/describe-commit/internal/debug"
+	"gh.tarampamp.am/describe-commit/internal/git"
+)
+
+const (
+	hookFileName = "prepare-commit-msg"
+
+	// hookMarker is used to identify the hook files installed by this tool (do not change it, otherwise
+	// previously installed hooks will not be recognized anymore).
+	hookMarker = "# describe-commit: prepare-commit-msg hook"
+)
+
+// newHookCommand creates the `hook` command with the `install`, `uninstall` and `run` subcommands. The loadOptions
+// function is called to load and validate the options when they are required (only in the hook mode).
+func (a *App) newHookCommand(loadOptions func(wd string) error) *cmd.Command {
+	// hookPath returns the path to the hook file in the repository located in the working directory
+	var hookPath = func(ctx context.Context, args []string) (string, error) {
+		var wd, wdErr = a.getWorkingDir(args)
+		if wdErr != nil {
+			return "", fmt.Errorf("wrong working directory: %w", wdErr)
+		}
+
+		hooksDir, err := git.HooksDir(ctx, wd)
+		if err != nil {
+			return "", err
+		}
+
+		return filepath.Join(hooksDir, hookFileName), nil
+	}
+
+	return &cmd.Command{
+		Name:        "hook",
+		Description: "Manage the " + hookFileName + " git hook",
+		Commands: []*cmd.Command{
+			{
+				Name:        "install",
+				Description: "Install the " + hookFileName + " hook into the repository (core.hooksPath is respected)",
+				Usage:       "[<git-dir-path>]",
+				Action: func(ctx context.Context, c *cmd.Command, args []string) error {
+					path, err := hookPath(ctx, args)
+					if err != nil {
+						return err
+					}
+
+					if err = installHook(path, a.cmd.Name); err != nil {
+						return err
+					}
+
+					_, err = fmt.Fprintf(c.Output, "hook installed: %s\n", path)
+
+					return err
+				},
+			},
+			{
+				Name:        "uninstall",
+				Description: "Remove the previously installed " + hookFileName + " hook from the repository",
+				Usage:       "[<git-dir-path>]",
+				Action: func(ctx context.Context, c *cmd.Command, args []string) error {
+					path, err := hookPath(ctx, args)
+					if err != nil {
+						return err
+					}
+
+					if err = uninstallHook(path); err != nil {
+						return err
+					}
+
+					_, err = fmt.Fprintf(c.Output, "hook uninstalled: %s\n", path)
+
+					return err
+				},
+			},
+			{
+				Name:        "run",
+				Description: "Write the generated message into the commit message file (executed by git)",
+				Usage:       "[<options>] <commit-msg-file> [<commit-source> [<commit-sha>]]",
+				Action: func(ctx context.Context, _ *cmd.Command, args []string) error {
+					// git runs the hooks from the root of the working tree
+					var wd, wdErr = a.getWorkingDir(nil)
+					if wdErr != nil {
+						return fmt.Errorf("wrong working directory: %w", wdErr)
+					}
+
+					return a.runHook(ctx, wd, args, loadOptions)
+				},
+			},
+		},
+	}
+}
+
+// runHook is executed by git as the `prepare-commit-msg` hook. The arguments are the same as git passes to the
+// hook: the path to the file with the commit message, the source of the message (optional) and the commit SHA
+// (optional).
+//
+// Any error that occurs while generating the message is reported to stderr only - the hook must never block
+// the commit.
+func (a *App) runHook(ctx context.Context, wd string, args []string, loadOptions func(wd string) error) error {
+	if len(args) == 0 || args[0] == "" {
+		return errors.New("missing commit message file path")
+	}
+
+	var msgFilePath, commitSource = args[0], ""
+
+	if len(args) > 1 {
+		commitSource = args[1]
+	}
+
+	if !filepath.IsAbs(msgFilePath) {
+		msgFilePath = filepath.Join(wd, msgFilePath)
+	}
+
+	// https://git-scm.com/docs/githooks#_prepare_commit_msg
+	switch commitSource {
+	case "message", // -m or -F option
+		"merge",  // merge commit or .git/MERGE_MSG exists
+		"squash", // .git/SQUASH_MSG exists
+		"commit": // -c, -C or --amend option
+		debug.Printf("hook: skipping the commit with source %q", commitSource)
+
+		return nil
+	}
+
+	if err := a.writeHookMessage(ctx, wd, msgFilePath, loadOptions); err != nil {
+		if errors.Is(err, errNoChanges) {
+			debug.Printf("hook: %s", err)
+
+			return nil
+		}
+
+		_, _ = fmt.Fprintf(os.Stderr, "describe-commit: commit message was not generated: %s\n", err)
+	}
+
+	return nil
+}
+
+// writeHookMessage generates the commit message and writes it at the beginning of the message file (the
+// existing content, like git comments or the commit template, is preserved below).
+func (a *App) writeHookMessage(ctx context.Context, wd, msgFilePath string, loadOptions func(wd string) error) error {
+	existing, err := os.ReadFile(msgFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to read the commit message file: %w", err)
+	}
+
+	if err = loadOptions(wd); err != nil {
+		return err
+	}
+
+	a.opt.DiffSource = git.SourceStaged // the message is for the changes being committed
+
+	defer a.reportUsage(wd)
+
+	response, err := a.generate(ctx, wd, "")
+	if err != nil {
+		return err
+	}
+
+	var content = strings.TrimRight(response.Answer, "\n") + "\n"
+
+	if len(existing) > 0 {
+		content += "\n" + string(existing)
+	}
+
+	stat, err := os.Stat(msgFilePath)
+	if err != nil {
+		return err
+	}
+
+	if err = os.WriteFile(msgFilePath, []byte(content), stat.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write the commit message file: %w", err)
+	}
+
+	return nil
+}
+
+// hookScript returns the content of the hook file. The application is called by its name when it's available
+// in the $PATH, and by the absolute path otherwise.
+func hookScript(appName string) (string, error) {
+	var bin = appName
+
+	if _, err := exec.LookPath(appName); err != nil {
+		if bin, err = os.Executable(); err != nil {
+			return "", fmt.Errorf("failed to determine the executable path: %w", err)
+		}
+
+		bin = filepath.ToSlash(bin) // the hook is executed by sh, even on Windows
+	}
+
+	var quoted = "'" + strings.ReplaceAll(bin, "'", `'\''`) + "'"
+
+	return strings.Join([]string{
+		"#!/bin/sh",
+		hookMarker,
+		"# (installed by `" + appName + " hook install`, remove with `" + appName + " hook uninstall`)",
+		"",
+		"# the hook never blocks the commit, even if the message generation fails",
+		"if command -v " + quoted + " >/dev/null 2>&1; then",
+		"  " + quoted + ` hook run "$@" || true`,
+		"fi",
+		"",
+		"exit 0",
+		"",
+	}, "\n"), nil
+}
+
+// installHook writes the hook file. An existing hook that was not installed by this tool is never overwritten.
+func installHook(hookPath, appName string) error {
+	if content, err := os.ReadFile(hookPath); err == nil {
+		if !strings.Contains(string(content), hookMarker) {
+			return fmt.Errorf("hook already exists and was not installed by %s: %s", appName, hookPath)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	script, err := hookScript(appName)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(hookPath), 0o755); err != nil { //nolint:mnd
+		return fmt.Errorf("failed to create the hooks directory: %w", err)
+	}
+
+	if err = os.WriteFile(hookPath, []byte(script), 0o755); err != nil { //nolint:gosec,mnd // must be executable
+		return fmt.Errorf("failed to write the hook file: %w", err)
+	}
+
+	return os.Chmod(hookPath, 0o755) //nolint:gosec,mnd // in case the file already existed with other permissions
+}
+
+// uninstallHook removes the hook file, but only if it was installed by this tool.
+func uninstallHook(hookPath string) error {
+	content, err := os.ReadFile(hookPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("hook is not installed: %s", hookPath)
+		}
+
+		return err
+	}
+
+	if !strings.Contains(string(content), hookMarker) {
+		return fmt.Errorf("hook was not installed by this tool, refusing to remove it: %s", hookPath)
+	}
+
+	return os.Remove(hookPath)
+}
@@ -0,0 +1,298 @@
+package cli
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestApp_RunHook_SkippedSources(t *testing.T) {
+	t.Parallel()
+
+	for _, source := range []string{"message", "merge", "squash", "commit"} {
+		t.Run(source, func(t *testing.T) {
+			t.Parallel()
+
+			var msgFile = filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+
+			if err := os.WriteFile(msgFile, []byte("existing\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			var loadOptions = func(string) error {
+				t.Error("the options must not be loaded for the skipped commit source")
+
+				return nil
+			}
+
+			if err := NewApp("app").runHook(t.Context(), t.TempDir(), []string{msgFile, source}, loadOptions); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if content, _ := os.ReadFile(msgFile); string(content) != "existing\n" {
+				t.Errorf("the message file must not be changed, got %q", content)
+			}
+		})
+	}
+}
+
+func TestApp_RunHook_NeverBlocks(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		giveFile    bool
+		giveLoadErr error
+	}{
+		"options error":        {giveFile: true, giveLoadErr: errors.New("wrong config")},
+		"no changes":           {giveFile: true, giveLoadErr: errNoChanges},
+		"missing message file": {},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var msgFile = filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+
+			if tc.giveFile {
+				if err := os.WriteFile(msgFile, []byte("# comment\n"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var loadOptions = func(string) error { return tc.giveLoadErr }
+
+			if err := NewApp("app").runHook(t.Context(), t.TempDir(), []string{msgFile}, loadOptions); err != nil {
+				t.Fatalf("the hook must not fail, got: %v", err)
+			}
+
+			if tc.giveFile {
+				if content, _ := os.ReadFile(msgFile); string(content) != "# comment\n" {
+					t.Errorf("the message file must not be changed, got %q", content)
+				}
+			}
+		})
+	}
+
+	t.Run("missing arguments", func(t *testing.T) {
+		t.Parallel()
+
+		if err := NewApp("app").runHook(t.Context(), t.TempDir(), nil, nil); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestInstallHook(t *testing.T) {
+	t.Parallel()
+
+	var hookPath = filepath.Join(t.TempDir(), "hooks", hookFileName)
+
+	if err := uninstallHook(hookPath); err == nil || !strings.Contains(err.Error(), "not installed") {
+		t.Errorf("expected the not installed error, got %v", err)
+	}
+
+	for range 2 { // the own hook is overwritten
+		if err := installHook(hookPath, "app"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	stat, err := os.Stat(hookPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if runtime.GOOS != "windows" && stat.Mode().Perm()&0o111 == 0 {
+		t.Errorf("the hook must be executable, got %s", stat.Mode())
+	}
+
+	if err = uninstallHook(hookPath); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err = os.Stat(hookPath); !os.IsNotExist(err) {
+		t.Errorf("the hook must be removed, got %v", err)
+	}
+}
+
+func TestInstallHook_Foreign(t *testing.T) {
+	t.Parallel()
+
+	const foreign = "#!/bin/sh\necho 'my own hook'\n"
+
+	var hookPath = filepath.Join(t.TempDir(), hookFileName)
+
+	if err := os.WriteFile(hookPath, []byte(foreign), 0o700); err != nil { //nolint:gosec
+		t.Fatal(err)
+	}
+
+	if err := installHook(hookPath, "app"); err == nil {
+		t.Error("the foreign hook must not be overwritten")
+	}
+
+	if err := uninstallHook(hookPath); err == nil {
+		t.Error("the foreign hook must not be removed")
+	}
+
+	if content, _ := os.ReadFile(hookPath); string(content) != foreign {
+		t.Errorf("the foreign hook was changed: %q", content)
+	}
+}
+
+func TestApp_HookInstall_HooksPath(t *testing.T) {
+	t.Parallel()
+
+	var repo = newTestRepo(t)
+
+	runGit(t, repo, "config", "core.hooksPath", "custom-hooks")
+
+	var app = NewApp("app")
+
+	app.cmd.Output = io.Discard
+
+	if err := app.Run(t.Context(), []string{"hook", "install", repo}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(repo, "custom-hooks", hookFileName)); err != nil {
+		t.Errorf("the hook must be installed into core.hooksPath: %v", err)
+	}
+}
+
+func TestHookScript(t *testing.T) { //nolint:paralleltest // modifies the PATH
+	if runtime.GOOS == "windows" {
+		t.Skip("the hook script is executed by sh")
+	}
+
+	var (
+		binDir  = t.TempDir()
+		argsOut = filepath.Join(t.TempDir(), "args.txt")
+		appName = "it's app" // the quote and the space must be escaped in the script
+	)
+
+	// the fake application records its arguments and fails, which must not block the commit
+	if err := os.WriteFile(filepath.Join(binDir, appName),
+		[]byte("#!/bin/sh\nprintf '%s\\n' \"$@\" > '"+argsOut+"'\nexit 1\n"), 0o700, //nolint:gosec
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	script, err := hookScript(appName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(script, hookMarker) {
+		t.Error("the script must contain the marker")
+	}
+
+	var hookPath = filepath.Join(t.TempDir(), hookFileName)
+
+	if err = os.WriteFile(hookPath, []byte(script), 0o700); err != nil { //nolint:gosec
+		t.Fatal(err)
+	}
+
+	var hook = exec.CommandContext(t.Context(), "sh", hookPath, "MSG FILE", "template")
+
+	if out, runErr := hook.CombinedOutput(); runErr != nil {
+		t.Fatalf("the hook must exit with zero code: %v\n%s", runErr, out)
+	}
+
+	args, err := os.ReadFile(argsOut)
+	if err != nil {
+		t.Fatalf("the application was not called: %v", err)
+	}
+
+	if want := "hook\nrun\nMSG FILE\ntemplate\n"; string(args) != want {
+		t.Errorf("unexpected arguments: %q, want %q", args, want)
+	}
+}
+
+func TestHook_Commit(t *testing.T) { //nolint:paralleltest // modifies the environment
+	if runtime.GOOS == "windows" {
+		t.Skip("the hook script is executed by sh")
+	}
+
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		requests = append(requests, string(body))
+		mu.Unlock()
+
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"content":"feat: Generated message"}}]}`)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	// the test binary is executed by the hook as the application (see TestMain), since the name is not in $PATH
+	for name, value := range map[string]string{
+		testAppEnvName:    "1",
+		"CONFIG_FILE":     filepath.Join(t.TempDir(), "missing.yml"),
+		"XDG_CONFIG_HOME": t.TempDir(),
+		"AI_PROVIDER":     "openai",
+		"OPENAI_API_KEY":  "key",
+		"OPENAI_BASE_URL": srv.URL,
+		"NO_CACHE":        "true",
+		"GIT_EDITOR":      "true", // keep the message written by the hook
+	} {
+		t.Setenv(name, value)
+	}
+
+	// git uses a temporary index for the commits below and passes it to the hook via GIT_INDEX_FILE, so the
+	// changes are not staged in the real index
+	for name, args := range map[string][]string{
+		"all":   {"commit", "--quiet", "--all"},
+		"paths": {"commit", "--quiet", "changed.txt"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var repo = newTestRepo(t)
+
+			if err := os.WriteFile(filepath.Join(repo, "changed.txt"), []byte("one\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			runGit(t, repo, "add", "changed.txt")
+			runGit(t, repo, "commit", "--quiet", "--message", "initial")
+
+			if err := installHook(filepath.Join(repo, ".git", "hooks", hookFileName), "describe-commit-test"); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := os.WriteFile(filepath.Join(repo, "changed.txt"), []byte("one\ntwo\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			mu.Lock()
+			requests = nil
+			mu.Unlock()
+
+			runGit(t, repo, args...)
+
+			if subject := runGit(t, repo, "log", "-1", "--format=%s"); subject != "feat: Generated message" {
+				t.Errorf("the message must be generated by the hook, got %q", subject)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if len(requests) != 1 || !strings.Contains(requests[0], "+two") {
+				t.Errorf("the request must contain the committed changes, got %q", requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"gh.tarampamp.am/describe-commit/internal/git"
+)
+
+// history keeps the commit message candidates generated during the interactive session, so the user is able
+// to go back to an earlier one.
+type history struct {
+	items   []string
+	current int
+}
+
+// Add appends the messages to the history and makes the first of them the current one.
+func (h *history) Add(msgs ...string) {
+	if len(msgs) > 0 {
+		h.items, h.current = append(h.items, msgs...), len(h.items)
+	}
+}
+
+// Current returns the currently selected message.
+func (h *history) Current() string { return h.items[h.current] }
+
+// Prev selects the previous message (if any).
+func (h *history) Prev() bool {
+	if h.current > 0 {
+		h.current--
+
+		return true
+	}
+
+	return false
+}
+
+// Next selects the next message (if any).
+func (h *history) Next() bool {
+	if h.current < len(h.items)-1 {
+		h.current++
+
+		return true
+	}
+
+	return false
+}
+
+// isTerminal reports whether the file is a terminal (character device).
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+// runInteractive generates the commit message and lets the user accept (and commit) it, edit it in the git
+// editor, regenerate it using the same changes, or cancel. All the prompts are written to stderr.
+//
+// If the revision is set, the changes of the commit are described and the HEAD commit is reworded on accept (the
+// caller ensures the revision is HEAD).
+func (a *App) runInteractive(ctx context.Context, workingDir, rev string) error { //nolint:funlen,gocognit,gocyclo
+	if !isTerminal(os.Stdin) {
+		return errors.New("interactive mode requires a terminal")
+	}
+
+	provider, pErr := a.newProvider()
+	if pErr != nil {
+		return pErr
+	}
+
+	// the large diff is summarized only once, the summaries are reused on regeneration
+	changes, commits, opts, cErr := a.prepare(ctx, provider, workingDir, rev)
+	if cErr != nil {
+		return cErr
+	}
+
+	var (
+		out  = os.Stderr
+		in   = bufio.NewReader(os.Stdin)
+		hist history
+	)
+
+	var generate = func() error {
+		_, _ = fmt.Fprintln(out, "Generating the commit message...")
+
+		response, err := a.query(ctx, provider, changes, commits, opts...)
+		if err != nil {
+			return err
+		}
+
+		if err = a.prependIssueKey(ctx, workingDir, rev, response); err != nil {
+			return err
+		}
+
+		hist.Add(response.Answers...)
+
+		return nil
+	}
+
+	if err := generate(); err != nil {
+		return err
+	}
+
+	for {
+		_, _ = fmt.Fprintf(out, "\n--- commit message %d of %d ---\n%s\n---\n",
+			hist.current+1, len(hist.items), strings.TrimRight(hist.Current(), "\n"),
+		)
+
+		_, _ = fmt.Fprint(out, "[c]ommit, [e]dit, [r]egenerate, [p]revious, [n]ext, [q]uit: ")
+
+		line, readErr := in.ReadString('\n')
+		if readErr != nil {
+			if errors.Is(readErr, io.EOF) {
+				_, _ = fmt.Fprintln(out)
+
+				return errors.New("canceled")
+			}
+
+			return readErr
+		}
+
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "c", "commit", "y", "yes":
+			var commit = git.Commit
+
+			if rev != "" {
+				commit = git.Reword
+			}
+
+			result, err := commit(ctx, workingDir, hist.Current())
+			if err != nil {
+				return err
+			}
+
+			_, err = fmt.Fprint(os.Stdout, result)
+
+			return err
+		case "e", "edit":
+			edited, err := editMessage(ctx, workingDir, hist.Current())
+			if err != nil {
+				_, _ = fmt.Fprintf(out, "Failed to edit the message: %s\n", err)
+
+				continue
+			}
+
+			if strings.TrimSpace(edited) == "" {
+				_, _ = fmt.Fprintln(out, "The edited message is empty, ignored")
+
+				continue
+			}
+
+			hist.Add(edited)
+		case "r", "regenerate":
+			a.opt.Cache = false // otherwise, the same message is served from the cache
+
+			if err := generate(); err != nil {
+				_, _ = fmt.Fprintf(out, "Failed to regenerate the message: %s\n", err)
+			}
+		case "p", "prev", "previous":
+			if !hist.Prev() {
+				_, _ = fmt.Fprintln(out, "This is the first message")
+			}
+		case "n", "next":
+			if !hist.Next() {
+				_, _ = fmt.Fprintln(out, "This is the last message")
+			}
+		case "q", "quit", "exit":
+			return errors.New("canceled")
+		default:
+			_, _ = fmt.Fprintln(out, "Unknown action")
+		}
+	}
+}
+
+// editMessage opens the message in the git editor and returns the edited content.
+func editMessage(ctx context.Context, workingDir, msg string) (string, error) {
+	editor, err := git.Editor(ctx, workingDir)
+	if err != nil {
+		return "", err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "describe-commit-*")
+	if err != nil {
+		return "", err
+	}
+
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	// the same file name as git uses, so the editors are able to enable the syntax highlighting
+	var filePath = filepath.Join(tmpDir, "COMMIT_EDITMSG")
+
+	if err = os.WriteFile(filePath, []byte(msg), 0o600); err != nil { //nolint:mnd
+		return "", err
+	}
+
+	// the editor is executed the same way as git does it: through the shell, so it may contain arguments
+	var shell = "sh"
+
+	if runtime.GOOS == "windows" {
+		if _, lookErr := exec.LookPath(shell); lookErr != nil {
+			shell = "" // no shell available, run the editor directly
+		}
+	}
+
+	var cmd *exec.Cmd
+
+	if shell != "" {
+		cmd = exec.CommandContext(ctx, shell, "-c", editor+` "$@"`, editor, filePath) //nolint:gosec
+	} else {
+		cmd = exec.CommandContext(ctx, editor, filePath) //nolint:gosec
+	}
+
+	cmd.Dir = workingDir
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stderr, os.Stderr
+
+	if err = cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+
+	edited, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	return string(edited), nil
+}
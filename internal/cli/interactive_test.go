@@ -0,0 +1,57 @@
+package cli
+
+import "testing"
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	var h history
+
+	h.Add() // nothing to add, the history stays empty
+
+	if len(h.items) != 0 || h.Prev() || h.Next() {
+		t.Fatal("the empty history must not be navigable")
+	}
+
+	h.Add("first")
+
+	if h.Current() != "first" || h.Prev() || h.Next() {
+		t.Fatalf("a single message must not be navigable, current %q", h.Current())
+	}
+
+	h.Add("second", "third") // the first of the added messages becomes the current one
+
+	if h.Current() != "second" {
+		t.Fatalf("expected the first added message to be current, got %q", h.Current())
+	}
+
+	for _, step := range []struct {
+		next, want bool
+		current    string
+	}{
+		{next: true, want: true, current: "third"},
+		{next: true, want: false, current: "third"}, // the last one
+		{next: false, want: true, current: "second"},
+		{next: false, want: true, current: "first"},
+		{next: false, want: false, current: "first"}, // the first one
+		{next: true, want: true, current: "second"},
+	} {
+		var got bool
+
+		if step.next {
+			got = h.Next()
+		} else {
+			got = h.Prev()
+		}
+
+		if got != step.want || h.Current() != step.current {
+			t.Fatalf("next=%t: expected %t and %q, got %t and %q", step.next, step.want, step.current, got, h.Current())
+		}
+	}
+
+	h.Add("fourth") // regeneration selects the new message, even if an older one was selected
+
+	if h.Current() != "fourth" || h.Next() {
+		t.Errorf("expected the new message to be current and the last one, got %q", h.Current())
+	}
+}
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+	"gh.tarampamp.am/describe-commit/internal/debug"
+	"gh.tarampamp.am/describe-commit/internal/git"
+)
+
+// The ways to use the issue key extracted from the branch name.
+const (
+	issueKeyModePrompt = "prompt" // ask the AI to start the subject line with the key
+	issueKeyModePrefix = "prefix" // prepend the key to the generated messages
+	issueKeyModeBoth   = "both"   // ask the AI, and prepend the key if the AI did not
+)
+
+// issueKeyModes returns the list of supported issue key modes.
+func issueKeyModes() []string {
+	return []string{issueKeyModePrompt, issueKeyModePrefix, issueKeyModeBoth}
+}
+
+// isIssueKeyModeSupported checks if the given issue key mode is supported.
+func isIssueKeyModeSupported(s string) bool { return slices.Contains(issueKeyModes(), s) }
+
+// issueKey returns the issue key extracted from the current branch name. An empty string is returned if the
+// pattern is not set, the HEAD is detached, the branch name does not match, or the revision (if set) is not the
+// HEAD commit - the older commits may belong to another issue.
+func (a *App) issueKey(ctx context.Context, workingDir, rev string) (string, error) {
+	if a.opt.IssueKeyPattern == "" {
+		return "", nil
+	}
+
+	if rev != "" {
+		if isHead, err := isHeadRevision(ctx, workingDir, rev); err != nil || !isHead {
+			debug.Printf("issue key: skipped (%q is not the HEAD commit)", rev)
+
+			return "", err
+		}
+	}
+
+	pattern, err := regexp.Compile(a.opt.IssueKeyPattern)
+	if err != nil {
+		return "", fmt.Errorf("wrong issue key pattern: %w", err)
+	}
+
+	branch, err := git.CurrentBranch(ctx, workingDir)
+	if err != nil {
+		return "", err
+	}
+
+	var key = ai.ExtractIssueKey(pattern, branch)
+
+	debug.Printf("issue key: %q (branch %q)", key, branch)
+
+	return key, nil
+}
+
+// prependIssueKey prepends the issue key to the subject line of each answer, unless the AI is only asked to add
+// it (see [issueKeyModePrompt]).
+func (a *App) prependIssueKey(ctx context.Context, workingDir, rev string, response *ai.Response) error {
+	if a.opt.IssueKeyMode == issueKeyModePrompt {
+		return nil
+	}
+
+	key, err := a.issueKey(ctx, workingDir, rev)
+	if err != nil || key == "" {
+		return err
+	}
+
+	for i, answer := range response.Answers {
+		response.Answers[i] = ai.PrependIssueKey(answer, a.opt.IssueKeyFormat, key)
+	}
+
+	response.Answer = response.Answers[0]
+
+	return nil
+}
+
+// isHeadRevision checks if the revision points to the HEAD commit.
+func isHeadRevision(ctx context.Context, workingDir, rev string) (bool, error) {
+	head, err := git.Revisions(ctx, workingDir, "HEAD")
+	if err != nil {
+		return false, err
+	}
+
+	revisions, err := git.Revisions(ctx, workingDir, rev)
+	if err != nil {
+		return false, err
+	}
+
+	return len(revisions) == 1 && revisions[0].Hash == head[0].Hash, nil
+}
@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+)
+
+// testAppEnvName makes the test binary act as the application, so it can be executed by git (e.g. as the hook).
+const testAppEnvName = "DESCRIBE_COMMIT_TEST_APP"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(testAppEnvName) != "" {
+		if err := NewApp("describe-commit").Run(context.Background(), os.Args[1:]); err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, "error: "+err.Error())
+
+			os.Exit(1)
+		}
+
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+	"gh.tarampamp.am/describe-commit/internal/cli/cmd"
+)
+
+// newModelsCommand creates the `models` command, which lists the models available for the selected provider.
+func (a *App) newModelsCommand(loadOptions func(wd string) error) *cmd.Command {
+	return &cmd.Command{
+		Name:        "models",
+		Description: "List the models available for the selected AI provider (only Ollama is supported for now)",
+		Usage:       "[<options>]",
+		Action: func(ctx context.Context, c *cmd.Command, _ []string) error {
+			var wd, wdErr = a.getWorkingDir(nil)
+			if wdErr != nil {
+				return fmt.Errorf("wrong working directory: %w", wdErr)
+			}
+
+			if err := loadOptions(wd); err != nil {
+				return err
+			}
+
+			provider, err := a.newProvider()
+			if err != nil {
+				return err
+			}
+
+			if chain, ok := provider.(providerChain); ok { // the fallback providers are not listed
+				provider = chain[0].Provider
+			}
+
+			ollama, ok := provider.(*ai.Ollama)
+			if !ok {
+				return fmt.Errorf("listing the models is not supported by the %s provider", a.opt.AIProviderName)
+			}
+
+			models, err := ollama.Models(ctx)
+			if err != nil {
+				return err
+			}
+
+			var tw = tabwriter.NewWriter(c.Output, 0, 0, 2, ' ', 0) //nolint:mnd
+
+			_, _ = fmt.Fprintln(tw, "NAME\tPARAMETERS\tQUANTIZATION\tSIZE")
+
+			for _, m := range models {
+				_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%.1f GB\n",
+					m.Name, m.Details.ParameterSize, m.Details.QuantizationLevel, float64(m.Size)/1e9, //nolint:mnd
+				)
+			}
+
+			return tw.Flush()
+		},
+	}
+}
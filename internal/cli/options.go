@@ -3,39 +3,104 @@ package cli
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"path"
+	"regexp"
+	"strings"
 	"time"
 
 	"gh.tarampamp.am/describe-commit/internal/ai"
 	"gh.tarampamp.am/describe-commit/internal/config"
+	"gh.tarampamp.am/describe-commit/internal/git"
 )
 
 // options represents the command-line options. this struct should be used ONLY in this package (do not try to pass
 // it somewhere else).
 type options struct {
-	ShortMessageOnly    bool
-	CommitHistoryLength int64
-	EnableEmoji         bool
-	MaxOutputTokens     int64
-	MaxRetries          uint
-	RetryDelay          time.Duration
-	AIProviderName      string
+	ShortMessageOnly       bool
+	CommitHistoryLength    int64
+	CommitHistoryBodies    bool // include the message bodies (with trailers) into the history
+	CommitHistoryAuthors   bool // include the author names into the history
+	CommitHistoryFiles     bool // include the changed files into the history
+	CommitHistorySamePaths bool // prefer the commits touching the changed files
+	CommitHistoryMaxChars  int  // the history size limit (0 = unlimited)
+	EnableEmoji            bool
+	MaxOutputTokens        int64
+	MaxRetries             uint
+	RetryDelay             time.Duration
+	RetryBackoff           float64       // the retry delay multiplier (1 = constant delay)
+	RetryMaxDelay          time.Duration // the retry delay limit (0 = unlimited)
+	RetryJitter            bool          // randomize the retry delay (full jitter)
+	RetryDeadline          time.Duration // the overall time limit for retries (0 = unlimited)
+	Interactive            bool
+	Stream                 bool
+	Candidates             int
+	MaxDiffChars           int
+	MaxDiffTokens          int
+	MaxInputTokens         int           // the estimated input tokens budget of the request (0 = unlimited)
+	InputBudgetAction      string        // what to do when the budget is exceeded (see budgetActions)
+	DryRun                 bool          // print the request estimation without calling the AI provider
+	Revision               string        // the existing commit (or range) to describe instead of the staged changes
+	Amend                  bool          // reword the HEAD commit with the generated message
+	Cache                  bool          // use the cache of the AI provider responses
+	CacheTTL               time.Duration // how long the cached responses are valid
+	DiffSource             string        // the source of the changes (see git.WithSource)
+	Exclude, Include       []string      // glob patterns of the files to exclude from (or keep in) the diff
+	DefaultExcludes        bool          // exclude the files matching git.DefaultExcludes
+	GitAttributes          bool          // exclude the files marked as generated or non-diffable in .gitattributes
+	PromptTemplate         string        // path to the custom prompt template file
+	CommitTypes            []string      // allowed commit types (ai.DefaultCommitTypes if empty)
+	IssueKeyPattern        string        // the regex to extract the issue key from the branch name (empty = disabled)
+	IssueKeyFormat         string        // the subject prefix with the ai.IssueKeyPlaceholder
+	IssueKeyMode           string        // how the issue key is used (see issueKeyModes)
+	Language               string
+	AIProviderName         string
 
 	Providers struct {
 		Gemini     struct{ ApiKey, ModelName, BaseURL string }
 		OpenAI     struct{ ApiKey, ModelName, BaseURL string }
 		OpenRouter struct{ ApiKey, ModelName, BaseURL string }
 		Anthropic  struct{ ApiKey, ModelName, BaseURL string }
+		Ollama     struct {
+			ModelName, BaseURL, KeepAlive string
+			NumCtx                        int
+			AutoPull                      bool
+		}
+		AzureOpenAI struct{ ApiKey, Endpoint, DeploymentName, APIVersion string }
 	}
+
+	Profiles map[string]providerProfile // user-defined providers by their names
+	Fallback []string                   // the providers ("name[:model]") to fail over to, in order
+
+	ShowUsage   bool                  // print the token usage and the estimated cost to stderr
+	UsageLedger string                // path to the JSONL file to append the token usage to (empty = disabled)
+	Prices      map[string]modelPrice // the model prices (USD per 1M tokens) by the model names
 }
 
+// maxCandidates limits the number of alternative commit messages to generate.
+const maxCandidates = 10
+
 func newOptionsWithDefaults() options {
 	var opt = options{
-		CommitHistoryLength: 20,  //nolint:mnd
-		MaxOutputTokens:     500, //nolint:mnd
-		MaxRetries:          5,   //nolint:mnd
-		RetryDelay:          time.Second,
-		AIProviderName:      ai.ProviderGemini, // due to its free
+		CommitHistoryLength:   20,   //nolint:mnd
+		CommitHistoryMaxChars: 4000, //nolint:mnd
+		MaxOutputTokens:       500,  //nolint:mnd
+		MaxRetries:            5,    //nolint:mnd
+		RetryDelay:            time.Second,
+		RetryBackoff:          1,
+		RetryMaxDelay:         30 * time.Second, //nolint:mnd
+		Candidates:            1,
+		DefaultExcludes:       true,
+		GitAttributes:         true,
+		InputBudgetAction:     budgetActionWarn,
+		IssueKeyFormat:        ai.IssueKeyPlaceholder,
+		IssueKeyMode:          issueKeyModePrefix,
+		Cache:                 true,
+		DiffSource:            git.SourceStaged,
+		CacheTTL:              24 * time.Hour, //nolint:mnd
+		Language:              ai.DefaultLanguage,
+		AIProviderName:        ai.ProviderGemini, // due to its free
 	}
 
 	// https://ai.google.dev/gemini-api/docs/models
@@ -46,6 +111,10 @@ func newOptionsWithDefaults() options {
 	opt.Providers.OpenRouter.ModelName = "google/gemma-4-31b-it:free"
 	// https://platform.claude.com/docs/en/about-claude/models/overview
 	opt.Providers.Anthropic.ModelName = "claude-haiku-4-5-20251001"
+	// https://ollama.com/search
+	opt.Providers.Ollama.ModelName = "qwen2.5-coder:7b"
+	// https://learn.microsoft.com/en-us/azure/ai-services/openai/api-version-deprecation
+	opt.Providers.AzureOpenAI.APIVersion = ai.AzureOpenAIDefaultAPIVersion
 
 	return opt
 }
@@ -54,14 +123,14 @@ func newOptionsWithDefaults() options {
 // The values loaded from the earlier files will be overridden by those from the later files, with the last
 // file taking the highest priority.
 // Missing files and directories are ignored.
-func (o *options) UpdateFromConfigFile(filePath []string) error {
-	if len(filePath) == 0 {
-		return nil
-	}
-
+//
+// The trusted file is the one chosen by the user (--config-file or the user-level one). The found files (in the
+// working directory and its parents) may come with a cloned repository, so they can not set the options sending
+// the requests elsewhere (see [config.Config.TrustedOnlyKeys]).
+func (o *options) UpdateFromConfigFile(trusted string, found ...string) error {
 	var cfg config.Config
 
-	for _, path := range filePath {
+	for i, path := range append([]string{trusted}, found...) {
 		if path == "" {
 			continue // skip empty paths
 		}
@@ -70,6 +139,12 @@ func (o *options) UpdateFromConfigFile(filePath []string) error {
 			continue // skip missing files and directories
 		}
 
+		if i > 0 && !sameFile(path, trusted) {
+			if err := checkUntrustedConfig(path); err != nil {
+				return err
+			}
+		}
+
 		if err := cfg.FromFile(path); err != nil {
 			return fmt.Errorf("failed to load the configuration file: %w", err)
 		}
@@ -77,18 +152,70 @@ func (o *options) UpdateFromConfigFile(filePath []string) error {
 
 	setIfSourceNotNil(&o.ShortMessageOnly, cfg.ShortMessageOnly)
 	setIfSourceNotNil(&o.CommitHistoryLength, cfg.CommitHistoryLength)
+	setIfSourceNotNil(&o.CommitHistoryBodies, cfg.CommitHistoryBodies)
+	setIfSourceNotNil(&o.CommitHistoryAuthors, cfg.CommitHistoryAuthors)
+	setIfSourceNotNil(&o.CommitHistoryFiles, cfg.CommitHistoryFiles)
+	setIfSourceNotNil(&o.CommitHistorySamePaths, cfg.CommitHistorySamePaths)
+	setIfSourceNotNil(&o.CommitHistoryMaxChars, cfg.CommitHistoryMaxChars)
 	setIfSourceNotNil(&o.EnableEmoji, cfg.EnableEmoji)
 	setIfSourceNotNil(&o.MaxOutputTokens, cfg.MaxOutputTokens)
 	setIfSourceNotNil(&o.MaxRetries, cfg.MaxRetries)
+	setIfSourceNotNil(&o.Interactive, cfg.Interactive)
+	setIfSourceNotNil(&o.Stream, cfg.Stream)
+	setIfSourceNotNil(&o.Candidates, cfg.Candidates)
+	setIfSourceNotNil(&o.MaxDiffChars, cfg.MaxDiffChars)
+	setIfSourceNotNil(&o.MaxDiffTokens, cfg.MaxDiffTokens)
+	setIfSourceNotNil(&o.MaxInputTokens, cfg.MaxInputTokens)
+	setIfSourceNotNil(&o.InputBudgetAction, cfg.InputBudgetAction)
+	setIfSourceNotNil(&o.Cache, cfg.Cache)
+	setIfSourceNotNil(&o.DiffSource, cfg.DiffSource)
+	setIfSourceNotNil(&o.DefaultExcludes, cfg.DefaultExcludes)
+	setIfSourceNotNil(&o.GitAttributes, cfg.GitAttributes)
+	setIfSourceNotNil(&o.PromptTemplate, cfg.PromptTemplate)
+	setIfSourceNotNil(&o.IssueKeyPattern, cfg.IssueKeyPattern)
+	setIfSourceNotNil(&o.IssueKeyFormat, cfg.IssueKeyFormat)
+	setIfSourceNotNil(&o.IssueKeyMode, cfg.IssueKeyMode)
+	setIfSourceNotNil(&o.Language, cfg.Language)
+	setIfSourceNotNil(&o.ShowUsage, cfg.ShowUsage)
+	setIfSourceNotNil(&o.UsageLedger, cfg.UsageLedger)
+
+	if cfg.Exclude != nil {
+		o.Exclude = cfg.Exclude
+	}
+
+	if cfg.Include != nil {
+		o.Include = cfg.Include
+	}
+
+	if cfg.Fallback != nil {
+		o.Fallback = cfg.Fallback
+	}
+
+	if cfg.CommitTypes != nil {
+		o.CommitTypes = cfg.CommitTypes
+	}
 	setIfSourceNotNil(&o.AIProviderName, cfg.AIProviderName)
 
-	if d := cfg.RetryDelay; d != nil && *d != "" {
-		dur, parseErr := time.ParseDuration(*d)
-		if parseErr != nil {
-			return fmt.Errorf("invalid retryDelay value %q: %w", *d, parseErr)
+	setIfSourceNotNil(&o.RetryBackoff, cfg.RetryBackoff)
+	setIfSourceNotNil(&o.RetryJitter, cfg.RetryJitter)
+
+	for name, d := range map[string]struct {
+		target *time.Duration
+		source *string
+	}{
+		"retryDelay":    {&o.RetryDelay, cfg.RetryDelay},
+		"retryMaxDelay": {&o.RetryMaxDelay, cfg.RetryMaxDelay},
+		"retryDeadline": {&o.RetryDeadline, cfg.RetryDeadline},
+		"cacheTTL":      {&o.CacheTTL, cfg.CacheTTL},
+	} {
+		if d.source != nil && *d.source != "" {
+			dur, parseErr := time.ParseDuration(*d.source)
+			if parseErr != nil {
+				return fmt.Errorf("invalid %s value %q: %w", name, *d.source, parseErr)
+			}
+
+			*d.target = dur
 		}
-
-		o.RetryDelay = dur
 	}
 
 	if sub := cfg.Gemini; sub != nil {
@@ -115,6 +242,41 @@ func (o *options) UpdateFromConfigFile(filePath []string) error {
 		setIfSourceNotNil(&o.Providers.Anthropic.BaseURL, sub.BaseURL)
 	}
 
+	if sub := cfg.Ollama; sub != nil {
+		setIfSourceNotNil(&o.Providers.Ollama.ModelName, sub.ModelName)
+		setIfSourceNotNil(&o.Providers.Ollama.BaseURL, sub.BaseURL)
+		setIfSourceNotNil(&o.Providers.Ollama.NumCtx, sub.NumCtx)
+		setIfSourceNotNil(&o.Providers.Ollama.KeepAlive, sub.KeepAlive)
+		setIfSourceNotNil(&o.Providers.Ollama.AutoPull, sub.AutoPull)
+	}
+
+	for name, p := range cfg.Profiles {
+		if o.Profiles == nil {
+			o.Profiles = make(map[string]providerProfile, len(cfg.Profiles))
+		}
+
+		o.Profiles[name] = providerProfile(p)
+	}
+
+	for model, p := range cfg.Prices {
+		if o.Prices == nil {
+			o.Prices = make(map[string]modelPrice, len(cfg.Prices))
+		}
+
+		var price = modelPrice{Input: p.Input, Output: p.Output, Cached: p.Input}
+
+		setIfSourceNotNil(&price.Cached, p.Cached)
+
+		o.Prices[model] = price
+	}
+
+	if sub := cfg.AzureOpenAI; sub != nil {
+		setIfSourceNotNil(&o.Providers.AzureOpenAI.ApiKey, sub.ApiKey)
+		setIfSourceNotNil(&o.Providers.AzureOpenAI.Endpoint, sub.Endpoint)
+		setIfSourceNotNil(&o.Providers.AzureOpenAI.DeploymentName, sub.DeploymentName)
+		setIfSourceNotNil(&o.Providers.AzureOpenAI.APIVersion, sub.APIVersion)
+	}
+
 	return nil
 }
 
@@ -132,49 +294,237 @@ func (o *options) Validate() error {
 		return errors.New("max output tokens must be greater than 1")
 	}
 
-	if v := o.AIProviderName; !ai.IsProviderSupported(v) {
-		return fmt.Errorf("unsupported AI provider: %s", v)
+	if o.RetryBackoff < 1 {
+		return errors.New("retry backoff factor must be greater than or equal to 1")
+	}
+
+	if o.RetryDelay < 0 || o.RetryMaxDelay < 0 || o.RetryDeadline < 0 {
+		return errors.New("retry delays and deadline must not be negative")
+	}
+
+	if o.Candidates < 1 || o.Candidates > maxCandidates {
+		return fmt.Errorf("candidates must be between 1 and %d", maxCandidates)
+	}
+
+	if o.MaxDiffChars < 0 || o.MaxDiffTokens < 0 {
+		return errors.New("diff limits must not be negative")
+	}
+
+	if o.CommitHistoryMaxChars < 0 {
+		return errors.New("commit history limit must not be negative")
+	}
+
+	if o.CacheTTL <= 0 {
+		return errors.New("cache TTL must be positive")
+	}
+
+	if o.MaxInputTokens < 0 {
+		return errors.New("max input tokens must not be negative")
+	}
+
+	if o.Amend && o.Candidates > 1 && !o.Interactive && !o.DryRun {
+		return errors.New("amending requires a single candidate (or the interactive mode to choose one)")
+	}
+
+	if o.Interactive && o.Revision != "" && !o.Amend {
+		return errors.New("the interactive mode can not describe the existing commits (use --amend to reword HEAD)")
+	}
+
+	if strings.TrimSpace(o.DiffSource) == "" {
+		return errors.New("diff source must not be empty")
+	}
+
+	if o.Interactive && o.DiffSource != git.SourceStaged && !o.Amend {
+		return fmt.Errorf("the interactive mode commits the staged changes, but the diff source is %q", o.DiffSource)
+	}
+
+	if v := o.InputBudgetAction; !isBudgetActionSupported(v) {
+		return fmt.Errorf("unsupported input budget action: %s (%s)", v, strings.Join(budgetActions(), "|"))
+	}
+
+	if _, err := regexp.Compile(o.IssueKeyPattern); err != nil {
+		return fmt.Errorf("wrong issue key pattern: %w", err)
+	}
+
+	if !strings.Contains(o.IssueKeyFormat, ai.IssueKeyPlaceholder) {
+		return fmt.Errorf("issue key format must contain the %s placeholder", ai.IssueKeyPlaceholder)
+	}
+
+	if v := o.IssueKeyMode; !isIssueKeyModeSupported(v) {
+		return fmt.Errorf("unsupported issue key mode: %s (%s)", v, strings.Join(issueKeyModes(), "|"))
+	}
+
+	for _, p := range append(o.Exclude[:len(o.Exclude):len(o.Exclude)], o.Include...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("wrong glob pattern %q: %w", p, err)
+		}
 	}
 
-	if o.AIProviderName == ai.ProviderGemini {
+	for model, p := range o.Prices {
+		if p.Input < 0 || p.Output < 0 || p.Cached < 0 {
+			return fmt.Errorf("the prices of the model %q must not be negative", model)
+		}
+	}
+
+	if o.Providers.Ollama.NumCtx < 0 {
+		return errors.New("Ollama context window size must not be negative") //nolint:staticcheck
+	}
+
+	if v := o.Language; !ai.IsLanguageSupported(v) {
+		return fmt.Errorf("unsupported language: %s", v)
+	}
+
+	for name, p := range o.Profiles {
+		if ai.IsProviderSupported(name) {
+			return fmt.Errorf("provider profile %q: the name is reserved by the built-in provider", name)
+		}
+
+		if err := p.validate(); err != nil {
+			return fmt.Errorf("provider profile %q: %w", name, err)
+		}
+	}
+
+	if v := o.AIProviderName; !o.isProviderKnown(v) {
+		return fmt.Errorf("unsupported AI provider: %s (neither built-in nor a profile)", v)
+	}
+
+	for _, entry := range o.Fallback {
+		if name, _ := splitFallback(entry); !o.isProviderKnown(name) {
+			return fmt.Errorf("unsupported fallback AI provider: %s (neither built-in nor a profile)", entry)
+		}
+	}
+
+	return nil
+}
+
+// isProviderKnown checks if the provider with the given name is built-in or defined by the profile.
+func (o *options) isProviderKnown(name string) bool {
+	if _, ok := o.Profiles[name]; ok {
+		return true
+	}
+
+	return ai.IsProviderSupported(name)
+}
+
+// ValidateProvider checks the options of the selected AI provider (they are not required by the commands that
+// do not call the provider, so they are validated separately).
+func (o *options) ValidateProvider() error {
+	if err := o.validateProvider(o.AIProviderName, ""); err != nil {
+		return err
+	}
+
+	for _, entry := range o.Fallback {
+		if name, model := splitFallback(entry); name != o.AIProviderName {
+			if err := o.validateProvider(name, model); err != nil {
+				return fmt.Errorf("fallback provider %s: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateProvider checks the options of the AI provider with the given name. The configured model name is not
+// required if the model is overridden (see [splitFallback]).
+func (o *options) validateProvider(name, model string) error { //nolint:gocyclo
+	if p, ok := o.Profiles[name]; ok {
+		if err := p.validateAPIKey(); err != nil {
+			return fmt.Errorf("provider profile %q: %w", name, err)
+		}
+
+		return nil
+	}
+
+	if name == ai.ProviderGemini {
 		if o.Providers.Gemini.ApiKey == "" {
 			return errors.New("gemini API key is required")
 		}
 
-		if o.Providers.Gemini.ModelName == "" {
+		if model == "" && o.Providers.Gemini.ModelName == "" {
 			return errors.New("gemini model name is required")
 		}
 	}
 
-	if o.AIProviderName == ai.ProviderOpenAI {
+	if name == ai.ProviderOpenAI {
 		if o.Providers.OpenAI.ApiKey == "" {
 			return errors.New("OpenAI API key is required")
 		}
 
-		if o.Providers.OpenAI.ModelName == "" {
+		if model == "" && o.Providers.OpenAI.ModelName == "" {
 			return errors.New("OpenAI model name is required")
 		}
 	}
 
-	if o.AIProviderName == ai.ProviderOpenRouter {
+	if name == ai.ProviderOpenRouter {
 		if o.Providers.OpenRouter.ApiKey == "" {
 			return errors.New("OpenRouter API key is required")
 		}
 
-		if o.Providers.OpenRouter.ModelName == "" {
+		if model == "" && o.Providers.OpenRouter.ModelName == "" {
 			return errors.New("OpenRouter model name is required")
 		}
 	}
 
-	if o.AIProviderName == ai.ProviderAnthropic {
+	if name == ai.ProviderAnthropic {
 		if o.Providers.Anthropic.ApiKey == "" {
 			return errors.New("Anthropic API key is required") //nolint:staticcheck
 		}
 
-		if o.Providers.Anthropic.ModelName == "" {
+		if model == "" && o.Providers.Anthropic.ModelName == "" {
 			return errors.New("Anthropic model name is required") //nolint:staticcheck
 		}
 	}
 
+	if name == ai.ProviderOllama {
+		if model == "" && o.Providers.Ollama.ModelName == "" {
+			return errors.New("Ollama model name is required") //nolint:staticcheck
+		}
+	}
+
+	if name == ai.ProviderAzure {
+		if o.Providers.AzureOpenAI.ApiKey == "" {
+			return errors.New("Azure OpenAI API key is required") //nolint:staticcheck
+		}
+
+		if u, err := url.Parse(o.Providers.AzureOpenAI.Endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+			return errors.New("Azure OpenAI endpoint must be a valid URL") //nolint:staticcheck
+		}
+
+		if model == "" && o.Providers.AzureOpenAI.DeploymentName == "" {
+			return errors.New("Azure OpenAI deployment name is required") //nolint:staticcheck
+		}
+
+		if o.Providers.AzureOpenAI.APIVersion == "" {
+			return errors.New("Azure OpenAI API version is required") //nolint:staticcheck
+		}
+	}
+
 	return nil
 }
+
+// checkUntrustedConfig returns an error if the configuration file sets the options that are accepted from the
+// trusted files only.
+func checkUntrustedConfig(path string) error {
+	var cfg config.Config
+
+	if err := cfg.FromFile(path); err != nil {
+		return fmt.Errorf("failed to load the configuration file: %w", err)
+	}
+
+	if keys := cfg.TrustedOnlyKeys(); len(keys) > 0 {
+		return fmt.Errorf("the configuration file %s can not set %s (it may come with the repository, so "+
+			"these options are accepted from the user-level configuration file or --config-file only)",
+			path, strings.Join(keys, ", "),
+		)
+	}
+
+	return nil
+}
+
+// sameFile reports whether the paths point to the same existing file.
+func sameFile(a, b string) bool {
+	aStat, aErr := os.Stat(a)
+	bStat, bErr := os.Stat(b)
+
+	return aErr == nil && bErr == nil && os.SameFile(aStat, bStat)
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOptions_UpdateFromConfigFile_Found(t *testing.T) {
+	t.Parallel()
+
+	const profile = "profiles:\n  evil:\n    type: openai-compatible\n    baseUrl: https://example.com\n" +
+		"    apiKeyEnv: AWS_SECRET_ACCESS_KEY\n    modelName: gpt\naiProvider: evil\n"
+
+	for name, tc := range map[string]struct {
+		giveTrusted, giveFound string // the content of the files (empty = missing)
+		giveFoundIsTrusted     bool   // the found file is the trusted one
+		wantErr                string
+		wantEmoji              bool
+	}{
+		"safe options in the found file": {
+			giveFound: "enableEmoji: true\nopenai:\n  modelName: gpt\n",
+			wantEmoji: true,
+		},
+		"profiles in the found file": {
+			giveFound: profile,
+			wantErr:   "can not set profiles",
+		},
+		"base URL in the found file": {
+			giveFound: "enableEmoji: true\nopenai:\n  baseUrl: https://example.com\n",
+			wantErr:   "can not set openai.baseUrl",
+		},
+		"endpoint in the found file": {
+			giveFound: "azureOpenai:\n  endpoint: https://example.com\n",
+			wantErr:   "can not set azureOpenai.endpoint",
+		},
+		"profiles in the trusted file": {
+			giveTrusted: profile + "enableEmoji: true\n",
+			giveFound:   "aiProvider: evil\n",
+			wantEmoji:   true,
+		},
+		"trusted file found in the working directory": {
+			giveFound:          profile + "enableEmoji: true\n",
+			giveFoundIsTrusted: true,
+			wantEmoji:          true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				dir     = t.TempDir()
+				trusted = filepath.Join(dir, "user.yml")
+				found   = filepath.Join(dir, "describe-commit.yml")
+			)
+
+			for path, content := range map[string]string{trusted: tc.giveTrusted, found: tc.giveFound} {
+				if content == "" {
+					continue
+				}
+
+				if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if tc.giveFoundIsTrusted {
+				trusted = found
+			}
+
+			var opt = newOptionsWithDefaults()
+
+			err := opt.UpdateFromConfigFile(trusted, found)
+
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("expected the %q error, got %v", tc.wantErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if opt.EnableEmoji != tc.wantEmoji {
+				t.Errorf("expected the emoji option %t, got %t", tc.wantEmoji, opt.EnableEmoji)
+			}
+		})
+	}
+}
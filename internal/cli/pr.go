@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+	"gh.tarampamp.am/describe-commit/internal/cli/cmd"
+	"gh.tarampamp.am/describe-commit/internal/debug"
+	"gh.tarampamp.am/describe-commit/internal/errgroup"
+	"gh.tarampamp.am/describe-commit/internal/git"
+)
+
+const (
+	// prCommitsLimit limits the number of the branch commits passed to the AI provider.
+	prCommitsLimit = 100
+
+	// prMinOutputTokens is the minimal output tokens limit for the pull request description, since it's usually
+	// much longer than the commit message (the larger configured limit is used as is).
+	prMinOutputTokens = 1500
+)
+
+// pullRequest is the generated pull request title and description.
+type pullRequest struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// newPRCommand creates the `pr` command, which describes the changes of the current branch as a pull request.
+func (a *App) newPRCommand(loadOptions func(wd string) error) *cmd.Command {
+	var (
+		base = cmd.Flag[string]{
+			Names:   []string{"base", "b"},
+			Usage:   "Target branch of the pull request (default: the branch origin/HEAD points to, main or master)",
+			EnvVars: []string{"PR_BASE"},
+		}
+		format = cmd.Flag[string]{
+			Names:     []string{"format", "f"},
+			Usage:     fmt.Sprintf("Output format (%s|%s)", formatMarkdown, formatJSON),
+			EnvVars:   []string{"PR_FORMAT"},
+			Default:   formatMarkdown,
+			Validator: validateFormat,
+		}
+	)
+
+	return &cmd.Command{
+		Name:        "pr",
+		Description: "Generate the pull request title and description for the changes of the current branch",
+		Usage:       "[<options>] [<git-dir-path>]",
+		Flags:       []cmd.Flagger{&base, &format},
+		Action: func(ctx context.Context, c *cmd.Command, args []string) error {
+			var wd, wdErr = a.getWorkingDir(args)
+			if wdErr != nil {
+				return fmt.Errorf("wrong working directory: %w", wdErr)
+			}
+
+			if err := loadOptions(wd); err != nil {
+				return err
+			}
+
+			defer a.reportUsage(wd)
+
+			pr, err := a.describePullRequest(ctx, wd, *base.Value)
+			if err != nil {
+				return err
+			}
+
+			return writePullRequest(c.Output, pr, *format.Value)
+		},
+	}
+}
+
+// describePullRequest asks the AI provider to describe the changes of the current branch since its merge-base
+// with the target branch (the default one, if empty), using the subjects of the branch commits as the context.
+func (a *App) describePullRequest(ctx context.Context, workingDir, target string) (*pullRequest, error) {
+	if target == "" {
+		var err error
+
+		if target, err = git.DefaultBranch(ctx, workingDir); err != nil {
+			return nil, fmt.Errorf("%w (use --base to set the target branch)", err)
+		}
+	}
+
+	mergeBase, err := git.MergeBase(ctx, workingDir, target, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	debug.Printf("pull request: target %s, merge-base %s", target, mergeBase)
+
+	var (
+		changes, commits string
+		eg, _            = errgroup.New(ctx)
+		branch           = mergeBase + "..HEAD"
+	)
+
+	eg.Go(func(ctx context.Context) (err error) {
+		changes, err = git.Diff(ctx, workingDir, append(a.diffOptions(), git.WithSource(branch))...)
+
+		return
+	})
+
+	eg.Go(func(ctx context.Context) (err error) {
+		commits, err = git.Log(ctx, workingDir, prCommitsLimit, git.WithLogRange(branch))
+
+		return
+	})
+
+	if err = eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	if changes == "" {
+		return nil, fmt.Errorf("%w between %s and HEAD", errNoChanges, target)
+	}
+
+	provider, err := a.newProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	changes, summarized, err := a.summarize(ctx, provider, changes)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		opts = []ai.Option{
+			ai.WithCommitTypes(a.opt.CommitTypes...),
+			ai.WithLanguage(a.opt.Language),
+			ai.WithSummarizedChanges(summarized),
+		}
+		prompt = ai.GeneratePullRequestPrompt(opts...)
+	)
+
+	if err = a.checkInputBudget(a.estimateInput(prompt, changes, commits)); err != nil {
+		return nil, err
+	}
+
+	response, err := a.query(ctx, provider, changes, commits, append(opts,
+		ai.WithInstructions(prompt),
+		ai.WithCandidates(1), // a single description is enough, it's edited anyway
+		ai.WithMaxOutputTokens(max(a.opt.MaxOutputTokens, prMinOutputTokens)),
+	)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var pr pullRequest
+
+	if pr.Title, pr.Body = ai.ParsePullRequest(response.Answer); pr.Title == "" {
+		return nil, errors.New("the AI provider returned an empty answer")
+	}
+
+	return &pr, nil
+}
+
+// writePullRequest writes the pull request in the given format: Markdown (the title as the heading, followed by
+// the description) or JSON (an object with the "title" and "body" fields).
+func writePullRequest(w io.Writer, pr *pullRequest, format string) error {
+	if format == formatJSON {
+		var enc = json.NewEncoder(w)
+
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(pr)
+	}
+
+	var b strings.Builder
+
+	b.WriteString("# " + pr.Title + "\n")
+
+	if pr.Body != "" {
+		b.WriteString("\n" + pr.Body + "\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+)
+
+// The types of the user-defined provider profiles (the API the profile speaks).
+const (
+	profileTypeOpenAI    = "openai-compatible"
+	profileTypeAnthropic = "anthropic"
+	profileTypeGemini    = "gemini"
+)
+
+// profileTypes returns the list of supported provider profile types.
+func profileTypes() []string {
+	return []string{profileTypeOpenAI, profileTypeAnthropic, profileTypeGemini}
+}
+
+// providerProfile is a user-defined provider, which can be selected by its name the same way as the built-in
+// ones. It allows using any service compatible with one of the supported APIs without writing new code.
+type providerProfile struct {
+	Type      string            // one of the profile types
+	BaseURL   string            // the API base URL (empty = the default one for the type)
+	ApiKey    string            // the API key (mutually exclusive with ApiKeyEnv)
+	ApiKeyEnv string            // the name of the environment variable with the API key
+	ModelName string            // the model name
+	Headers   map[string]string // extra request headers
+	Body      map[string]any    // extra request body fields (nil values remove the fields)
+}
+
+// validate checks the profile settings (the API key is checked by [providerProfile.validateAPIKey], since
+// it's required only when the profile is used).
+func (p providerProfile) validate() error {
+	if !slices.Contains(profileTypes(), p.Type) {
+		return fmt.Errorf("unsupported type %q (%s)", p.Type, strings.Join(profileTypes(), "|"))
+	}
+
+	if p.ModelName == "" {
+		return errors.New("model name is required")
+	}
+
+	if p.BaseURL != "" {
+		if u, err := url.Parse(p.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("wrong base URL %q", p.BaseURL)
+		}
+	}
+
+	if p.ApiKey != "" && p.ApiKeyEnv != "" {
+		return errors.New("API key and API key environment variable are mutually exclusive")
+	}
+
+	return nil
+}
+
+// apiKey returns the API key, reading it from the environment variable if needed.
+func (p providerProfile) apiKey() string {
+	if p.ApiKeyEnv != "" {
+		return os.Getenv(p.ApiKeyEnv)
+	}
+
+	return p.ApiKey
+}
+
+// validateAPIKey checks that the API key is available (the OpenAI-compatible local servers usually do not need
+// it, so it's optional for them unless the environment variable is referenced explicitly).
+func (p providerProfile) validateAPIKey() error {
+	if p.apiKey() != "" {
+		return nil
+	}
+
+	if p.ApiKeyEnv != "" {
+		return fmt.Errorf("the %s environment variable with the API key is not set", p.ApiKeyEnv)
+	}
+
+	if p.Type != profileTypeOpenAI {
+		return errors.New("API key is required")
+	}
+
+	return nil
+}
+
+// newProvider creates the AI provider for the profile.
+func (p providerProfile) newProvider() (ai.Provider, error) {
+	switch p.Type {
+	case profileTypeOpenAI:
+		return ai.NewOpenAI(p.apiKey(), p.ModelName,
+			ai.WithOpenAIBaseURL(p.BaseURL),
+			ai.WithOpenAIHeaders(p.Headers),
+			ai.WithOpenAIExtraBody(p.Body),
+		), nil
+	case profileTypeAnthropic:
+		return ai.NewAnthropic(p.apiKey(), p.ModelName,
+			ai.WithAnthropicBaseURL(p.BaseURL),
+			ai.WithAnthropicHeaders(p.Headers),
+			ai.WithAnthropicExtraBody(p.Body),
+		), nil
+	case profileTypeGemini:
+		return ai.NewGemini(p.apiKey(), p.ModelName,
+			ai.WithGeminiBaseURL(p.BaseURL),
+			ai.WithGeminiHeaders(p.Headers),
+			ai.WithGeminiExtraBody(p.Body),
+		), nil
+	}
+
+	return nil, fmt.Errorf("unsupported provider profile type: %s", p.Type)
+}
+
+// providerType returns the built-in provider name for the provider with the given name: the profiles are mapped
+// to the provider of the API they speak (e.g. to choose the tokenizer for the estimation).
+func (o *options) providerType(name string) string {
+	p, ok := o.Profiles[name]
+	if !ok {
+		return name
+	}
+
+	switch p.Type {
+	case profileTypeAnthropic:
+		return ai.ProviderAnthropic
+	case profileTypeGemini:
+		return ai.ProviderGemini
+	}
+
+	return ai.ProviderOpenAI
+}
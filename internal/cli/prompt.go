@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+	"gh.tarampamp.am/describe-commit/internal/cli/cmd"
+	"gh.tarampamp.am/describe-commit/internal/git"
+)
+
+// newPromptCommand creates the `prompt` command with the `print` subcommand.
+func (a *App) newPromptCommand(loadOptions func(wd string) error) *cmd.Command {
+	return &cmd.Command{
+		Name:        "prompt",
+		Description: "Manage the prompt (system instructions) sent to the AI provider",
+		Commands: []*cmd.Command{
+			{
+				Name:        "print",
+				Description: "Print the rendered prompt (the custom template, if set) without calling the AI provider",
+				Usage:       "[<options>] [<git-dir-path>]",
+				Action: func(ctx context.Context, c *cmd.Command, args []string) error {
+					var wd, wdErr = a.getWorkingDir(args)
+					if wdErr != nil {
+						return fmt.Errorf("wrong working directory: %w", wdErr)
+					}
+
+					if err := loadOptions(wd); err != nil {
+						return err
+					}
+
+					_, prompt, err := a.prompt(ctx, wd, "", false)
+					if err != nil {
+						return err
+					}
+
+					_, err = fmt.Fprintln(c.Output, prompt)
+
+					return err
+				},
+			},
+		},
+	}
+}
+
+// prompt returns the options that affect the prompt and the prompt itself, rendered from the custom template
+// (if set) or generated by [ai.GeneratePrompt]. The rendered template is included into the returned options.
+// The revision (if set) is the described commit.
+func (a *App) prompt(ctx context.Context, workingDir, rev string, summarized bool) ([]ai.Option, string, error) {
+	var opts = []ai.Option{
+		ai.WithShortMessageOnly(a.opt.ShortMessageOnly),
+		ai.WithEmoji(a.opt.EnableEmoji),
+		ai.WithCommitTypes(a.opt.CommitTypes...),
+		ai.WithLanguage(a.opt.Language),
+		ai.WithSummarizedChanges(summarized),
+	}
+
+	if a.opt.IssueKeyMode != issueKeyModePrefix {
+		key, err := a.issueKey(ctx, workingDir, rev)
+		if err != nil {
+			return nil, "", err
+		}
+
+		opts = append(opts, ai.WithIssueKey(key, a.opt.IssueKeyFormat))
+	}
+
+	if a.opt.PromptTemplate == "" {
+		return opts, ai.GeneratePrompt(opts...), nil
+	}
+
+	branch, err := git.CurrentBranch(ctx, workingDir)
+	if err != nil {
+		return nil, "", err
+	}
+
+	opts = append(opts, ai.WithBranchName(branch))
+
+	var filePath = a.opt.PromptTemplate
+
+	if !filepath.IsAbs(filePath) { // relative paths are resolved against the working directory
+		filePath = filepath.Join(workingDir, filePath)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read the prompt template: %w", err)
+	}
+
+	tmpl, err := ai.NewPromptTemplate(string(content))
+	if err != nil {
+		return nil, "", err
+	}
+
+	prompt, err := ai.RenderPrompt(tmpl, ai.NewPromptData(opts...))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return append(opts, ai.WithInstructions(prompt)), prompt, nil
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"gh.tarampamp.am/describe-commit/internal/git"
+)
+
+// maxRevisions limits the number of the existing commits described at once (each of them is a separate request).
+const maxRevisions = 50
+
+// runRevisions describes the existing commit(s) instead of the staged changes, writing the messages to stdout,
+// or rewords the HEAD commit with the generated message (see [App.amend]).
+func (a *App) runRevisions(ctx context.Context, workingDir string) error {
+	revisions, err := git.Revisions(ctx, workingDir, cmp.Or(a.opt.Revision, "HEAD"))
+	if err != nil {
+		return err
+	}
+
+	if len(revisions) > maxRevisions {
+		return fmt.Errorf("too many commits to describe: %d (up to %d at once)", len(revisions), maxRevisions)
+	}
+
+	if a.opt.Amend {
+		return a.amend(ctx, workingDir, revisions)
+	}
+
+	for i, rev := range revisions {
+		if len(revisions) > 1 { // the header allows to match the messages with the commits
+			if i > 0 {
+				_, _ = fmt.Fprintln(os.Stdout)
+			}
+
+			_, _ = fmt.Fprintf(os.Stdout, "# %s %s\n", rev.Short(), rev.Subject)
+		}
+
+		if a.opt.DryRun {
+			if err = a.dryRun(ctx, os.Stdout, workingDir, rev.Hash); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		response, gErr := a.generate(ctx, workingDir, rev.Hash)
+		if gErr != nil {
+			if len(revisions) > 1 && errors.Is(gErr, errNoChanges) { // e.g. an empty commit, the rest are described
+				_, _ = fmt.Fprintf(os.Stderr, "%s: warning: %s, skipped\n", a.cmd.Name, gErr)
+
+				continue
+			}
+
+			return gErr
+		}
+
+		if err = writeAnswers(os.Stdout, response); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// amend generates the message for the HEAD commit and rewords it (`git commit --amend --only`), so neither the
+// commit content nor the staged changes are affected. The older commits are not supported, since rewording them
+// requires rewriting the history (rebasing).
+func (a *App) amend(ctx context.Context, workingDir string, revisions []git.Revision) error {
+	head, err := git.Revisions(ctx, workingDir, "HEAD")
+	if err != nil {
+		return err
+	}
+
+	if len(revisions) != 1 || revisions[0].Hash != head[0].Hash {
+		return errors.New("only the HEAD commit can be amended (print the messages for the older commits " +
+			"without --amend and reword them using `git rebase --interactive`)")
+	}
+
+	var rev = revisions[0].Hash
+
+	switch {
+	case a.opt.DryRun:
+		return a.dryRun(ctx, os.Stdout, workingDir, rev)
+	case a.opt.Interactive:
+		return a.runInteractive(ctx, workingDir, rev)
+	}
+
+	response, err := a.generate(ctx, workingDir, rev)
+	if err != nil {
+		return err
+	}
+
+	result, err := git.Reword(ctx, workingDir, response.Answer)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprint(os.Stdout, result)
+
+	return err
+}
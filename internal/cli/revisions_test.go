@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+)
+
+func TestApp_Amend(t *testing.T) {
+	t.Parallel()
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"content":"feat: Generated message"}}]}`)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	var commit = func(t *testing.T, repo, name, message string) {
+		t.Helper()
+
+		if err := os.WriteFile(filepath.Join(repo, name), []byte(message+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		runGit(t, repo, "add", name)
+		runGit(t, repo, "commit", "--quiet", "--message", message)
+	}
+
+	for name, tc := range map[string]struct {
+		giveRev     string
+		wantErr     string
+		wantSubject string // of the HEAD commit after amending
+	}{
+		"HEAD":           {giveRev: "HEAD", wantSubject: "feat: Generated message"},
+		"branch name":    {giveRev: "main", wantSubject: "feat: Generated message"},
+		"older commit":   {giveRev: "HEAD~1", wantErr: "only the HEAD commit", wantSubject: "third"},
+		"range":          {giveRev: "HEAD~2..HEAD", wantErr: "only the HEAD commit", wantSubject: "third"},
+		"range of HEAD":  {giveRev: "HEAD~1..HEAD", wantSubject: "feat: Generated message"},
+		"unknown commit": {giveRev: "unknown", wantErr: "unknown", wantSubject: "third"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var repo = newTestRepo(t)
+
+			commit(t, repo, "a.txt", "first")
+			commit(t, repo, "b.txt", "second")
+			commit(t, repo, "c.txt", "third")
+
+			// the staged changes must not be included into the amended commit
+			if err := os.WriteFile(filepath.Join(repo, "staged.txt"), []byte("staged\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			runGit(t, repo, "add", "staged.txt")
+
+			var app = NewApp("app")
+
+			app.opt.Cache, app.opt.Amend = false, true
+			app.opt.AIProviderName = ai.ProviderOpenAI
+			app.opt.Providers.OpenAI.ApiKey, app.opt.Providers.OpenAI.BaseURL = "key", srv.URL
+			app.opt.Revision = tc.giveRev
+
+			var err = app.runRevisions(t.Context(), repo)
+
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("expected the %q error, got %v", tc.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if subject := runGit(t, repo, "log", "-1", "--format=%s"); subject != tc.wantSubject {
+				t.Errorf("expected the HEAD subject %q, got %q", tc.wantSubject, subject)
+			}
+
+			if staged := runGit(t, repo, "diff", "--cached", "--name-only"); staged != "staged.txt" {
+				t.Errorf("the staged changes must stay staged, got %q", staged)
+			}
+
+			if count := runGit(t, repo, "rev-list", "--count", "HEAD"); count != "3" {
+				t.Errorf("expected 3 commits, got %s", count)
+			}
+		})
+	}
+}
+
+func TestApp_RunRevisions_TooMany(t *testing.T) {
+	t.Parallel()
+
+	var repo = newTestRepo(t)
+
+	for range maxRevisions + 2 {
+		runGit(t, repo, "commit", "--quiet", "--allow-empty", "--message", "empty")
+	}
+
+	var app = NewApp("app")
+
+	app.opt.Revision = "HEAD~" + strconv.Itoa(maxRevisions+1) + "..HEAD"
+
+	if err := app.runRevisions(t.Context(), repo); err == nil || !strings.Contains(err.Error(), "too many") {
+		t.Errorf("expected the too many commits error, got %v", err)
+	}
+}
+
+func TestApp_Generate_IssueKeyOfRevision(t *testing.T) {
+	t.Parallel()
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"content":"feat: Generated message"}}]}`)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	var repo = newTestRepo(t)
+
+	runGit(t, repo, "commit", "--quiet", "--allow-empty", "--message", "first")
+	runGit(t, repo, "switch", "--quiet", "--create", "PROJ-123-feature")
+
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(repo, name), []byte(name+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		runGit(t, repo, "add", name)
+		runGit(t, repo, "commit", "--quiet", "--message", name)
+	}
+
+	for name, tc := range map[string]struct {
+		giveRev string
+		want    string
+	}{
+		"HEAD":         {giveRev: "HEAD", want: "PROJ-123 feat: Generated message"},
+		"HEAD by hash": {giveRev: runGit(t, repo, "rev-parse", "HEAD"), want: "PROJ-123 feat: Generated message"},
+		"older commit": {giveRev: "HEAD~1", want: "feat: Generated message"}, // may belong to another issue
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var app = NewApp("app")
+
+			app.opt.Cache, app.opt.IssueKeyPattern = false, `[A-Z]+-[0-9]+`
+			app.opt.AIProviderName = ai.ProviderOpenAI
+			app.opt.Providers.OpenAI.ApiKey, app.opt.Providers.OpenAI.BaseURL = "key", srv.URL
+
+			response, err := app.generate(t.Context(), repo, tc.giveRev)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if response.Answer != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, response.Answer)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+	"gh.tarampamp.am/describe-commit/internal/debug"
+	"gh.tarampamp.am/describe-commit/internal/errgroup"
+	"gh.tarampamp.am/describe-commit/internal/git"
+	"gh.tarampamp.am/describe-commit/internal/tokens"
+)
+
+// maxConcurrentSummaries limits the number of diff parts summarized at the same time.
+const maxConcurrentSummaries = 4
+
+// diffLimit returns the maximum size of the changes (in characters) that are sent to the AI provider verbatim
+// (0 = unlimited). The tokens limit is estimated the same way as the input budget, and converted to characters
+// in proportion to the changes. If both the characters and tokens limits are set, the smaller one wins.
+func (o *options) diffLimit(changes string) int {
+	var limit = o.MaxDiffChars
+
+	if o.MaxDiffTokens <= 0 {
+		return limit
+	}
+
+	if n := tokens.EstimateFor(o.providerType(o.AIProviderName), changes); n > o.MaxDiffTokens {
+		if t := max(len(changes)*o.MaxDiffTokens/n, 1); limit == 0 || t < limit {
+			limit = t
+		}
+	}
+
+	return limit
+}
+
+// summarize returns the changes as is, if they fit into the diff budget. Otherwise, the diff is split into
+// chunks, each chunk is summarized concurrently (without the commit history, it's not needed for the summary),
+// and the joined summaries are returned (summarized = true).
+func (a *App) summarize(
+	ctx context.Context,
+	provider ai.Provider,
+	changes string,
+) (_ string, summarized bool, _ error) {
+	var limit = a.opt.diffLimit(changes)
+
+	if limit == 0 || len(changes) <= limit {
+		debug.Printf("diff strategy: verbatim (%d chars)", len(changes))
+
+		return changes, false, nil
+	}
+
+	var chunks = git.SplitDiff(changes, limit)
+
+	debug.Printf("diff strategy: map-reduce (%d chars, limit %d, %d chunks)", len(changes), limit, len(chunks))
+
+	var (
+		eg, _     = errgroup.New(ctx)
+		sem       = make(chan struct{}, maxConcurrentSummaries)
+		summaries = make([]string, len(chunks)) // each goroutine writes its own element only
+	)
+
+	for i, chunk := range chunks {
+		eg.Go(func(ctx context.Context) error {
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			response, err := a.ask(ctx, provider, nil, chunk, "", a.summaryOptions()...)
+			if err != nil {
+				return fmt.Errorf("failed to summarize the diff part %d of %d: %w", i+1, len(chunks), err)
+			}
+
+			debug.Printf("summary of the diff part %d of %d:\n%s", i+1, len(chunks), response.Answer)
+
+			summaries[i] = response.Answer
+
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return "", false, err
+	}
+
+	return joinSummaries(summaries), true, nil
+}
+
+// summaryOptions returns the options of the requests summarizing the diff parts.
+func (a *App) summaryOptions() []ai.Option {
+	return []ai.Option{
+		ai.WithInstructions(ai.GenerateSummaryPrompt()),
+		ai.WithMaxOutputTokens(a.opt.MaxOutputTokens),
+	}
+}
+
+// joinSummaries joins the summaries of the diff parts, numbering them.
+func joinSummaries(summaries []string) string {
+	var b strings.Builder
+
+	for i, summary := range summaries {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+
+		_, _ = fmt.Fprintf(&b, "Part %d of %d:\n%s", i+1, len(summaries), strings.TrimSpace(summary))
+	}
+
+	return b.String()
+}
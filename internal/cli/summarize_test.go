@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+	"gh.tarampamp.am/describe-commit/internal/tokens"
+)
+
+func TestApp_Summarize(t *testing.T) {
+	t.Parallel()
+
+	const (
+		file1 = "diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-foo\n+bar\n"
+		file2 = "diff --git a/b.txt b/b.txt\n--- a/b.txt\n+++ b/b.txt\n@@ -1 +1 @@\n-one\n+two\n"
+	)
+
+	var calls atomic.Int32
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+
+		body, _ := io.ReadAll(r.Body)
+
+		var summary = "Changed a.txt"
+
+		if strings.Contains(string(body), "b.txt") {
+			summary = "Changed b.txt"
+		}
+
+		_, _ = io.WriteString(w, `{"choices":[{"message":{"content":"`+summary+`"}}]}`)
+	}))
+
+	t.Cleanup(srv.Close)
+
+	var provider = ai.NewOpenAI("key", "gpt", ai.WithOpenAIBaseURL(srv.URL))
+
+	for name, tc := range map[string]struct {
+		giveMaxChars   int
+		want           string
+		wantSummarized bool
+		wantCalls      int32
+	}{
+		"unlimited": {
+			want: file1 + file2,
+		},
+		"fits": {
+			giveMaxChars: len(file1 + file2),
+			want:         file1 + file2,
+		},
+		"summarized by parts": {
+			giveMaxChars:   len(file1),
+			want:           "Part 1 of 2:\nChanged a.txt\n\nPart 2 of 2:\nChanged b.txt",
+			wantSummarized: true,
+			wantCalls:      2,
+		},
+	} {
+		t.Run(name, func(t *testing.T) { //nolint:paralleltest // the requests counter is shared
+			var app = NewApp("app")
+
+			app.opt.Cache, app.opt.MaxDiffChars = false, tc.giveMaxChars
+
+			calls.Store(0)
+
+			got, summarized, err := app.summarize(t.Context(), provider, file1+file2)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tc.want || summarized != tc.wantSummarized {
+				t.Errorf("unexpected result (summarized: %t):\n%s\nwant:\n%s", summarized, got, tc.want)
+			}
+
+			if n := calls.Load(); n != tc.wantCalls {
+				t.Errorf("expected %d request(s), got %d", tc.wantCalls, n)
+			}
+		})
+	}
+}
+
+func TestOptions_DiffLimit(t *testing.T) {
+	t.Parallel()
+
+	var changes = strings.Repeat("+func main() { fmt.Println(\"hello\") }\n", 10)
+
+	var (
+		estimated = tokens.Estimate(changes)
+		half      = len(changes) * (estimated / 2) / estimated // the chars of the half of the tokens
+	)
+
+	for name, tc := range map[string]struct {
+		giveProvider string
+		giveChars    int
+		giveTokens   int
+		want         int
+	}{
+		"unlimited":                {want: 0},
+		"chars":                    {giveChars: 100, want: 100},
+		"tokens fit":               {giveTokens: estimated, want: 0},
+		"tokens fit, chars exceed": {giveChars: 100, giveTokens: estimated, want: 100},
+		"tokens exceed":            {giveTokens: estimated / 2, want: half},
+		"chars are smaller":        {giveChars: 10, giveTokens: estimated / 2, want: 10},
+		"tokens are smaller":       {giveChars: len(changes), giveTokens: estimated / 2, want: half},
+		"provider tokenizer": {
+			giveProvider: ai.ProviderAnthropic,
+			giveTokens:   estimated, // fits for OpenAI, but not for the Claude tokenizer
+			want:         len(changes) * estimated / tokens.EstimateFor(ai.ProviderAnthropic, changes),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var o = options{AIProviderName: tc.giveProvider, MaxDiffChars: tc.giveChars, MaxDiffTokens: tc.giveTokens}
+
+			if got := o.diffLimit(changes); got != tc.want {
+				t.Errorf("expected the limit %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
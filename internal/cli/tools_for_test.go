@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// newTestRepo creates an empty git repository in a temporary directory and returns its path. The repository is
+// configured to not depend on the user's git configuration (author, signing, hooks).
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+
+	var dir = t.TempDir()
+
+	runGit(t, dir, "init", "--quiet", "--initial-branch=main")
+	runGit(t, dir, "config", "user.name", "Test")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	runGit(t, dir, "config", "commit.gpgSign", "false")
+	runGit(t, dir, "config", "core.hooksPath", ".git/hooks")
+
+	return dir
+}
+
+// runGit runs git with the arguments in the directory and returns its (trimmed) output, failing the test on error.
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	var cmd = exec.CommandContext(t.Context(), "git", args...)
+
+	cmd.Dir = dir
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+
+	return strings.TrimSpace(string(out))
+}
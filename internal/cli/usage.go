@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gh.tarampamp.am/describe-commit/internal/ai"
+)
+
+// modelPrice is the price of the model in USD per 1M tokens.
+type modelPrice struct{ Input, Output, Cached float64 }
+
+// cost returns the estimated cost of the usage in USD.
+func (p modelPrice) cost(u ai.Usage) float64 {
+	var uncached = max(u.InputTokens-u.CachedTokens, 0)
+
+	return (float64(uncached)*p.Input + float64(u.CachedTokens)*p.Cached + float64(u.OutputTokens)*p.Output) / 1e6
+}
+
+// usageTracker sums the token usage of the AI provider requests by the model. It's safe for concurrent use.
+type usageTracker struct {
+	mu     sync.Mutex
+	models []string // in order of the first request
+	usage  map[string]*usageRecord
+}
+
+// usageRecord is the token usage of a single model (the line of the usage ledger).
+type usageRecord struct {
+	Time         time.Time `json:"time"`
+	Dir          string    `json:"dir"`
+	Model        string    `json:"model"`
+	Requests     int       `json:"requests"`
+	InputTokens  int64     `json:"input_tokens"` // including the cached ones
+	CachedTokens int64     `json:"cached_tokens"`
+	OutputTokens int64     `json:"output_tokens"`
+	CostUSD      *float64  `json:"cost_usd,omitempty"` // nil if the model price is unknown
+}
+
+// add records the usage of the successful request to the model.
+func (t *usageTracker) add(model string, u ai.Usage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if model == "" {
+		model = "unknown"
+	}
+
+	if t.usage == nil {
+		t.usage = make(map[string]*usageRecord)
+	}
+
+	rec, ok := t.usage[model]
+	if !ok {
+		rec = &usageRecord{Model: model}
+		t.usage[model], t.models = rec, append(t.models, model)
+	}
+
+	rec.Requests++
+	rec.InputTokens += u.InputTokens
+	rec.CachedTokens += u.CachedTokens
+	rec.OutputTokens += u.OutputTokens
+}
+
+// records returns the copy of the recorded usage, priced by the given table.
+func (t *usageTracker) records(prices map[string]modelPrice) []usageRecord {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var out = make([]usageRecord, 0, len(t.models))
+
+	for _, model := range t.models {
+		var rec = *t.usage[model]
+
+		if price, ok := prices[model]; ok {
+			var cost = price.cost(ai.Usage{
+				InputTokens:  rec.InputTokens,
+				OutputTokens: rec.OutputTokens,
+				CachedTokens: rec.CachedTokens,
+			})
+
+			rec.CostUSD = &cost
+		}
+
+		out = append(out, rec)
+	}
+
+	return out
+}
+
+// reportUsage prints the token usage (and the estimated cost, if the model price is known) to stderr when
+// requested, and appends it to the usage ledger (if set). Failures are reported, but not returned, since the
+// commit message is already generated at this point.
+func (a *App) reportUsage(workingDir string) {
+	var records = a.usage.records(a.opt.Prices)
+	if len(records) == 0 {
+		return
+	}
+
+	if a.opt.ShowUsage {
+		_ = writeUsage(os.Stderr, records)
+	}
+
+	if a.opt.UsageLedger != "" {
+		if err := appendUsageLedger(a.opt.UsageLedger, workingDir, time.Now(), records); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "%s: failed to write the usage ledger: %s\n", a.cmd.Name, err)
+		}
+	}
+}
+
+// writeUsage writes the human-readable usage lines to w.
+func writeUsage(w io.Writer, records []usageRecord) error {
+	for _, rec := range records {
+		var cost = "unknown cost (no price for the model)"
+
+		if rec.CostUSD != nil {
+			cost = fmt.Sprintf("~$%.6f", *rec.CostUSD)
+		}
+
+		if _, err := fmt.Fprintf(w, "usage: %s: %d request(s), %d input (%d cached) + %d output tokens, %s\n",
+			rec.Model, rec.Requests, rec.InputTokens, rec.CachedTokens, rec.OutputTokens, cost,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// appendUsageLedger appends the records to the JSONL file (one JSON object per line), creating it (and the
+// parent directories) if needed.
+func appendUsageLedger(path, workingDir string, now time.Time, records []usageRecord) error {
+	var buf []byte
+
+	for _, rec := range records {
+		rec.Time, rec.Dir = now.UTC(), workingDir
+
+		line, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+
+		buf = append(append(buf, line...), '\n')
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:mnd
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) //nolint:mnd
+	if err != nil {
+		return err
+	}
+
+	if _, err = f.Write(buf); err != nil { // a single write, so the concurrent runs do not interleave the lines
+		_ = f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
 
 	"gh.tarampamp.am/describe-commit/internal/yaml"
 )
@@ -13,17 +14,53 @@ type (
 	// Config is used to unmarshal the configuration file content.
 	Config struct {
 		// pointers are used to distinguish between unset and set values (nil = unset)
-		ShortMessageOnly    *bool       `yaml:"shortMessageOnly"`
-		CommitHistoryLength *int64      `yaml:"commitHistoryLength"`
-		EnableEmoji         *bool       `yaml:"enableEmoji"`
-		AIProviderName      *string     `yaml:"aiProvider"`
-		MaxOutputTokens     *int64      `yaml:"maxOutputTokens"`
-		MaxRetries          *uint       `yaml:"maxRetries"`
-		RetryDelay          *string     `yaml:"retryDelay"`
-		Gemini              *Gemini     `yaml:"gemini"`
-		OpenAI              *OpenAI     `yaml:"openai"`
-		OpenRouter          *OpenRouter `yaml:"openrouter"`
-		Anthropic           *Anthropic  `yaml:"anthropic"`
+		ShortMessageOnly       *bool                      `yaml:"shortMessageOnly"`
+		CommitHistoryLength    *int64                     `yaml:"commitHistoryLength"`
+		CommitHistoryBodies    *bool                      `yaml:"commitHistoryBodies"`
+		CommitHistoryAuthors   *bool                      `yaml:"commitHistoryAuthors"`
+		CommitHistoryFiles     *bool                      `yaml:"commitHistoryFiles"`
+		CommitHistorySamePaths *bool                      `yaml:"commitHistorySamePaths"`
+		CommitHistoryMaxChars  *int                       `yaml:"commitHistoryMaxChars"`
+		EnableEmoji            *bool                      `yaml:"enableEmoji"`
+		AIProviderName         *string                    `yaml:"aiProvider"`
+		MaxOutputTokens        *int64                     `yaml:"maxOutputTokens"`
+		MaxRetries             *uint                      `yaml:"maxRetries"`
+		RetryDelay             *string                    `yaml:"retryDelay"`
+		RetryBackoff           *float64                   `yaml:"retryBackoff"`
+		RetryMaxDelay          *string                    `yaml:"retryMaxDelay"`
+		RetryJitter            *bool                      `yaml:"retryJitter"`
+		RetryDeadline          *string                    `yaml:"retryDeadline"`
+		Interactive            *bool                      `yaml:"interactive"`
+		Stream                 *bool                      `yaml:"stream"`
+		Candidates             *int                       `yaml:"candidates"`
+		MaxDiffChars           *int                       `yaml:"maxDiffChars"`
+		MaxDiffTokens          *int                       `yaml:"maxDiffTokens"`
+		MaxInputTokens         *int                       `yaml:"maxInputTokens"`
+		InputBudgetAction      *string                    `yaml:"inputBudgetAction"`
+		Cache                  *bool                      `yaml:"cache"`
+		CacheTTL               *string                    `yaml:"cacheTTL"`
+		DiffSource             *string                    `yaml:"diffSource"`
+		Exclude                []string                   `yaml:"exclude"`
+		Include                []string                   `yaml:"include"`
+		DefaultExcludes        *bool                      `yaml:"defaultExcludes"`
+		GitAttributes          *bool                      `yaml:"gitAttributes"`
+		PromptTemplate         *string                    `yaml:"promptTemplate"`
+		CommitTypes            []string                   `yaml:"commitTypes"`
+		IssueKeyPattern        *string                    `yaml:"issueKeyPattern"`
+		IssueKeyFormat         *string                    `yaml:"issueKeyFormat"`
+		IssueKeyMode           *string                    `yaml:"issueKeyMode"`
+		Language               *string                    `yaml:"language"`
+		Fallback               []string                   `yaml:"fallback"`
+		ShowUsage              *bool                      `yaml:"showUsage"`
+		UsageLedger            *string                    `yaml:"usageLedger"`
+		Prices                 map[string]ModelPrice      `yaml:"prices"`
+		Gemini                 *Gemini                    `yaml:"gemini"`
+		OpenAI                 *OpenAI                    `yaml:"openai"`
+		OpenRouter             *OpenRouter                `yaml:"openrouter"`
+		Anthropic              *Anthropic                 `yaml:"anthropic"`
+		Ollama                 *Ollama                    `yaml:"ollama"`
+		AzureOpenAI            *AzureOpenAI               `yaml:"azureOpenai"`
+		Profiles               map[string]ProviderProfile `yaml:"profiles"`
 	}
 
 	Gemini struct {
@@ -49,6 +86,39 @@ type (
 		ModelName *string `yaml:"modelName"`
 		BaseURL   *string `yaml:"baseUrl"`
 	}
+
+	Ollama struct {
+		ModelName *string `yaml:"modelName"`
+		BaseURL   *string `yaml:"baseUrl"`
+		NumCtx    *int    `yaml:"numCtx"`
+		KeepAlive *string `yaml:"keepAlive"`
+		AutoPull  *bool   `yaml:"autoPull"`
+	}
+
+	AzureOpenAI struct {
+		ApiKey         *string `yaml:"apiKey"`
+		Endpoint       *string `yaml:"endpoint"`
+		DeploymentName *string `yaml:"deploymentName"`
+		APIVersion     *string `yaml:"apiVersion"`
+	}
+
+	// ProviderProfile is a user-defined provider (the profiles from several files are merged by their names).
+	ProviderProfile struct {
+		Type      string            `yaml:"type"`
+		BaseURL   string            `yaml:"baseUrl"`
+		ApiKey    string            `yaml:"apiKey"`
+		ApiKeyEnv string            `yaml:"apiKeyEnv"`
+		ModelName string            `yaml:"modelName"`
+		Headers   map[string]string `yaml:"headers"`
+		Body      map[string]any    `yaml:"body"`
+	}
+
+	// ModelPrice is the price of the model in USD per 1M tokens (used to estimate the cost of the requests).
+	ModelPrice struct {
+		Input  float64  `yaml:"input"`
+		Output float64  `yaml:"output"`
+		Cached *float64 `yaml:"cached"` // the input price is used if unset
+	}
 )
 
 // FromFile initializes self state by reading the configuration file from the provided path.
@@ -76,3 +146,29 @@ func (c *Config) FromFile(path string) error {
 
 	return nil
 }
+
+// TrustedOnlyKeys returns the keys set in the configuration that redirect the requests (with the API keys and the
+// changes) to another host, or read the secrets from the environment: the provider base URLs, the Azure OpenAI
+// endpoint and the provider profiles. They must be accepted from the files chosen by the user only, not from the
+// ones that may come with a cloned repository.
+func (c *Config) TrustedOnlyKeys() []string {
+	var keys []string
+
+	for key, set := range map[string]bool{
+		"gemini.baseUrl":       c.Gemini != nil && c.Gemini.BaseURL != nil,
+		"openai.baseUrl":       c.OpenAI != nil && c.OpenAI.BaseURL != nil,
+		"openrouter.baseUrl":   c.OpenRouter != nil && c.OpenRouter.BaseURL != nil,
+		"anthropic.baseUrl":    c.Anthropic != nil && c.Anthropic.BaseURL != nil,
+		"ollama.baseUrl":       c.Ollama != nil && c.Ollama.BaseURL != nil,
+		"azureOpenai.endpoint": c.AzureOpenAI != nil && c.AzureOpenAI.Endpoint != nil,
+		"profiles":             c.Profiles != nil,
+	} {
+		if set {
+			keys = append(keys, key)
+		}
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
@@ -26,9 +26,42 @@ func TestConfig_FromFile(t *testing.T) {
 			giveContent: `
 shortMessageOnly: true
 commitHistoryLength: 312312
+commitHistoryBodies: true
+commitHistoryAuthors: true
+commitHistoryFiles: true
+commitHistorySamePaths: true
+commitHistoryMaxChars: 4321
 enableEmoji: false
 maxOutputTokens: 123123123
+retryBackoff: 1.5
+retryMaxDelay: 10s
+retryJitter: false
+retryDeadline: 2m
+maxInputTokens: 8000
+inputBudgetAction: abort
+cache: false
+cacheTTL: 1h
+diffSource: unstaged
 aiProvider: foobar
+exclude: ["*.pb.go", "vendor/"]
+include:
+  - go.sum
+defaultExcludes: false
+gitAttributes: true
+promptTemplate: ./prompt.tmpl
+commitTypes: [feat, fix]
+issueKeyPattern: '[A-Z]+-[0-9]+'
+issueKeyFormat: '[{key}]'
+issueKeyMode: both
+language: de
+fallback: [openai, "ollama:qwen2.5-coder:7b"]
+showUsage: true
+usageLedger: /tmp/usage.jsonl
+prices:
+  gpt-4.1-nano: {input: 0.1, output: 0.4, cached: 0.025}
+  claude-haiku-4-5-20251001:
+    input: 1
+    output: 5
 gemini:
   apiKey: <your-api-key>
   modelName: <gemini-model-name>
@@ -44,13 +77,67 @@ openrouter:
 anthropic:
   apiKey: <anthropic-api-key>
   modelName: <anthropic-model-name>
-  baseUrl: https://anthropic.example.com`,
+  baseUrl: https://anthropic.example.com
+ollama:
+  modelName: <ollama-model-name>
+  baseUrl: http://ollama.example.com
+  numCtx: 8192
+  keepAlive: 10m
+  autoPull: true
+azureOpenai:
+  apiKey: <azure-api-key>
+  endpoint: https://my-resource.openai.azure.com
+  deploymentName: gpt-4o-mini
+  apiVersion: 2025-01-01-preview
+profiles:
+  groq:
+    type: openai-compatible
+    baseUrl: https://api.groq.com/openai
+    apiKeyEnv: GROQ_API_KEY
+    modelName: llama-3.3-70b-versatile
+    headers:
+      X-Title: describe-commit
+    body:
+      store: null
+      reasoning:
+        effort: low`,
 			wantStruct: func() (c config.Config) {
 				c.ShortMessageOnly = toPtr(true)
 				c.CommitHistoryLength = toPtr[int64](312312)
+				c.CommitHistoryBodies = toPtr(true)
+				c.CommitHistoryAuthors = toPtr(true)
+				c.CommitHistoryFiles = toPtr(true)
+				c.CommitHistorySamePaths = toPtr(true)
+				c.CommitHistoryMaxChars = toPtr(4321)
 				c.EnableEmoji = toPtr(false)
 				c.MaxOutputTokens = toPtr[int64](123123123)
+				c.RetryBackoff = toPtr(1.5)
+				c.RetryMaxDelay = toPtr("10s")
+				c.RetryJitter = toPtr(false)
+				c.RetryDeadline = toPtr("2m")
+				c.MaxInputTokens = toPtr(8000)
+				c.InputBudgetAction = toPtr("abort")
+				c.Cache = toPtr(false)
+				c.CacheTTL = toPtr("1h")
+				c.DiffSource = toPtr("unstaged")
 				c.AIProviderName = toPtr("foobar")
+				c.Exclude = []string{"*.pb.go", "vendor/"}
+				c.Include = []string{"go.sum"}
+				c.DefaultExcludes = toPtr(false)
+				c.GitAttributes = toPtr(true)
+				c.PromptTemplate = toPtr("./prompt.tmpl")
+				c.CommitTypes = []string{"feat", "fix"}
+				c.IssueKeyPattern = toPtr("[A-Z]+-[0-9]+")
+				c.IssueKeyFormat = toPtr("[{key}]")
+				c.IssueKeyMode = toPtr("both")
+				c.Language = toPtr("de")
+				c.ShowUsage = toPtr(true)
+				c.UsageLedger = toPtr("/tmp/usage.jsonl")
+				c.Prices = map[string]config.ModelPrice{
+					"gpt-4.1-nano":              {Input: 0.1, Output: 0.4, Cached: toPtr(0.025)},
+					"claude-haiku-4-5-20251001": {Input: 1, Output: 5},
+				}
+				c.Fallback = []string{"openai", "ollama:qwen2.5-coder:7b"}
 				c.Gemini = &config.Gemini{
 					ApiKey:    toPtr("<your-api-key>"),
 					ModelName: toPtr("<gemini-model-name>"),
@@ -71,6 +158,29 @@ anthropic:
 					ModelName: toPtr("<anthropic-model-name>"),
 					BaseURL:   toPtr("https://anthropic.example.com"),
 				}
+				c.Ollama = &config.Ollama{
+					ModelName: toPtr("<ollama-model-name>"),
+					BaseURL:   toPtr("http://ollama.example.com"),
+					NumCtx:    toPtr(8192),
+					KeepAlive: toPtr("10m"),
+					AutoPull:  toPtr(true),
+				}
+				c.AzureOpenAI = &config.AzureOpenAI{
+					ApiKey:         toPtr("<azure-api-key>"),
+					Endpoint:       toPtr("https://my-resource.openai.azure.com"),
+					DeploymentName: toPtr("gpt-4o-mini"),
+					APIVersion:     toPtr("2025-01-01-preview"),
+				}
+				c.Profiles = map[string]config.ProviderProfile{
+					"groq": {
+						Type:      "openai-compatible",
+						BaseURL:   "https://api.groq.com/openai",
+						ApiKeyEnv: "GROQ_API_KEY",
+						ModelName: "llama-3.3-70b-versatile",
+						Headers:   map[string]string{"X-Title": "describe-commit"},
+						Body:      map[string]any{"store": nil, "reasoning": map[string]any{"effort": "low"}},
+					},
+				}
 
 				return
 			}(),
@@ -188,4 +298,48 @@ openai:
 	})
 }
 
+func TestConfig_TrustedOnlyKeys(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		giveConfig config.Config
+		want       []string
+	}{
+		"empty": {},
+		"safe options": {
+			giveConfig: config.Config{
+				EnableEmoji: toPtr(true),
+				OpenAI:      &config.OpenAI{ApiKey: toPtr("key"), ModelName: toPtr("gpt")},
+				Ollama:      &config.Ollama{ModelName: toPtr("qwen")},
+				AzureOpenAI: &config.AzureOpenAI{DeploymentName: toPtr("deployment")},
+			},
+		},
+		"base URLs": {
+			giveConfig: config.Config{
+				Gemini:     &config.Gemini{BaseURL: toPtr("")},
+				OpenAI:     &config.OpenAI{BaseURL: toPtr("https://example.com")},
+				OpenRouter: &config.OpenRouter{BaseURL: toPtr("https://example.com")},
+				Anthropic:  &config.Anthropic{BaseURL: toPtr("https://example.com")},
+				Ollama:     &config.Ollama{BaseURL: toPtr("https://example.com")},
+			},
+			want: []string{"anthropic.baseUrl", "gemini.baseUrl", "ollama.baseUrl", "openai.baseUrl", "openrouter.baseUrl"},
+		},
+		"endpoint and profiles": {
+			giveConfig: config.Config{
+				AzureOpenAI: &config.AzureOpenAI{Endpoint: toPtr("https://example.com")},
+				Profiles:    map[string]config.ProviderProfile{"evil": {ApiKeyEnv: "AWS_SECRET_ACCESS_KEY"}},
+			},
+			want: []string{"azureOpenai.endpoint", "profiles"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tc.giveConfig.TrustedOnlyKeys(); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
 func toPtr[T any](v T) *T { return &v }
@@ -0,0 +1,46 @@
+package git
+
+import (
+	"context"
+	"strings"
+)
+
+// ignoredByAttributes returns the paths (of the given ones) that are marked as generated (`linguist-generated`)
+// or non-diffable (`-diff`) in the .gitattributes files. The attributes are read from the index, so the staged
+// .gitattributes changes are taken into account.
+func ignoredByAttributes(ctx context.Context, dirPath string, paths []string) (map[string]struct{}, error) {
+	if len(paths) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+
+	// the paths are relative to the root of the working tree, while check-attr resolves them against the current
+	// directory
+	root, err := run(ctx, dirPath, nil, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := run(ctx, trimNewline(root),
+		strings.NewReader(strings.Join(paths, "\x00")+"\x00"),
+		"check-attr", "--cached", "-z", "--stdin", "linguist-generated", "diff",
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		fields  = strings.Split(out, "\x00")
+		ignored = make(map[string]struct{})
+	)
+
+	// the output is a sequence of "<path> NUL <attribute> NUL <value> NUL" records
+	for i := 0; i+2 < len(fields); i += 3 {
+		var filePath, attr, value = fields[i], fields[i+1], fields[i+2]
+
+		if (attr == "linguist-generated" && (value == "set" || value == "true")) || (attr == "diff" && value == "unset") {
+			ignored[filePath] = struct{}{}
+		}
+	}
+
+	return ignored, nil
+}
@@ -0,0 +1,52 @@
+package git_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gh.tarampamp.am/describe-commit/internal/git"
+)
+
+func TestDiff_Attributes(t *testing.T) {
+	t.Parallel()
+
+	var repo = newTestRepo(t)
+
+	commitFiles(t, repo, "init", map[string]string{
+		".gitattributes": "gen/** linguist-generated\n*.bin -diff\n",
+		"sub/keep.txt":   "",
+	})
+
+	writeFiles(t, repo, map[string]string{
+		"gen/api.go":    "package gen\n",
+		"sub/data.bin":  "data\n",
+		"sub/main.go":   "package main\n",
+		"sub/other.txt": "text\n",
+	})
+	runGit(t, repo, "add", "--all")
+
+	// the paths are relative to the root, so the attributes must be checked there regardless of the current dir
+	for _, dir := range []string{repo, filepath.Join(repo, "sub"), filepath.Join(repo, "gen")} {
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			t.Parallel()
+
+			diff, err := git.Diff(t.Context(), dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, want := range []string{"sub/main.go", "sub/other.txt"} {
+				if !strings.Contains(diff, "+++ b/"+want) {
+					t.Errorf("the diff must contain %s:\n%s", want, diff)
+				}
+			}
+
+			for _, unwanted := range []string{"gen/api.go", "sub/data.bin"} {
+				if strings.Contains(diff, unwanted) {
+					t.Errorf("the diff must not contain %s:\n%s", unwanted, diff)
+				}
+			}
+		})
+	}
+}
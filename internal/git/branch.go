@@ -0,0 +1,45 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// CurrentBranch returns the name of the current branch. An empty string is returned for the detached HEAD.
+func CurrentBranch(ctx context.Context, dirPath string) (string, error) {
+	out, err := run(ctx, dirPath, nil, "branch", "--show-current")
+	if err != nil {
+		return "", err
+	}
+
+	return trimNewline(out), nil
+}
+
+// DefaultBranch returns the name of the default branch to compare the current branch with: the one the remote
+// "origin" points to (e.g. "origin/main"), or the local "main" or "master" branch, whichever exists.
+func DefaultBranch(ctx context.Context, dirPath string) (string, error) {
+	if out, err := run(ctx, dirPath, nil, "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		if name := trimNewline(out); name != "" {
+			return name, nil
+		}
+	}
+
+	for _, name := range []string{"main", "master"} {
+		if _, err := run(ctx, dirPath, nil, "rev-parse", "--verify", "--quiet", "refs/heads/"+name); err == nil {
+			return name, nil
+		}
+	}
+
+	return "", errors.New("failed to determine the default branch (neither origin/HEAD, main nor master exist)")
+}
+
+// MergeBase returns the hash of the best common ancestor of the two revisions (`git merge-base`).
+func MergeBase(ctx context.Context, dirPath, a, b string) (string, error) {
+	out, err := run(ctx, dirPath, nil, "merge-base", "--end-of-options", a, b)
+	if err != nil {
+		return "", fmt.Errorf("failed to find the merge-base of %s and %s: %w", a, b, err)
+	}
+
+	return trimNewline(out), nil
+}
@@ -0,0 +1,27 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Commit records the staged changes to the repository using the provided message (`git commit -F -`) and
+// returns the git output (a short summary of the created commit).
+func Commit(ctx context.Context, dirPath, message string) (string, error) {
+	if strings.TrimSpace(message) == "" {
+		return "", errors.New("empty commit message")
+	}
+
+	return run(ctx, dirPath, strings.NewReader(message), "commit", "--file=-")
+}
+
+// Reword replaces the message of the HEAD commit (`git commit --amend --only -F -`), leaving its content as is,
+// even if something is staged. The git output (a short summary of the amended commit) is returned.
+func Reword(ctx context.Context, dirPath, message string) (string, error) {
+	if strings.TrimSpace(message) == "" {
+		return "", errors.New("empty commit message")
+	}
+
+	return run(ctx, dirPath, strings.NewReader(message), "commit", "--amend", "--only", "--file=-")
+}
@@ -4,20 +4,91 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 )
 
-// Diff returns the diff of the staged changes or changes between the index and the working tree.
-func Diff(ctx context.Context, dirPath string) (string, error) {
+// DefaultExcludes are the glob patterns (see [MatchGlob]) of the files that are excluded from the diff by
+// default.
+var DefaultExcludes = []string{ //nolint:gochecknoglobals
+	"*.sum",  // exclude .sum files
+	"*.lock", // exclude .lock files
+	"*.log",  // exclude .log files
+	"*.out",  // exclude .out files
+	"*.tmp",  // exclude .tmp files
+	"*.bak",  // exclude .bak files
+	"*.swp",  // exclude .swp files
+	"*.env",  // exclude .env files
+}
+
+// The sources of the changes for [Diff] (see [WithSource]). Any other source is treated as the base revision (or
+// the revision range) to compare with, e.g. "origin/main" or "origin/main...HEAD".
+const (
+	SourceStaged   = "staged"   // the staged changes (the default)
+	SourceUnstaged = "unstaged" // the changes in the working tree that are not staged yet
+	SourceAll      = "all"      // all the changes in the working tree (staged or not), including the untracked files
+)
+
+type (
+	diffOptions struct {
+		Exclude, Include []string
+		NoDefaults       bool
+		NoAttributes     bool
+		Revision         string
+		Source           string
+	}
+
+	// DiffOption allows to customize the [Diff] output.
+	DiffOption func(*diffOptions)
+)
+
+// WithExclude excludes the files matching the glob patterns (see [MatchGlob]) from the diff, in addition to
+// the [DefaultExcludes].
+func WithExclude(patterns ...string) DiffOption {
+	return func(o *diffOptions) { o.Exclude = append(o.Exclude, patterns...) }
+}
+
+// WithInclude keeps the files matching the glob patterns in the diff, even if they are excluded by the other
+// patterns (including the [DefaultExcludes]) or by the .gitattributes markers.
+func WithInclude(patterns ...string) DiffOption {
+	return func(o *diffOptions) { o.Include = append(o.Include, patterns...) }
+}
+
+// WithoutDefaultExcludes disables the [DefaultExcludes].
+func WithoutDefaultExcludes() DiffOption { return func(o *diffOptions) { o.NoDefaults = true } }
+
+// WithoutAttributes disables excluding the files marked as `linguist-generated` or `-diff` in .gitattributes.
+func WithoutAttributes() DiffOption { return func(o *diffOptions) { o.NoAttributes = true } }
+
+// WithRevision makes the diff of the changes introduced by the commit (compared to its first parent) instead of
+// the staged changes.
+func WithRevision(rev string) DiffOption { return func(o *diffOptions) { o.Revision = rev } }
+
+// WithSource sets the source of the changes (see [SourceStaged], [SourceUnstaged], [SourceAll]) or the base
+// revision to compare with. It's ignored if the revision is set (see [WithRevision]).
+func WithSource(source string) DiffOption { return func(o *diffOptions) { o.Source = source } }
+
+// Diff returns the diff of the staged changes (or the changes from another source, see [WithSource]), or the
+// changes introduced by the commit (see [WithRevision]).
+//
+// The files matching the exclude patterns and the files marked as `linguist-generated` or `-diff` in
+// .gitattributes are omitted, unless they match the include patterns.
+func Diff(ctx context.Context, dirPath string, opts ...DiffOption) (string, error) {
+	var opt diffOptions
+
+	for _, o := range opts {
+		o(&opt)
+	}
+
 	// ensure git is installed and available to run
 	gitFilePath, lookErr := binPath()
 	if lookErr != nil {
 		return "", lookErr
 	}
 
-	// get the diff
-	var cmd = exec.CommandContext(ctx, gitFilePath, "diff",
-		"--cached",                 // show all staged changes or changes between the index and the working tree
+	var args = []string{"diff",
 		"--ignore-submodules=all",  // ignore changes to submodules
 		"--diff-algorithm=minimal", // use the minimal diff algorithm
 		"--no-ext-diff",            // do not use external diff helper
@@ -25,24 +96,55 @@ func Diff(ctx context.Context, dirPath string) (string, error) {
 		"--ignore-blank-lines",     // ignore changes whose lines are all blank
 		"--no-color",               // do not use any color in the output
 		"--patch",                  // generate patch (unified diff) format
-		"--",
-		":(exclude)*.sum",  // exclude .sum files
-		":(exclude)*.lock", // exclude .lock files
-		":(exclude)*.log",  // exclude .log files
-		":(exclude)*.out",  // exclude .out files
-		":(exclude)*.tmp",  // exclude .tmp files
-		":(exclude)*.bak",  // exclude .bak files
-		":(exclude)*.swp",  // exclude .swp files
-		":(exclude)*.env",  // exclude .env files
-	)
+	}
 
-	cmd.Dir = dirPath
-	cmd.Env = []string{
+	var env = []string{
 		"LC_ALL=C", "LANG=C", // forces the system to use the "C" (POSIX) locale, English-based output with no localization
 		"NO_COLOR=1",            // disables colored output
 		"GIT_CONFIG_NOSYSTEM=1", // do not use the system-wide configuration file
 	}
 
+	env = append(env, repositoryEnv()...) // e.g. the index of the commit in progress, when running as a hook
+
+	// the revisions are passed after the options, and they are not options even if they start with a dash
+	switch {
+	case opt.Revision != "":
+		args = append([]string{"show",
+			"--format=",                  // omit the commit header, the patch only
+			"--diff-merges=first-parent", // show the merge commit as the changes brought to the first parent
+		}, args[1:]...)
+		args = append(args, "--end-of-options", opt.Revision)
+	case opt.Source == "", opt.Source == SourceStaged:
+		args = append(args, "--cached") // the changes between the index and the HEAD commit
+	case opt.Source == SourceUnstaged:
+		// the changes between the working tree and the index, no extra arguments needed
+	case opt.Source == SourceAll:
+		indexPath, cleanup, err := intentToAddIndex(ctx, dirPath)
+		if err != nil {
+			return "", err
+		}
+
+		defer cleanup()
+
+		base, err := headOrEmptyTree(ctx, dirPath)
+		if err != nil {
+			return "", err
+		}
+
+		// the changes between the working tree and the HEAD commit, with the untracked files marked as added in
+		// the temporary index, so they are shown as new files
+		args = append(args, "--end-of-options", base)
+		env = append(env, "GIT_INDEX_FILE="+indexPath)
+	default:
+		args = append(args, "--end-of-options", opt.Source) // the base revision or the revision range
+	}
+
+	// get the diff
+	var cmd = exec.CommandContext(ctx, gitFilePath, args...)
+
+	cmd.Dir = dirPath
+	cmd.Env = env
+
 	var stdOut, stdErr bytes.Buffer
 
 	stdOut.Grow(1024 * 8) //nolint:mnd // 8KB
@@ -58,5 +160,109 @@ func Diff(ctx context.Context, dirPath string) (string, error) {
 		return "", fmt.Errorf("git diff failed: %w", err)
 	}
 
-	return stdOut.String(), nil
+	return filterDiff(ctx, dirPath, stdOut.String(), opt)
+}
+
+// intentToAddIndex creates a copy of the index with all the untracked (and not ignored) files marked as
+// "intent to add" (`git add --all --intent-to-add`), so the real index is not touched. The returned function
+// removes the copy.
+func intentToAddIndex(ctx context.Context, dirPath string) (string, func(), error) {
+	out, err := run(ctx, dirPath, nil, "rev-parse", "--git-path", "index")
+	if err != nil {
+		return "", nil, err
+	}
+
+	var realPath = trimNewline(out)
+
+	if !filepath.IsAbs(realPath) { // relative to the working directory
+		realPath = filepath.Join(dirPath, realPath)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "describe-commit-*")
+	if err != nil {
+		return "", nil, err
+	}
+
+	var (
+		indexPath = filepath.Join(tmpDir, "index")
+		cleanup   = func() { _ = os.RemoveAll(tmpDir) }
+	)
+
+	if data, rErr := os.ReadFile(realPath); rErr == nil { // the index is missing in the fresh repository
+		if err = os.WriteFile(indexPath, data, 0o600); err != nil { //nolint:mnd
+			cleanup()
+
+			return "", nil, err
+		}
+	}
+
+	if _, err = runWithEnv(ctx, dirPath, []string{"GIT_INDEX_FILE=" + indexPath}, nil,
+		"add", "--all", "--intent-to-add",
+	); err != nil {
+		cleanup()
+
+		return "", nil, err
+	}
+
+	return indexPath, cleanup, nil
+}
+
+// headOrEmptyTree returns the HEAD commit hash, or the hash of the empty tree if there are no commits yet.
+func headOrEmptyTree(ctx context.Context, dirPath string) (string, error) {
+	if out, err := run(ctx, dirPath, nil, "rev-parse", "--verify", "--quiet", "HEAD"); err == nil {
+		return trimNewline(out), nil
+	}
+
+	out, err := run(ctx, dirPath, strings.NewReader(""), "hash-object", "-t", "tree", "--stdin")
+	if err != nil {
+		return "", err
+	}
+
+	return trimNewline(out), nil
+}
+
+// filterDiff removes the excluded files from the diff.
+func filterDiff(ctx context.Context, dirPath, diff string, opt diffOptions) (string, error) {
+	var exclude = opt.Exclude
+
+	if !opt.NoDefaults {
+		exclude = append(DefaultExcludes[:len(DefaultExcludes):len(DefaultExcludes)], exclude...)
+	}
+
+	files, err := ParseDiff(diff)
+	if err != nil {
+		return "", err
+	}
+
+	var paths = make([]string, 0, len(files))
+
+	for _, f := range files {
+		paths = append(paths, f.Path())
+	}
+
+	var ignored map[string]struct{}
+
+	if !opt.NoAttributes {
+		if ignored, err = ignoredByAttributes(ctx, dirPath, paths); err != nil {
+			return "", err
+		}
+	}
+
+	var kept = make([]*File, 0, len(files))
+
+	for _, f := range files {
+		var _, byAttr = ignored[f.Path()]
+
+		if (byAttr || matchAny(exclude, f.Path())) && !matchAny(opt.Include, f.Path()) {
+			continue
+		}
+
+		kept = append(kept, f)
+	}
+
+	if len(kept) == len(files) {
+		return diff, nil // nothing was excluded, return the diff as is
+	}
+
+	return FormatDiff(kept), nil
 }
@@ -0,0 +1,189 @@
+package git_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"gh.tarampamp.am/describe-commit/internal/git"
+)
+
+// diffPaths returns the sorted paths of the files in the diff.
+func diffPaths(t *testing.T, diff string) []string {
+	t.Helper()
+
+	files, err := git.ParseDiff(diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var paths = make([]string, 0, len(files))
+
+	for _, f := range files {
+		paths = append(paths, f.Path())
+	}
+
+	slices.Sort(paths)
+
+	return paths
+}
+
+func TestDiff_Sources(t *testing.T) {
+	t.Parallel()
+
+	var repo = newTestRepo(t)
+
+	commitFiles(t, repo, "first", map[string]string{".gitignore": "ignored.txt\n", "tracked.txt": "one\n"})
+	commitFiles(t, repo, "second", map[string]string{"second.txt": "two\n"})
+
+	writeFiles(t, repo, map[string]string{"staged.txt": "staged\n"})
+	runGit(t, repo, "add", "staged.txt")
+	writeFiles(t, repo, map[string]string{
+		"tracked.txt":   "one\nmodified\n",
+		"untracked.txt": "untracked\n",
+		"ignored.txt":   "ignored\n",
+	})
+
+	for name, tc := range map[string]struct {
+		giveOpts []git.DiffOption
+		want     []string
+	}{
+		"default": {
+			want: []string{"staged.txt"},
+		},
+		"staged": {
+			giveOpts: []git.DiffOption{git.WithSource(git.SourceStaged)},
+			want:     []string{"staged.txt"},
+		},
+		"unstaged": {
+			giveOpts: []git.DiffOption{git.WithSource(git.SourceUnstaged)},
+			want:     []string{"tracked.txt"},
+		},
+		"all": {
+			giveOpts: []git.DiffOption{git.WithSource(git.SourceAll)},
+			want:     []string{"staged.txt", "tracked.txt", "untracked.txt"},
+		},
+		"base revision": {
+			giveOpts: []git.DiffOption{git.WithSource("HEAD~1")},
+			want:     []string{"second.txt", "staged.txt", "tracked.txt"},
+		},
+		"revision range": {
+			giveOpts: []git.DiffOption{git.WithSource("HEAD~1..HEAD")},
+			want:     []string{"second.txt"},
+		},
+		"revision overrides the source": {
+			giveOpts: []git.DiffOption{git.WithRevision("HEAD"), git.WithSource(git.SourceAll)},
+			want:     []string{"second.txt"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			diff, err := git.Diff(t.Context(), repo, tc.giveOpts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := diffPaths(t, diff); !slices.Equal(got, tc.want) {
+				t.Errorf("expected the files %v, got %v", tc.want, got)
+			}
+		})
+	}
+
+	t.Run("unknown base revision", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := git.Diff(t.Context(), repo, git.WithSource("unknown")); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestDiff_SourceAll_IndexUntouched(t *testing.T) {
+	t.Parallel()
+
+	var repo = newTestRepo(t)
+
+	commitFiles(t, repo, "first", map[string]string{"tracked.txt": "one\n"})
+	writeFiles(t, repo, map[string]string{"staged.txt": "staged\n"})
+	runGit(t, repo, "add", "staged.txt")
+	writeFiles(t, repo, map[string]string{"untracked.txt": "untracked\n"})
+
+	var readIndex = func() []byte {
+		data, err := os.ReadFile(filepath.Join(repo, ".git", "index"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return data
+	}
+
+	var indexBefore = readIndex()
+
+	diff, err := git.Diff(t.Context(), repo, git.WithSource(git.SourceAll))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := diffPaths(t, diff); !slices.Equal(got, []string{"staged.txt", "untracked.txt"}) {
+		t.Errorf("unexpected files: %v", got)
+	}
+
+	if !slices.Equal(readIndex(), indexBefore) {
+		t.Error("the index must not be changed")
+	}
+
+	if staged := runGit(t, repo, "diff", "--cached", "--name-only"); staged != "staged.txt" {
+		t.Errorf("the staged files must not be changed, got %q", staged)
+	}
+
+	if untracked := runGit(t, repo, "ls-files", "--others", "--exclude-standard"); untracked != "untracked.txt" {
+		t.Errorf("the untracked files must stay untracked, got %q", untracked)
+	}
+}
+
+func TestDiff_SourceAll_NoCommits(t *testing.T) {
+	t.Parallel()
+
+	var repo = newTestRepo(t)
+
+	writeFiles(t, repo, map[string]string{"staged.txt": "staged\n"})
+	runGit(t, repo, "add", "staged.txt")
+	writeFiles(t, repo, map[string]string{"sub/untracked.txt": "untracked\n"})
+
+	// compared to the empty tree, since there is no HEAD commit yet
+	diff, err := git.Diff(t.Context(), repo, git.WithSource(git.SourceAll))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := diffPaths(t, diff); !slices.Equal(got, []string{"staged.txt", "sub/untracked.txt"}) {
+		t.Errorf("unexpected files: %v", got)
+	}
+
+	if staged := runGit(t, repo, "diff", "--cached", "--name-only"); staged != "staged.txt" {
+		t.Errorf("the staged files must not be changed, got %q", staged)
+	}
+}
+
+func TestDiff_SourceAll_NoIndex(t *testing.T) {
+	t.Parallel()
+
+	var repo = newTestRepo(t) // a fresh repository has no index file
+
+	writeFiles(t, repo, map[string]string{"untracked.txt": "untracked\n"})
+
+	diff, err := git.Diff(t.Context(), repo, git.WithSource(git.SourceAll))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := diffPaths(t, diff); !slices.Equal(got, []string{"untracked.txt"}) {
+		t.Errorf("unexpected files: %v", got)
+	}
+
+	if _, err = os.Stat(filepath.Join(repo, ".git", "index")); !os.IsNotExist(err) {
+		t.Errorf("the index must not be created, got %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+package git
+
+import (
+	"context"
+	"errors"
+)
+
+// Editor returns the editor command configured for git. The lookup order is the same as git uses: the
+// `$GIT_EDITOR` environment variable, the `core.editor` configuration option, `$VISUAL`, `$EDITOR`, and
+// finally the compiled-in default (usually `vi`).
+//
+// The returned value is a shell command, which may contain arguments (e.g. `code --wait`).
+func Editor(ctx context.Context, dirPath string) (string, error) {
+	out, err := run(ctx, dirPath, nil, "var", "GIT_EDITOR")
+	if err != nil {
+		return "", err
+	}
+
+	var editor = trimNewline(out)
+
+	if editor == "" {
+		return "", errors.New("no editor configured")
+	}
+
+	return editor, nil
+}
@@ -0,0 +1,87 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// run executes git with the given arguments in the specified directory and returns its standard output.
+//
+// Unlike [Diff] and [Log], the command inherits the current process environment, so the user's global git
+// configuration (core.hooksPath, core.editor, user.name, etc.) is taken into account.
+func run(ctx context.Context, dirPath string, stdIn io.Reader, args ...string) (string, error) {
+	return runWithEnv(ctx, dirPath, nil, stdIn, args...)
+}
+
+// runWithEnv is the same as [run], but with the extra environment variables (they take precedence over the
+// inherited ones).
+func runWithEnv(ctx context.Context, dirPath string, env []string, stdIn io.Reader, args ...string) (string, error) {
+	// ensure git is installed and available to run
+	gitFilePath, lookErr := binPath()
+	if lookErr != nil {
+		return "", lookErr
+	}
+
+	var cmd = exec.CommandContext(ctx, gitFilePath, args...)
+
+	cmd.Dir = dirPath
+	cmd.Env = append(os.Environ(),
+		"LC_ALL=C", "LANG=C", // forces the system to use the "C" (POSIX) locale, English-based output with no localization
+		"NO_COLOR=1", // disables colored output
+	)
+	cmd.Env = append(cmd.Env, env...) // the last value of the duplicated variable wins
+
+	var stdOut, stdErr bytes.Buffer
+
+	cmd.Stdin = stdIn
+	cmd.Stdout = &stdOut
+	cmd.Stderr = &stdErr
+
+	if err := cmd.Run(); err != nil {
+		if stdErr.Len() > 0 {
+			err = fmt.Errorf("%s: %w", stdErrToString(stdErr.String()), err)
+		}
+
+		var name = "git"
+
+		if len(args) > 0 {
+			name += " " + args[0]
+		}
+
+		return "", fmt.Errorf("%s failed: %w", name, err)
+	}
+
+	return stdOut.String(), nil
+}
+
+// repositoryEnv returns the variables locating the repository and its index (GIT_DIR, GIT_WORK_TREE and
+// GIT_INDEX_FILE) from the current process environment, for the commands running with the clean environment
+// (see [Diff] and [Log]). Git sets them for the hooks, e.g. the index is a temporary file during `git commit -a`
+// or `git commit <paths>`. The relative paths are made absolute, since the commands may run in another directory.
+func repositoryEnv() []string {
+	var env []string
+
+	for _, name := range []string{"GIT_DIR", "GIT_WORK_TREE", "GIT_INDEX_FILE"} {
+		var value = os.Getenv(name)
+		if value == "" {
+			continue
+		}
+
+		if abs, err := filepath.Abs(value); err == nil {
+			value = abs
+		}
+
+		env = append(env, name+"="+value)
+	}
+
+	return env
+}
+
+// trimNewline removes the trailing line break(s) from the git output.
+func trimNewline(s string) string { return strings.TrimRight(s, "\r\n") }
@@ -0,0 +1,77 @@
+package git
+
+import (
+	"path"
+	"strings"
+)
+
+// MatchGlob reports whether the file path (relative to the repository root, slash-separated) matches the glob
+// pattern:
+//
+//   - a pattern without slashes is matched against the file name (e.g. "*.lock" matches "web/yarn.lock")
+//   - a pattern with slashes is matched against the whole path, "**" matches any number of directories
+//     (e.g. "api/**/*.pb.go")
+//   - a pattern with the trailing slash matches everything in the directory (e.g. "vendor/" = "vendor/**")
+//
+// The syntax of each path segment is the same as for [path.Match].
+func MatchGlob(pattern, filePath string) bool {
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	if pattern == "" {
+		return false
+	}
+
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(filePath))
+
+		return ok
+	}
+
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(filePath, "/"))
+}
+
+// matchSegments matches the path segments against the pattern segments, where "**" matches zero or more segments.
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			if len(pattern) == 1 {
+				return true
+			}
+
+			for i := range len(name) + 1 {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
+
+// matchAny reports whether the file path matches any of the patterns (see [MatchGlob]).
+func matchAny(patterns []string, filePath string) bool {
+	for _, p := range patterns {
+		if MatchGlob(p, filePath) {
+			return true
+		}
+	}
+
+	return false
+}
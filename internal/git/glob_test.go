@@ -0,0 +1,39 @@
+package git_test
+
+import (
+	"testing"
+
+	"gh.tarampamp.am/describe-commit/internal/git"
+)
+
+func TestMatchGlob(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		givePattern, givePath string
+		want                  bool
+	}{
+		"file name":               {"*.lock", "yarn.lock", true},
+		"file name in subdir":     {"*.lock", "web/app/yarn.lock", true},
+		"file name mismatch":      {"*.lock", "web/lock.go", false},
+		"exact name":              {"go.sum", "tools/go.sum", true},
+		"full path":               {"api/*.pb.go", "api/user.pb.go", true},
+		"full path in subdir":     {"api/*.pb.go", "api/v1/user.pb.go", false},
+		"leading slash":           {"/api/*.pb.go", "api/user.pb.go", true},
+		"double star":             {"api/**/*.pb.go", "api/v1/user.pb.go", true},
+		"double star zero dirs":   {"api/**/*.pb.go", "api/user.pb.go", true},
+		"double star prefix":      {"**/testdata/*", "a/b/testdata/x.json", true},
+		"directory":               {"vendor/", "vendor/github.com/foo/bar.go", true},
+		"directory not in root":   {"vendor/", "internal/vendor/foo.go", false},
+		"directory with wildcard": {"**/vendor/", "internal/vendor/foo.go", true},
+		"empty pattern":           {"", "foo", false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := git.MatchGlob(tc.givePattern, tc.givePath); got != tc.want {
+				t.Errorf("MatchGlob(%q, %q) = %v, want %v", tc.givePattern, tc.givePath, got, tc.want)
+			}
+		})
+	}
+}
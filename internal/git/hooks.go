@@ -0,0 +1,29 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+)
+
+// HooksDir returns the absolute path to the directory where git looks for the hooks. The `core.hooksPath`
+// configuration option is respected, and the `.git/hooks` directory is used otherwise.
+func HooksDir(ctx context.Context, dirPath string) (string, error) {
+	out, err := run(ctx, dirPath, nil, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+
+	var path = trimNewline(out)
+
+	if path == "" {
+		return "", errors.New("git returned an empty hooks path")
+	}
+
+	// the path is relative to the directory where the command was executed
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dirPath, path)
+	}
+
+	return filepath.Clean(path), nil
+}
@@ -5,10 +5,273 @@ import (
 	"context"
 	"fmt"
 	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
-// Log returns the commit log of the repository limited to the specified number of commits.
-func Log(ctx context.Context, dirPath string, len int) (string, error) {
+type (
+	logOptions struct {
+		Before, Range string
+		Bodies        bool     // include the message bodies
+		BodyMaxChars  int      // the message body limit (0 = unlimited)
+		Authors       bool     // include the author names
+		Files         int      // include the names of the changed files, up to the number per commit (0 = none)
+		Paths         []string // prefer the commits touching the paths
+		MaxChars      int      // the overall log limit (0 = unlimited)
+	}
+
+	// LogOption allows to customize the [Log] output.
+	LogOption func(*logOptions)
+)
+
+// WithLogBefore limits the log to the commits preceding the revision (the revision itself is not included).
+func WithLogBefore(rev string) LogOption { return func(o *logOptions) { o.Before = rev } }
+
+// WithLogRange limits the log to the commits of the revision range (e.g. "main..HEAD"). It takes precedence over
+// [WithLogBefore].
+func WithLogRange(rev string) LogOption { return func(o *logOptions) { o.Range = rev } }
+
+// WithLogBodies includes the message bodies (with the trailers, like "Signed-off-by") into the log, each one
+// truncated to the number of characters (0 = unlimited).
+func WithLogBodies(maxChars int) LogOption {
+	return func(o *logOptions) { o.Bodies, o.BodyMaxChars = true, maxChars }
+}
+
+// WithLogAuthors includes the commit author names into the log.
+func WithLogAuthors() LogOption { return func(o *logOptions) { o.Authors = true } }
+
+// WithLogFiles includes the names of the files changed by each commit into the log, up to the number per commit
+// (the rest are counted only).
+func WithLogFiles(maxPerCommit int) LogOption { return func(o *logOptions) { o.Files = maxPerCommit } }
+
+// WithLogPaths makes the log prefer the commits touching the paths (relative to the repository root, taken
+// literally, not as globs): they go first, and the rest of the log is filled with the latest commits.
+func WithLogPaths(paths ...string) LogOption { return func(o *logOptions) { o.Paths = paths } }
+
+// WithLogMaxChars limits the overall size of the log (0 = unlimited). The log is cut at the commit boundary, so
+// the commits that do not fit are omitted entirely.
+func WithLogMaxChars(n int) LogOption { return func(o *logOptions) { o.MaxChars = n } }
+
+// Log returns the commit log of the repository limited to the specified number of commits. By default, only the
+// subjects are returned (one per line); the bodies, authors and changed files are indented below each subject,
+// if requested.
+func Log(ctx context.Context, dirPath string, count int, opts ...LogOption) (string, error) {
+	var opt logOptions
+
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	var (
+		maxCount = count
+		rev      string
+		skip     string // the hash of the commit to omit
+	)
+
+	switch {
+	case opt.Range != "":
+		rev = opt.Range
+	case opt.Before != "":
+		// the log starts with the revision itself, so it's omitted (--skip is not used, since it's applied after the
+		// paths filtering, and the revision may not touch the paths)
+		hash, err := run(ctx, dirPath, nil, "rev-parse", "--verify", "--end-of-options", opt.Before+"^{commit}")
+		if err != nil {
+			return "", fmt.Errorf("unknown revision %q: %w", opt.Before, err)
+		}
+
+		rev, skip, maxCount = opt.Before, trimNewline(hash), count+1
+	}
+
+	var args = []string{"log", "--format=" + logEntryFormat, "--max-count=" + strconv.Itoa(maxCount), "--no-color"}
+
+	if opt.Files > 0 {
+		args = append(args, "--name-only")
+	}
+
+	var revArgs []string
+
+	if rev != "" {
+		revArgs = []string{"--end-of-options", rev}
+	}
+
+	var entries []LogEntry
+
+	if len(opt.Paths) > 0 {
+		// all the files changed by the commit are listed, not only the ones matching the paths (--full-diff); the
+		// paths are relative to the repository root, not to the working directory (the "top" magic)
+		var pathArgs = append(slices.Concat(args, []string{"--full-diff"}, revArgs), "--")
+
+		for _, p := range opt.Paths {
+			pathArgs = append(pathArgs, ":(top,literal)"+p)
+		}
+
+		related, err := logEntries(ctx, dirPath, pathArgs...)
+		if err != nil {
+			return "", err
+		}
+
+		entries = related
+	}
+
+	if len(entries) < maxCount { // fill the rest with the latest commits
+		latest, err := logEntries(ctx, dirPath, slices.Concat(args, revArgs)...)
+		if err != nil {
+			return "", err
+		}
+
+		for _, e := range latest {
+			if !slices.ContainsFunc(entries, func(x LogEntry) bool { return x.Hash == e.Hash }) {
+				entries = append(entries, e)
+			}
+		}
+	}
+
+	var b strings.Builder
+
+	for _, e := range entries {
+		if e.Hash == skip {
+			continue
+		}
+
+		if count--; count < 0 {
+			break
+		}
+
+		var s = formatLogEntry(e, opt)
+
+		if opt.MaxChars > 0 && b.Len()+len(s) > opt.MaxChars {
+			break
+		}
+
+		b.WriteString(s)
+	}
+
+	return b.String(), nil
+}
+
+// formatLogEntry formats the commit for [Log]: the subject line, followed by the requested details indented.
+func formatLogEntry(e LogEntry, opt logOptions) string {
+	const indent = "  "
+
+	var b strings.Builder
+
+	b.WriteString(e.Subject)
+
+	if opt.Authors && e.Author != "" {
+		b.WriteString(" (by " + e.Author + ")")
+	}
+
+	b.WriteRune('\n')
+
+	if body := e.Body; opt.Bodies && body != "" {
+		if opt.BodyMaxChars > 0 {
+			body = truncate(body, opt.BodyMaxChars)
+		}
+
+		for line := range strings.Lines(body) {
+			if line = strings.TrimRight(line, "\r\n"); line != "" {
+				line = indent + line
+			}
+
+			b.WriteString(line + "\n")
+		}
+	}
+
+	if files := e.Files; opt.Files > 0 && len(files) > 0 {
+		var more string
+
+		if len(files) > opt.Files {
+			files, more = files[:opt.Files], fmt.Sprintf(" (and %d more)", len(files)-opt.Files)
+		}
+
+		b.WriteString(indent + "Files: " + strings.Join(files, ", ") + more + "\n")
+	}
+
+	return b.String()
+}
+
+// truncate cuts the string to the number of bytes (at the rune boundary), marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return strings.TrimRight(s[:n], " \t\r\n") + "…"
+}
+
+// LogEntry is the commit read by [LogEntries].
+type LogEntry struct {
+	Hash    string   // the full commit hash
+	Author  string   // the author name
+	Subject string   // the first line of the commit message
+	Body    string   // the rest of the commit message (without the trailing line breaks)
+	Files   []string // the changed files (read by [Log] only, if requested)
+}
+
+// logEntryFormat is the git log format parsed by [logEntries]: the fields are separated by NUL, and the commits
+// are separated by the ASCII record separator (unlikely to be in the messages). The record starts with the
+// separator, so the changed files (if any, they are printed after the formatted message) belong to the record.
+const logEntryFormat = "%x1e%H%x00%an%x00%s%x00%b%x00"
+
+// LogEntries returns the commits of the revision range (e.g. "v1.0.0..v1.1.0", or "HEAD" for the whole history),
+// newest first. The merge commits are skipped, since they do not describe the changes themselves.
+func LogEntries(ctx context.Context, dirPath, rev string) ([]LogEntry, error) {
+	return logEntries(ctx, dirPath, "log",
+		"--format="+logEntryFormat,
+		"--no-merges",
+		"--no-color",
+		"--end-of-options", rev,
+	)
+}
+
+// logEntries runs git log with the arguments (the format must be [logEntryFormat]) and parses its output.
+func logEntries(ctx context.Context, dirPath string, args ...string) ([]LogEntry, error) {
+	out, err := gitLog(ctx, dirPath, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []LogEntry
+
+	for record := range strings.SplitSeq(out, "\x1e") {
+		if record == "" {
+			continue
+		}
+
+		var fields = strings.SplitN(record, "\x00", 5) //nolint:mnd
+
+		if len(fields) != 5 { //nolint:mnd
+			return nil, fmt.Errorf("unexpected git log output: %q", record)
+		}
+
+		var entry = LogEntry{
+			Hash:    fields[0],
+			Author:  fields[1],
+			Subject: fields[2],
+			Body:    strings.TrimRight(fields[3], "\r\n"),
+		}
+
+		for file := range strings.SplitSeq(fields[4], "\n") {
+			if file != "" {
+				entry.Files = append(entry.Files, file)
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// gitLog runs git with the given arguments (without the user's global configuration, unlike [run]) and returns
+// its output.
+func gitLog(ctx context.Context, dirPath string, args ...string) (string, error) {
 	// ensure git is installed and available to run
 	gitFilePath, lookErr := binPath()
 	if lookErr != nil {
@@ -16,18 +279,14 @@ func Log(ctx context.Context, dirPath string, len int) (string, error) {
 	}
 
 	// get the log
-	var cmd = exec.CommandContext(ctx, gitFilePath, "log",
-		"--format=%s",
-		fmt.Sprintf("--max-count=%d", len),
-		"--no-color",
-	)
+	var cmd = exec.CommandContext(ctx, gitFilePath, args...)
 
 	cmd.Dir = dirPath
-	cmd.Env = []string{
+	cmd.Env = append([]string{
 		"LC_ALL=C", "LANG=C", // forces the system to use the "C" (POSIX) locale, English-based output with no localization
 		"NO_COLOR=1",            // disables colored output
 		"GIT_CONFIG_NOSYSTEM=1", // do not use the system-wide configuration file
-	}
+	}, repositoryEnv()...)
 
 	var stdOut, stdErr bytes.Buffer
 
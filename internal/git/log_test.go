@@ -0,0 +1,178 @@
+package git_test
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"gh.tarampamp.am/describe-commit/internal/git"
+)
+
+func TestLog(t *testing.T) {
+	t.Parallel()
+
+	var repo = newTestRepo(t)
+
+	runGit(t, repo, "config", "user.name", "Alice")
+	commitFiles(t, repo, "first\n\nThe body line.\n\nSigned-off-by: Alice <alice@example.com>",
+		map[string]string{"a.txt": "a", "sub/b.txt": "b"},
+	)
+
+	runGit(t, repo, "config", "user.name", "Bob")
+	commitFiles(t, repo, "second", map[string]string{"c.txt": "c"})
+	commitFiles(t, repo, "third", map[string]string{"sub/b.txt": "b2", "c.txt": "c2", "d.txt": "d", "e.txt": "e"})
+	commitFiles(t, repo, "fourth", map[string]string{"d.txt": "d2"})
+
+	for name, tc := range map[string]struct {
+		giveDir   string // relative to the repository root
+		giveCount int
+		giveOpts  []git.LogOption
+		want      string
+	}{
+		"subjects": {
+			giveCount: 10,
+			want:      "fourth\nthird\nsecond\nfirst\n",
+		},
+		"count": {
+			giveCount: 2,
+			want:      "fourth\nthird\n",
+		},
+		"bodies and authors": {
+			giveCount: 10,
+			giveOpts:  []git.LogOption{git.WithLogBodies(0), git.WithLogAuthors()},
+			want: "fourth (by Bob)\nthird (by Bob)\nsecond (by Bob)\nfirst (by Alice)\n" +
+				"  The body line.\n\n  Signed-off-by: Alice <alice@example.com>\n",
+		},
+		"body truncated": {
+			giveCount: 1,
+			giveOpts:  []git.LogOption{git.WithLogBefore("HEAD~2"), git.WithLogBodies(8)},
+			want:      "first\n  The body…\n",
+		},
+		"files": {
+			giveCount: 10,
+			giveOpts:  []git.LogOption{git.WithLogFiles(10)},
+			want: "fourth\n  Files: d.txt\nthird\n  Files: c.txt, d.txt, e.txt, sub/b.txt\n" +
+				"second\n  Files: c.txt\nfirst\n  Files: a.txt, sub/b.txt\n",
+		},
+		"files limited": {
+			giveCount: 1,
+			giveOpts:  []git.LogOption{git.WithLogBefore("HEAD"), git.WithLogFiles(2)},
+			want:      "third\n  Files: c.txt, d.txt (and 2 more)\n",
+		},
+		"before": {
+			giveCount: 10,
+			giveOpts:  []git.LogOption{git.WithLogBefore("HEAD~1")},
+			want:      "second\nfirst\n",
+		},
+		"before the root commit": {
+			giveCount: 10,
+			giveOpts:  []git.LogOption{git.WithLogBefore("HEAD~3")},
+			want:      "",
+		},
+		"range": {
+			giveCount: 10,
+			giveOpts:  []git.LogOption{git.WithLogRange("HEAD~2..HEAD"), git.WithLogBefore("HEAD~1")},
+			want:      "fourth\nthird\n",
+		},
+		"max chars at the commit boundary": {
+			giveCount: 10,
+			giveOpts:  []git.LogOption{git.WithLogMaxChars(len("fourth\nthird\n"))},
+			want:      "fourth\nthird\n",
+		},
+		"max chars cut the commit entirely": {
+			giveCount: 10,
+			giveOpts:  []git.LogOption{git.WithLogMaxChars(len("fourth\nthird\n") - 1)},
+			want:      "fourth\n",
+		},
+		"same paths first": {
+			giveCount: 3,
+			giveOpts:  []git.LogOption{git.WithLogPaths("a.txt")},
+			want:      "first\nfourth\nthird\n",
+		},
+		"same paths with all the files": {
+			giveCount: 1,
+			giveOpts:  []git.LogOption{git.WithLogPaths("a.txt"), git.WithLogFiles(10)},
+			want:      "first\n  Files: a.txt, sub/b.txt\n",
+		},
+		"same paths from the subdirectory": {
+			giveDir:   "sub",
+			giveCount: 3,
+			giveOpts:  []git.LogOption{git.WithLogPaths("sub/b.txt")},
+			want:      "third\nfirst\nfourth\n",
+		},
+		"same paths before the revision": {
+			giveCount: 2,
+			giveOpts:  []git.LogOption{git.WithLogBefore("HEAD~1"), git.WithLogPaths("sub/b.txt")},
+			want:      "first\nsecond\n",
+		},
+		"same paths taken literally": {
+			giveCount: 2,
+			giveOpts:  []git.LogOption{git.WithLogPaths("*.txt")},
+			want:      "fourth\nthird\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := git.Log(t.Context(), filepath.Join(repo, tc.giveDir), tc.giveCount, tc.giveOpts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tc.want {
+				t.Errorf("unexpected log:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("unknown revision", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := git.Log(t.Context(), repo, 10, git.WithLogBefore("unknown")); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestLogEntries(t *testing.T) {
+	t.Parallel()
+
+	var repo = newTestRepo(t)
+
+	var first = commitFiles(t, repo, "first\n\nThe body.\n", map[string]string{"a.txt": "a"})
+
+	runGit(t, repo, "switch", "--quiet", "--create", "feature")
+	commitFiles(t, repo, "feature", map[string]string{"b.txt": "b"})
+	runGit(t, repo, "switch", "--quiet", "main")
+	commitFiles(t, repo, "main", map[string]string{"c.txt": "c"})
+	runGit(t, repo, "merge", "--quiet", "--no-ff", "--message", "Merge branch 'feature'", "feature")
+
+	entries, err := git.LogEntries(t.Context(), repo, "HEAD")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var subjects = make([]string, 0, len(entries))
+
+	for _, e := range entries {
+		subjects = append(subjects, e.Subject)
+
+		if e.Author != "Test" || len(e.Hash) != 40 || e.Files != nil {
+			t.Errorf("unexpected entry: %+v", e)
+		}
+
+		if e.Hash == first && e.Body != "The body." {
+			t.Errorf("unexpected body: %q", e.Body)
+		}
+	}
+
+	slices.Sort(subjects) // the commits of the same second may go in any order
+
+	if want := []string{"feature", "first", "main"}; !slices.Equal(subjects, want) {
+		t.Errorf("expected the subjects %v (merges skipped), got %v", want, subjects)
+	}
+
+	if entries, err = git.LogEntries(t.Context(), repo, "main..feature"); err != nil || len(entries) != 0 {
+		t.Errorf("expected no entries, got %v (%v)", entries, err)
+	}
+}
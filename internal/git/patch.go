@@ -0,0 +1,521 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type (
+	// FileStatus describes what happened to the file.
+	FileStatus string
+
+	// File is a single file in the unified diff.
+	File struct {
+		OldPath, NewPath string     // paths without the "a/" and "b/" prefixes (equal unless renamed or copied)
+		Status           FileStatus // the kind of the change
+		OldMode, NewMode string     // file modes (e.g. "100644"); empty if unknown (e.g. OldMode of the added file)
+		OldHash, NewHash string     // abbreviated blob hashes from the "index" line (if any)
+		Similarity       int        // similarity index (percents) of the renamed or copied file
+		Dissimilarity    int        // dissimilarity index (percents) of the rewritten file
+		Binary           bool       // binary files have no hunks
+		Hunks            []*Hunk
+	}
+
+	// Hunk is a continuous block of changes in the file.
+	Hunk struct {
+		OldStart, OldLines int
+		NewStart, NewLines int
+		Section            string // the text after the range information (usually the enclosing function name)
+		Lines              []Line
+	}
+
+	// LineKind is the first character of the hunk line.
+	LineKind byte
+
+	// Line is a single line of the hunk.
+	Line struct {
+		Kind LineKind
+		Text string // line content without the kind prefix and the line break
+	}
+)
+
+const (
+	FileModified FileStatus = "modified"
+	FileAdded    FileStatus = "added"
+	FileDeleted  FileStatus = "deleted"
+	FileRenamed  FileStatus = "renamed"
+	FileCopied   FileStatus = "copied"
+)
+
+const (
+	LineContext   LineKind = ' '
+	LineAdded     LineKind = '+'
+	LineDeleted   LineKind = '-'
+	LineNoNewline LineKind = '\\' // the "\ No newline at end of file" marker
+)
+
+const devNull = "/dev/null"
+
+// ParseDiff parses the unified diff (the `git diff --patch` output) into the list of files. Any text before
+// the first file header is ignored. The contents of "GIT binary patch" blocks are skipped, only the
+// [File.Binary] flag is set for them.
+func ParseDiff(diff string) ([]*File, error) { //nolint:funlen,gocognit,gocyclo
+	var (
+		files        []*File
+		file         *File
+		hunk         *Hunk
+		oldN, newN   int  // the number of lines the current hunk still expects
+		binaryPatch  bool // inside the "GIT binary patch" block
+		lineNum      int
+		lineErr      = func(msg string) error { return fmt.Errorf("diff line %d: %s", lineNum, msg) }
+		unquoteOrErr = func(s string) (string, error) {
+			if p, err := unquotePath(s); err == nil {
+				return p, nil
+			}
+
+			return "", lineErr(fmt.Sprintf("wrong path %s", s))
+		}
+	)
+
+	for raw := range strings.Lines(diff) {
+		lineNum++
+
+		var line = strings.TrimSuffix(raw, "\n")
+
+		if strings.HasPrefix(line, "diff --git ") {
+			oldPath, newPath, err := parseDiffHeader(strings.TrimPrefix(line, "diff --git "))
+			if err != nil {
+				return nil, lineErr(err.Error())
+			}
+
+			file = &File{OldPath: oldPath, NewPath: newPath, Status: FileModified}
+			hunk, oldN, newN, binaryPatch = nil, 0, 0, false
+			files = append(files, file)
+
+			continue
+		}
+
+		if file == nil || binaryPatch {
+			continue
+		}
+
+		if strings.HasPrefix(line, "@@ ") {
+			h, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, lineErr(err.Error())
+			}
+
+			hunk, oldN, newN = h, h.OldLines, h.NewLines
+			file.Hunks = append(file.Hunks, hunk)
+
+			continue
+		}
+
+		if hunk != nil {
+			var kind = LineContext
+
+			if line != "" {
+				kind = LineKind(line[0])
+			} else if oldN == 0 && newN == 0 {
+				continue // an empty line after the hunk
+			}
+
+			switch kind {
+			case LineContext:
+				oldN, newN = oldN-1, newN-1
+			case LineDeleted:
+				oldN--
+			case LineAdded:
+				newN--
+			case LineNoNewline:
+			default:
+				return nil, lineErr(fmt.Sprintf("unexpected hunk line %q", line))
+			}
+
+			var text string
+			if len(line) > 0 {
+				text = line[1:]
+			}
+
+			hunk.Lines = append(hunk.Lines, Line{Kind: kind, Text: text})
+
+			continue
+		}
+
+		// the extended header lines
+		var key, value, _ = strings.Cut(line, " ")
+
+		switch {
+		case strings.HasPrefix(line, "new file mode "):
+			file.Status, file.NewMode = FileAdded, strings.TrimPrefix(line, "new file mode ")
+		case strings.HasPrefix(line, "deleted file mode "):
+			file.Status, file.OldMode = FileDeleted, strings.TrimPrefix(line, "deleted file mode ")
+		case strings.HasPrefix(line, "old mode "):
+			file.OldMode = strings.TrimPrefix(line, "old mode ")
+		case strings.HasPrefix(line, "new mode "):
+			file.NewMode = strings.TrimPrefix(line, "new mode ")
+		case strings.HasPrefix(line, "similarity index "), strings.HasPrefix(line, "dissimilarity index "):
+			var _, percents, _ = strings.Cut(line, "index ")
+
+			n, err := strconv.Atoi(strings.TrimSuffix(percents, "%"))
+			if err != nil {
+				return nil, lineErr(fmt.Sprintf("wrong similarity index %q", percents))
+			}
+
+			if key == "similarity" {
+				file.Similarity = n
+			} else {
+				file.Dissimilarity = n
+			}
+		case strings.HasPrefix(line, "rename from "), strings.HasPrefix(line, "copy from "):
+			p, err := unquoteOrErr(strings.TrimPrefix(value, "from "))
+			if err != nil {
+				return nil, err
+			}
+
+			file.OldPath, file.Status = p, map[string]FileStatus{"rename": FileRenamed, "copy": FileCopied}[key]
+		case strings.HasPrefix(line, "rename to "), strings.HasPrefix(line, "copy to "):
+			p, err := unquoteOrErr(strings.TrimPrefix(value, "to "))
+			if err != nil {
+				return nil, err
+			}
+
+			file.NewPath = p
+		case key == "index":
+			var hashes, mode, _ = strings.Cut(value, " ")
+
+			file.OldHash, file.NewHash, _ = strings.Cut(hashes, "..")
+
+			if mode != "" {
+				file.OldMode, file.NewMode = mode, mode
+			}
+		case strings.HasPrefix(line, "Binary files "):
+			file.Binary = true
+		case line == "GIT binary patch":
+			file.Binary, binaryPatch = true, true
+		case key == "---" || key == "+++":
+			if value = strings.TrimSuffix(value, "\t"); value == devNull {
+				continue
+			}
+
+			p, err := unquoteOrErr(value)
+			if err != nil {
+				return nil, err
+			}
+
+			if _, p, _ = strings.Cut(p, "/"); key == "---" { // strip the "a/" or "b/" prefix
+				file.OldPath = p
+			} else {
+				file.NewPath = p
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// parseDiffHeader extracts the old and the new paths from the "diff --git" line (without the "diff --git "
+// prefix). The paths may be quoted; unquoted paths with spaces are ambiguous, so the paths are considered
+// equal if possible (the rename and copy headers set the right paths later).
+func parseDiffHeader(s string) (oldPath, newPath string, _ error) {
+	var a, b string
+
+	switch {
+	case strings.HasPrefix(s, `"`):
+		var end = quotedEnd(s)
+		if end == -1 {
+			return "", "", fmt.Errorf("wrong diff header %q", s)
+		}
+
+		a, b = s[:end], strings.TrimPrefix(s[end:], " ")
+	case strings.Contains(s, ` "`):
+		a, b, _ = strings.Cut(s, ` "`)
+		b = `"` + b
+	default:
+		if half := len(s) / 2; half >= 2 && len(s)%2 == 1 && s[half] == ' ' && s[2:half] == s[half+3:] { //nolint:mnd
+			a, b = s[:half], s[half+1:]
+		} else if i := strings.Index(s, " b/"); i != -1 {
+			a, b = s[:i], s[i+1:]
+		} else {
+			return "", "", fmt.Errorf("wrong diff header %q", s)
+		}
+	}
+
+	var err error
+
+	if a, err = unquotePath(a); err != nil {
+		return "", "", err
+	}
+
+	if b, err = unquotePath(b); err != nil {
+		return "", "", err
+	}
+
+	_, a, _ = strings.Cut(a, "/") // strip the "a/" and "b/" prefixes
+	_, b, _ = strings.Cut(b, "/")
+
+	return a, b, nil
+}
+
+// parseHunkHeader parses the "@@ -1,2 +3,4 @@ section" line.
+func parseHunkHeader(line string) (*Hunk, error) {
+	var ranges, section, ok = strings.Cut(strings.TrimPrefix(line, "@@ "), " @@")
+	if !ok {
+		return nil, fmt.Errorf("wrong hunk header %q", line)
+	}
+
+	var oldRange, newRange, _ = strings.Cut(ranges, " ")
+
+	if !strings.HasPrefix(oldRange, "-") || !strings.HasPrefix(newRange, "+") {
+		return nil, fmt.Errorf("wrong hunk header %q", line)
+	}
+
+	var h = Hunk{Section: strings.TrimPrefix(section, " ")}
+
+	var err error
+
+	if h.OldStart, h.OldLines, err = parseRange(oldRange[1:]); err != nil {
+		return nil, fmt.Errorf("wrong hunk header %q: %w", line, err)
+	}
+
+	if h.NewStart, h.NewLines, err = parseRange(newRange[1:]); err != nil {
+		return nil, fmt.Errorf("wrong hunk header %q: %w", line, err)
+	}
+
+	return &h, nil
+}
+
+// parseRange parses the "start,count" (or just "start", when count is 1) hunk range.
+func parseRange(s string) (start, count int, err error) {
+	var startStr, countStr, hasCount = strings.Cut(s, ",")
+
+	if start, err = strconv.Atoi(startStr); err != nil {
+		return 0, 0, err
+	}
+
+	if !hasCount {
+		return start, 1, nil
+	}
+
+	if count, err = strconv.Atoi(countStr); err != nil {
+		return 0, 0, err
+	}
+
+	return start, count, nil
+}
+
+// FormatDiff renders the files back to the unified diff format.
+func FormatDiff(files []*File) string {
+	var b strings.Builder
+
+	for _, f := range files {
+		b.WriteString(f.String())
+	}
+
+	return b.String()
+}
+
+// Path returns the path of the file in the new tree (or the old one, if the file was deleted).
+func (f *File) Path() string {
+	if f.Status == FileDeleted {
+		return f.OldPath
+	}
+
+	return f.NewPath
+}
+
+// Stats returns the number of added and deleted lines.
+func (f *File) Stats() (added, deleted int) {
+	for _, h := range f.Hunks {
+		for _, l := range h.Lines {
+			switch l.Kind { //nolint:exhaustive
+			case LineAdded:
+				added++
+			case LineDeleted:
+				deleted++
+			}
+		}
+	}
+
+	return
+}
+
+// String renders the file back to the unified diff format (the same way git does it).
+func (f *File) String() string { //nolint:funlen,gocyclo
+	var (
+		b           strings.Builder
+		oldName     = quotePath("a/" + f.OldPath)
+		newName     = quotePath("b/" + f.NewPath)
+		oldLabel    = oldName
+		newLabel    = newName
+		sameModes   = f.OldMode != "" && f.OldMode == f.NewMode
+		addOrDelete bool
+	)
+
+	_, _ = fmt.Fprintf(&b, "diff --git %s %s\n", oldName, newName)
+
+	switch {
+	case f.Status == FileAdded:
+		_, _ = fmt.Fprintf(&b, "new file mode %s\n", f.NewMode)
+		oldLabel, addOrDelete = devNull, true
+	case f.Status == FileDeleted:
+		_, _ = fmt.Fprintf(&b, "deleted file mode %s\n", f.OldMode)
+		newLabel, addOrDelete = devNull, true
+	case f.OldMode != f.NewMode && f.OldMode != "" && f.NewMode != "":
+		_, _ = fmt.Fprintf(&b, "old mode %s\nnew mode %s\n", f.OldMode, f.NewMode)
+	}
+
+	if f.Status == FileRenamed || f.Status == FileCopied {
+		var verb = map[FileStatus]string{FileRenamed: "rename", FileCopied: "copy"}[f.Status]
+
+		_, _ = fmt.Fprintf(&b, "similarity index %d%%\n%s from %s\n%s to %s\n",
+			f.Similarity, verb, quotePath(f.OldPath), verb, quotePath(f.NewPath),
+		)
+	} else if f.Dissimilarity > 0 {
+		_, _ = fmt.Fprintf(&b, "dissimilarity index %d%%\n", f.Dissimilarity)
+	}
+
+	if f.OldHash != "" || f.NewHash != "" {
+		_, _ = fmt.Fprintf(&b, "index %s..%s", f.OldHash, f.NewHash)
+
+		if sameModes && !addOrDelete {
+			_, _ = fmt.Fprintf(&b, " %s", f.OldMode)
+		}
+
+		b.WriteRune('\n')
+	}
+
+	if f.Binary {
+		_, _ = fmt.Fprintf(&b, "Binary files %s and %s differ\n", oldLabel, newLabel)
+
+		return b.String()
+	}
+
+	if len(f.Hunks) > 0 {
+		_, _ = fmt.Fprintf(&b, "--- %s%s\n+++ %s%s\n", oldLabel, labelSuffix(oldLabel), newLabel, labelSuffix(newLabel))
+
+		for _, h := range f.Hunks {
+			b.WriteString(h.String())
+		}
+	}
+
+	return b.String()
+}
+
+// labelSuffix returns the tab git appends to the "---" and "+++" file labels that contain spaces.
+func labelSuffix(label string) string {
+	if strings.ContainsRune(label, ' ') {
+		return "\t"
+	}
+
+	return ""
+}
+
+// String renders the hunk back to the unified diff format.
+func (h *Hunk) String() string {
+	var b strings.Builder
+
+	_, _ = fmt.Fprintf(&b, "@@ -%s +%s @@", formatRange(h.OldStart, h.OldLines), formatRange(h.NewStart, h.NewLines))
+
+	if h.Section != "" {
+		b.WriteRune(' ')
+		b.WriteString(h.Section)
+	}
+
+	b.WriteRune('\n')
+
+	for _, l := range h.Lines {
+		b.WriteByte(byte(l.Kind))
+		b.WriteString(l.Text)
+		b.WriteRune('\n')
+	}
+
+	return b.String()
+}
+
+// formatRange formats the hunk range the same way git does it (the count is omitted when it's 1).
+func formatRange(start, count int) string {
+	if count == 1 {
+		return strconv.Itoa(start)
+	}
+
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// quotedEnd returns the index right after the closing quote of the quoted string at the beginning of s, or -1.
+func quotedEnd(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++ // skip the escaped character
+		case '"':
+			return i + 1
+		}
+	}
+
+	return -1
+}
+
+// unquotePath unquotes the path if it's quoted (git quotes paths with "unusual" characters using the C-style
+// escape sequences).
+func unquotePath(s string) (string, error) {
+	if !strings.HasPrefix(s, `"`) {
+		return s, nil
+	}
+
+	return strconv.Unquote(s)
+}
+
+// quotePath quotes the path the same way git does it (when core.quotePath is enabled, the default), if needed.
+func quotePath(s string) string {
+	var needsQuoting bool
+
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < 0x20 || c >= 0x7f || c == '"' || c == '\\' {
+			needsQuoting = true
+
+			break
+		}
+	}
+
+	if !needsQuoting {
+		return s
+	}
+
+	var b strings.Builder
+
+	b.WriteRune('"')
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\a':
+			b.WriteString(`\a`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\v':
+			b.WriteString(`\v`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if c < 0x20 || c >= 0x7f {
+				_, _ = fmt.Fprintf(&b, `\%03o`, c)
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+
+	b.WriteRune('"')
+
+	return b.String()
+}
@@ -0,0 +1,180 @@
+package git_test
+
+import (
+	"testing"
+
+	"gh.tarampamp.am/describe-commit/internal/git"
+)
+
+// testDiff is the real `git diff --cached -M` output (with a deleted, binary, modified, renamed, added (with the
+// quoted name), mode-changed files and a file with a space in the name).
+const testDiff = "" +
+	"diff --git a/gone.txt b/gone.txt\n" +
+	"deleted file mode 100644\n" +
+	"index b023018..0000000\n" +
+	"--- a/gone.txt\n" +
+	"+++ /dev/null\n" +
+	"@@ -1 +0,0 @@\n" +
+	"-bye\n" +
+	"diff --git a/img.bin b/img.bin\n" +
+	"index bdc955b..8835708 100644\n" +
+	"Binary files a/img.bin and b/img.bin differ\n" +
+	"diff --git a/main.go b/main.go\n" +
+	"index 10b6280..dae9889 100644\n" +
+	"--- a/main.go\n" +
+	"+++ b/main.go\n" +
+	"@@ -1,5 +1,5 @@\n" +
+	" package main\n" +
+	" \n" +
+	" func main() {\n" +
+	"-\tprintln(1)\n" +
+	"+\tprintln(2)\n" +
+	" }\n" +
+	"diff --git a/move.txt b/moved.txt\n" +
+	"similarity index 85%\n" +
+	"rename from move.txt\n" +
+	"rename to moved.txt\n" +
+	"index b00a0f1..ac8d4ad 100644\n" +
+	"--- a/move.txt\n" +
+	"+++ b/moved.txt\n" +
+	"@@ -5,4 +5,4 @@ four\n" +
+	" five\n" +
+	" six\n" +
+	" seven\n" +
+	"-eight\n" +
+	"+EIGHT\n" +
+	"diff --git \"a/new \\303\\251.txt\" \"b/new \\303\\251.txt\"\n" +
+	"new file mode 100644\n" +
+	"index 0000000..3e5126c\n" +
+	"--- /dev/null\n" +
+	"+++ \"b/new \\303\\251.txt\"\t\n" +
+	"@@ -0,0 +1 @@\n" +
+	"+new\n" +
+	"\\ No newline at end of file\n" +
+	"diff --git a/run.sh b/run.sh\n" +
+	"old mode 100644\n" +
+	"new mode 100755\n" +
+	"diff --git a/with space.txt b/with space.txt\n" +
+	"index b00a0f1..eb6c752 100644\n" +
+	"--- a/with space.txt\t\n" +
+	"+++ b/with space.txt\t\n" +
+	"@@ -6,3 +6,4 @@ five\n" +
+	" six\n" +
+	" seven\n" +
+	" eight\n" +
+	"+more\n"
+
+func TestParseDiff(t *testing.T) {
+	t.Parallel()
+
+	files, err := git.ParseDiff("some preamble\n" + testDiff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type want struct {
+		oldPath, newPath string
+		status           git.FileStatus
+		oldMode, newMode string
+		similarity       int
+		binary           bool
+		hunks            int
+		added, deleted   int
+	}
+
+	for i, w := range []want{
+		{"gone.txt", "gone.txt", git.FileDeleted, "100644", "", 0, false, 1, 0, 1},
+		{"img.bin", "img.bin", git.FileModified, "100644", "100644", 0, true, 0, 0, 0},
+		{"main.go", "main.go", git.FileModified, "100644", "100644", 0, false, 1, 1, 1},
+		{"move.txt", "moved.txt", git.FileRenamed, "100644", "100644", 85, false, 1, 1, 1},
+		{"new é.txt", "new é.txt", git.FileAdded, "", "100644", 0, false, 1, 1, 0},
+		{"run.sh", "run.sh", git.FileModified, "100644", "100755", 0, false, 0, 0, 0},
+		{"with space.txt", "with space.txt", git.FileModified, "100644", "100644", 0, false, 1, 1, 0},
+	} {
+		if i >= len(files) {
+			t.Fatalf("expected at least %d files, got %d", i+1, len(files))
+		}
+
+		var (
+			f              = files[i]
+			added, deleted = f.Stats()
+			got            = want{
+				f.OldPath, f.NewPath, f.Status, f.OldMode, f.NewMode, f.Similarity, f.Binary, len(f.Hunks), added, deleted,
+			}
+		)
+
+		if got != w {
+			t.Errorf("file #%d: expected %+v, got %+v", i, w, got)
+		}
+	}
+
+	if len(files) != 7 { //nolint:mnd
+		t.Fatalf("expected 7 files, got %d", len(files))
+	}
+
+	if h := files[3].Hunks[0]; h.OldStart != 5 || h.OldLines != 4 || h.NewStart != 5 || h.NewLines != 4 ||
+		h.Section != "four" {
+		t.Errorf("unexpected hunk: %+v", h)
+	}
+
+	if l := files[4].Hunks[0].Lines; len(l) != 2 || l[1].Kind != git.LineNoNewline {
+		t.Errorf("unexpected lines: %+v", l)
+	}
+
+	if got := files[0].Path(); got != "gone.txt" {
+		t.Errorf("unexpected path: %s", got)
+	}
+}
+
+func TestFormatDiff(t *testing.T) {
+	t.Parallel()
+
+	const copied = "diff --git a/a.sh b/b.sh\n" +
+		"old mode 100644\n" +
+		"new mode 100755\n" +
+		"similarity index 100%\n" +
+		"copy from a.sh\n" +
+		"copy to b.sh\n"
+
+	for name, tc := range map[string]struct {
+		giveDiff, wantDiff string
+	}{
+		"git output":            {giveDiff: testDiff, wantDiff: testDiff},
+		"copy with mode change": {giveDiff: copied, wantDiff: copied},
+		"no trailing newline": {
+			giveDiff: "diff --git a/a b/a\nindex 1..2 100644\n--- a/a\n+++ b/a\n@@ -1 +1 @@\n-a\n+b",
+			wantDiff: "diff --git a/a b/a\nindex 1..2 100644\n--- a/a\n+++ b/a\n@@ -1 +1 @@\n-a\n+b\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			files, err := git.ParseDiff(tc.giveDiff)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := git.FormatDiff(files); got != tc.wantDiff {
+				t.Errorf("expected:\n%s\ngot:\n%s", tc.wantDiff, got)
+			}
+		})
+	}
+}
+
+func TestParseDiff_Errors(t *testing.T) {
+	t.Parallel()
+
+	for name, diff := range map[string]string{
+		"wrong hunk header": "diff --git a/a b/a\n--- a/a\n+++ b/a\n@@ -x +1 @@\n-a\n",
+		"wrong hunk line":   "diff --git a/a b/a\n--- a/a\n+++ b/a\n@@ -1 +1 @@\n-a\n+b\n?c\n",
+		"wrong header":      "diff --git foo\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := git.ParseDiff(diff); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Revision is the commit resolved by [Revisions].
+type Revision struct {
+	Hash    string // the full commit hash
+	Subject string // the first line of the commit message
+}
+
+// Short returns the abbreviated commit hash.
+func (r Revision) Short() string {
+	const length = 7
+
+	if len(r.Hash) > length {
+		return r.Hash[:length]
+	}
+
+	return r.Hash
+}
+
+// Revisions resolves the revision (e.g. "HEAD~2") or the revision range (e.g. "HEAD~3..HEAD", "main..feature")
+// to the list of commits, oldest first.
+func Revisions(ctx context.Context, dirPath, rev string) ([]Revision, error) {
+	if strings.TrimSpace(rev) == "" {
+		return nil, errors.New("empty revision")
+	}
+
+	out, err := run(ctx, dirPath, nil, "log",
+		"--no-walk",           // a single revision is not expanded to its history (ignored for the ranges)
+		"--reverse",           // oldest first
+		"--format=%H%x00%s",   // the hash and the subject, separated by the NUL character
+		"--no-show-signature", // the signatures are not needed, even if enabled in the git configuration
+		"--no-color",
+		"--end-of-options", rev,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var revisions []Revision
+
+	for line := range strings.SplitSeq(trimNewline(out), "\n") {
+		if line == "" {
+			continue
+		}
+
+		hash, subject, ok := strings.Cut(line, "\x00")
+		if !ok {
+			return nil, fmt.Errorf("unexpected git log output: %q", line)
+		}
+
+		revisions = append(revisions, Revision{Hash: hash, Subject: subject})
+	}
+
+	if len(revisions) == 0 {
+		return nil, fmt.Errorf("no commits found for %q", rev)
+	}
+
+	return revisions, nil
+}
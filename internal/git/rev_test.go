@@ -0,0 +1,91 @@
+package git_test
+
+import (
+	"slices"
+	"testing"
+
+	"gh.tarampamp.am/describe-commit/internal/git"
+)
+
+func TestRevisions(t *testing.T) {
+	t.Parallel()
+
+	var (
+		repo   = newTestRepo(t)
+		first  = commitFiles(t, repo, "first", map[string]string{"a.txt": "a"})
+		second = commitFiles(t, repo, "second\n\nthe body", map[string]string{"a.txt": "b"})
+		third  = commitFiles(t, repo, "third", map[string]string{"b.txt": "b"})
+	)
+
+	runGit(t, repo, "switch", "--quiet", "--create", "feature")
+
+	var fourth = commitFiles(t, repo, "fourth", map[string]string{"c.txt": "c"})
+
+	runGit(t, repo, "switch", "--quiet", "main")
+
+	for name, tc := range map[string]struct {
+		giveRev  string
+		want     []git.Revision
+		wantHash []string // if only the hashes are checked
+		wantErr  bool
+	}{
+		"HEAD":            {giveRev: "HEAD", want: []git.Revision{{Hash: third, Subject: "third"}}},
+		"single revision": {giveRev: "HEAD~1", want: []git.Revision{{Hash: second, Subject: "second"}}},
+		"root commit":     {giveRev: first, want: []git.Revision{{Hash: first, Subject: "first"}}},
+		"range":           {giveRev: "HEAD~2..HEAD", wantHash: []string{second, third}},
+		"branch":          {giveRev: "feature", wantHash: []string{fourth}}, // not expanded to the history
+		"branch range":    {giveRev: "main..feature", wantHash: []string{fourth}},
+		"symmetric range": {giveRev: first + "...feature", wantHash: []string{second, third, fourth}},
+		"empty range":     {giveRev: "feature..main", wantErr: true},
+		"unknown":         {giveRev: "unknown", wantErr: true},
+		"option":          {giveRev: "--all", wantErr: true},
+		"empty":           {giveRev: " ", wantErr: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			revisions, err := git.Revisions(t.Context(), repo, tc.giveRev)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", revisions)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.want != nil && !slices.Equal(revisions, tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, revisions)
+			}
+
+			if tc.wantHash != nil {
+				var hashes = make([]string, len(revisions))
+
+				for i, rev := range revisions {
+					hashes[i] = rev.Hash
+				}
+
+				if !slices.Equal(hashes, tc.wantHash) {
+					t.Errorf("expected %v (oldest first), got %v", tc.wantHash, hashes)
+				}
+			}
+		})
+	}
+}
+
+func TestRevision_Short(t *testing.T) {
+	t.Parallel()
+
+	for give, want := range map[string]string{
+		"0123456789abcdef": "0123456",
+		"0123456":          "0123456",
+		"0123":             "0123",
+	} {
+		if got := (git.Revision{Hash: give}).Short(); got != want {
+			t.Errorf("Short() of %q = %q, want %q", give, got, want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package git
+
+import "strings"
+
+// SplitDiff splits the unified diff into chunks that are not larger than limit bytes.
+//
+// The diff is split by files first. Files that do not fit into the limit are split by hunks (the file header is
+// repeated for each part), and hunks that still do not fit are split by lines (the hunk header is repeated too).
+// Small parts are packed together, so the number of chunks is as small as possible.
+func SplitDiff(diff string, limit int) []string {
+	if limit <= 0 || len(diff) <= limit {
+		if diff == "" {
+			return nil
+		}
+
+		return []string{diff}
+	}
+
+	var parts []string
+
+	for _, file := range splitBefore(diff, "diff --git ") {
+		if len(file) <= limit {
+			parts = append(parts, file)
+
+			continue
+		}
+
+		var (
+			hunks  = splitBefore(file, "@@ ")
+			header string
+		)
+
+		if len(hunks) > 0 && !strings.HasPrefix(hunks[0], "@@ ") {
+			header, hunks = hunks[0], hunks[1:]
+		}
+
+		if len(hunks) == 0 { // no hunks (e.g. a huge header), nothing to do but to cut it
+			parts = append(parts, cutLines("", file, limit)...)
+
+			continue
+		}
+
+		for _, hunk := range hunks {
+			if len(header)+len(hunk) <= limit {
+				parts = append(parts, header+hunk)
+
+				continue
+			}
+
+			var hunkHeader, body, _ = strings.Cut(hunk, "\n")
+
+			parts = append(parts, cutLines(header+hunkHeader+"\n", body, limit)...)
+		}
+	}
+
+	// pack the small parts together
+	var (
+		chunks  = make([]string, 0, len(parts))
+		current strings.Builder
+	)
+
+	for _, part := range parts {
+		if current.Len() > 0 && current.Len()+len(part) > limit {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+
+		current.WriteString(part)
+	}
+
+	if current.Len() > 0 {
+		chunks = append(chunks, current.String())
+	}
+
+	return chunks
+}
+
+// splitBefore splits the string before each line that starts with the prefix.
+func splitBefore(s, prefix string) []string {
+	var (
+		parts []string
+		start int
+	)
+
+	for i := 0; i < len(s); {
+		var end = strings.IndexByte(s[i:], '\n')
+
+		if end == -1 {
+			end = len(s)
+		} else {
+			end += i + 1
+		}
+
+		if i > start && strings.HasPrefix(s[i:], prefix) {
+			parts, start = append(parts, s[start:i]), i
+		}
+
+		i = end
+	}
+
+	if start < len(s) {
+		parts = append(parts, s[start:])
+	}
+
+	return parts
+}
+
+// cutLines splits the body by lines into parts not larger than limit bytes, each part starts with the prefix.
+// Lines that are too long are cut.
+func cutLines(prefix, body string, limit int) []string {
+	var (
+		parts   []string
+		current strings.Builder
+		space   = max(limit-len(prefix), 1) // available space for the body in each part
+	)
+
+	for line := range strings.SplitAfterSeq(body, "\n") {
+		for len(line) > space { // the line does not fit at all
+			if current.Len() > 0 {
+				parts = append(parts, prefix+current.String())
+				current.Reset()
+			}
+
+			parts, line = append(parts, prefix+line[:space]), line[space:]
+		}
+
+		if current.Len()+len(line) > space {
+			parts = append(parts, prefix+current.String())
+			current.Reset()
+		}
+
+		current.WriteString(line)
+	}
+
+	if current.Len() > 0 {
+		parts = append(parts, prefix+current.String())
+	}
+
+	return parts
+}
@@ -0,0 +1,95 @@
+package git_test
+
+import (
+	"strings"
+	"testing"
+
+	"gh.tarampamp.am/describe-commit/internal/git"
+)
+
+func TestSplitDiff(t *testing.T) {
+	t.Parallel()
+
+	const (
+		file1 = "diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-foo\n+bar\n"
+		file2 = "diff --git a/b.txt b/b.txt\n--- a/b.txt\n+++ b/b.txt\n" +
+			"@@ -1,2 +1,2 @@\n-one\n+two\n three\n" +
+			"@@ -10 +10 @@\n-ten\n+eleven\n"
+	)
+
+	for name, tc := range map[string]struct {
+		giveDiff  string
+		giveLimit int
+		want      []string
+	}{
+		"empty": {
+			giveDiff:  "",
+			giveLimit: 10,
+			want:      nil,
+		},
+		"no limit": {
+			giveDiff:  file1 + file2,
+			giveLimit: 0,
+			want:      []string{file1 + file2},
+		},
+		"fits": {
+			giveDiff:  file1 + file2,
+			giveLimit: len(file1 + file2),
+			want:      []string{file1 + file2},
+		},
+		"by files": {
+			giveDiff:  file1 + file2,
+			giveLimit: len(file2),
+			want:      []string{file1, file2},
+		},
+		"by hunks": {
+			giveDiff:  file1 + file2,
+			giveLimit: len(file2) - 1,
+			want: []string{
+				file1,
+				"diff --git a/b.txt b/b.txt\n--- a/b.txt\n+++ b/b.txt\n@@ -1,2 +1,2 @@\n-one\n+two\n three\n",
+				"diff --git a/b.txt b/b.txt\n--- a/b.txt\n+++ b/b.txt\n@@ -10 +10 @@\n-ten\n+eleven\n",
+			},
+		},
+		"by lines": {
+			giveDiff:  file1,
+			giveLimit: len("diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-foo\n"),
+			want: []string{
+				"diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-foo\n",
+				"diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n+bar\n",
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var got = git.SplitDiff(tc.giveDiff, tc.giveLimit)
+
+			if len(got) != len(tc.want) {
+				t.Fatalf("unexpected number of chunks: %d, want %d (%q)", len(got), len(tc.want), got)
+			}
+
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("chunk %d:\n%q\nwant:\n%q", i, got[i], tc.want[i])
+				}
+
+				if tc.giveLimit > 0 && len(got[i]) > tc.giveLimit {
+					t.Errorf("chunk %d exceeds the limit: %d > %d", i, len(got[i]), tc.giveLimit)
+				}
+			}
+		})
+	}
+
+	t.Run("long lines are cut", func(t *testing.T) {
+		t.Parallel()
+
+		var diff = "diff --git a/a b/a\n@@ -1 +1 @@\n+" + strings.Repeat("x", 100) + "\n"
+
+		for _, chunk := range git.SplitDiff(diff, 40) {
+			if len(chunk) > 40 {
+				t.Errorf("chunk exceeds the limit: %d", len(chunk))
+			}
+		}
+	})
+}
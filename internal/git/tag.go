@@ -0,0 +1,46 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// LatestTag returns the name of the most recent tag reachable from the revision (the revision itself included).
+// An empty string is returned if there are no such tags.
+func LatestTag(ctx context.Context, dirPath, rev string) (string, error) {
+	return describeTag(ctx, dirPath, rev, "--abbrev=0")
+}
+
+// TagAt returns the name of the tag pointing exactly to the revision. An empty string is returned if the
+// revision is not tagged.
+func TagAt(ctx context.Context, dirPath, rev string) (string, error) {
+	return describeTag(ctx, dirPath, rev, "--exact-match")
+}
+
+// describeTag runs `git describe --tags` for the revision in the given mode. The "no tags" errors result in an
+// empty string, while the other ones (e.g. an unknown revision) are returned as is.
+func describeTag(ctx context.Context, dirPath, rev, mode string) (string, error) {
+	// the revision is checked first, since git describe fails in the same way for the unknown revisions and the
+	// revisions without tags
+	if _, err := run(ctx, dirPath, nil, "rev-parse", "--verify", "--end-of-options", rev+"^{commit}"); err != nil {
+		return "", fmt.Errorf("unknown revision %q: %w", rev, err)
+	}
+
+	out, err := run(ctx, dirPath, nil, "describe", "--tags", mode, "--end-of-options", rev)
+	if err != nil {
+		return "", nil //nolint:nilerr // no tags found
+	}
+
+	return strings.TrimSpace(out), nil
+}
+
+// CommitDate returns the commit date of the revision in the YYYY-MM-DD format.
+func CommitDate(ctx context.Context, dirPath, rev string) (string, error) {
+	out, err := run(ctx, dirPath, nil, "log", "--max-count=1", "--format=%cs", "--end-of-options", rev)
+	if err != nil {
+		return "", err
+	}
+
+	return trimNewline(out), nil
+}
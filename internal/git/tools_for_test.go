@@ -0,0 +1,68 @@
+package git_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestRepo creates an empty git repository in a temporary directory and returns its path. The repository is
+// configured to not depend on the user's git configuration (author, signing).
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+
+	var dir = t.TempDir()
+
+	runGit(t, dir, "init", "--quiet", "--initial-branch=main")
+	runGit(t, dir, "config", "user.name", "Test")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	runGit(t, dir, "config", "commit.gpgSign", "false")
+
+	return dir
+}
+
+// runGit runs git with the arguments in the directory and returns its (trimmed) output, failing the test on error.
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	var cmd = exec.CommandContext(t.Context(), "git", args...)
+
+	cmd.Dir = dir
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+// writeFiles writes the files (paths relative to the directory, parent directories are created) with the content.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		var path = filepath.Join(dir, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// commitFiles writes the files, stages them and commits with the message, returning the commit hash.
+func commitFiles(t *testing.T, dir, message string, files map[string]string) string {
+	t.Helper()
+
+	writeFiles(t, dir, files)
+	runGit(t, dir, "add", "--all")
+	runGit(t, dir, "commit", "--quiet", "--allow-empty", "--message", message)
+
+	return runGit(t, dir, "rev-parse", "HEAD")
+}
@@ -2,6 +2,9 @@ package retry
 
 import (
 	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
 	"time"
 )
 
@@ -11,6 +14,11 @@ type Option func(*options)
 type options struct {
 	maxAttempts uint
 	delay       time.Duration
+	backoff     float64
+	maxDelay    time.Duration
+	jitter      bool
+	deadline    time.Duration
+	notify      func(attempt uint, err error, delay time.Duration)
 }
 
 // WithMaxAttempts sets the maximum number of times fn is called. Zero means unlimited retries.
@@ -19,12 +27,46 @@ func WithMaxAttempts(n uint) Option { return func(o *options) { o.maxAttempts =
 // WithDelay sets the pause between consecutive attempts. Zero (the default) means no delay.
 func WithDelay(d time.Duration) Option { return func(o *options) { o.delay = d } }
 
+// WithBackoff enables the exponential backoff: the delay (see [WithDelay]) is multiplied by the factor after
+// each failed attempt (e.g. 1s, 2s, 4s, 8s... for the factor 2). Factors less than or equal to 1 mean the
+// constant delay (the default).
+func WithBackoff(factor float64) Option { return func(o *options) { o.backoff = factor } }
+
+// WithMaxDelay caps the pause between consecutive attempts, including the delay suggested by the error (see
+// [Do]). Zero (the default) means no limit.
+func WithMaxDelay(d time.Duration) Option { return func(o *options) { o.maxDelay = d } }
+
+// WithJitter enables the "full jitter": the pause is a random duration between zero and the computed delay,
+// so that many clients failed at the same time do not retry in lockstep. The delay suggested by the error is
+// not randomized.
+func WithJitter(on bool) Option { return func(o *options) { o.jitter = on } }
+
+// WithDeadline limits the overall time spent on retries (since Do is called): no attempt is started after the
+// deadline, and Do returns the last error without waiting if the next attempt would start after it. The
+// running attempt is not interrupted. Zero (the default) means no deadline.
+func WithDeadline(d time.Duration) Option { return func(o *options) { o.deadline = d } }
+
+// WithNotify sets the function called before waiting for the next attempt with its (zero-based) number,
+// the error of the previous attempt, and the pause.
+func WithNotify(fn func(attempt uint, err error, delay time.Duration)) Option {
+	return func(o *options) { o.notify = fn }
+}
+
+// retryAfterError is implemented by the errors that carry the delay suggested by the server (e.g. from the
+// Retry-After HTTP header).
+type retryAfterError interface {
+	error
+	RetryAfter() time.Duration
+}
+
 // Do calls fn until it returns a nil error or signals stop (first return value is true). When the stop signal
 // is true, Do returns fn's error immediately - even if it is non-nil. If the context is canceled, Do returns
 // ctx.Err() immediately.
 //
 // By default, Do retries indefinitely with no delay between attempts. Use WithMaxAttempts to cap the number of
-// attempts and WithDelay to insert a pause between them.
+// attempts and WithDelay to insert a pause between them (WithBackoff, WithMaxDelay and WithJitter tune it).
+// If the error returned by fn (or any error in its chain) has the "RetryAfter() time.Duration" method returning
+// a positive duration, Do waits for that long instead.
 func Do(
 	ctx context.Context,
 	fn func(ctx context.Context, attempt uint) (bool, error),
@@ -36,7 +78,10 @@ func Do(
 		opt(&o)
 	}
 
-	var lastErr error
+	var (
+		start   = time.Now()
+		lastErr error
+	)
 
 	for attempt := uint(0); o.maxAttempts == 0 || attempt < o.maxAttempts; attempt++ {
 		if err := ctx.Err(); err != nil {
@@ -51,7 +96,17 @@ func Do(
 		}
 
 		if o.maxAttempts == 0 || attempt+1 < o.maxAttempts {
-			if err := waitForNext(ctx, o.delay); err != nil {
+			var delay = o.delayFor(attempt, lastErr)
+
+			if o.deadline > 0 && time.Since(start)+delay >= o.deadline {
+				return lastErr // the next attempt would start after the deadline
+			}
+
+			if o.notify != nil {
+				o.notify(attempt+1, lastErr, delay)
+			}
+
+			if err := waitForNext(ctx, delay); err != nil {
 				return err
 			}
 		}
@@ -60,6 +115,44 @@ func Do(
 	return lastErr
 }
 
+// delayFor returns the pause before the next attempt after the given (zero-based) one has failed with err.
+func (o *options) delayFor(attempt uint, err error) time.Duration {
+	var ra retryAfterError
+
+	if errors.As(err, &ra) { // the server knows better
+		if d := ra.RetryAfter(); d > 0 {
+			return o.capped(float64(d))
+		}
+	}
+
+	var d = float64(o.delay)
+
+	if o.backoff > 1 {
+		d *= math.Pow(o.backoff, float64(attempt))
+	}
+
+	var delay = o.capped(d)
+
+	if o.jitter && delay > 0 {
+		delay = rand.N(delay) //nolint:gosec // no need for the cryptographically secure random here
+	}
+
+	return delay
+}
+
+// capped converts d to the duration, limiting it by the max delay (and the max duration, to avoid overflows).
+func (o *options) capped(d float64) time.Duration {
+	if o.maxDelay > 0 && d > float64(o.maxDelay) {
+		return o.maxDelay
+	}
+
+	if d >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+
+	return time.Duration(d)
+}
+
 // waitForNext pauses for d before the next attempt. With zero delay it only checks whether ctx is already
 // canceled. With a positive delay it blocks until d elapses or ctx is canceled, whichever comes first.
 func waitForNext(ctx context.Context, d time.Duration) error {
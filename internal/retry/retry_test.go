@@ -3,6 +3,7 @@ package retry_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -169,6 +170,99 @@ func TestDo(t *testing.T) {
 	})
 }
 
+func TestDo_Delays(t *testing.T) {
+	t.Parallel()
+
+	errFoo := errors.New("foo")
+
+	for name, tc := range map[string]struct {
+		giveErr  error
+		giveOpts []retry.Option
+
+		wantDelays []time.Duration // nil means the delays are checked by checkDelay
+		checkDelay func(*testing.T, time.Duration)
+	}{
+		"constant": {
+			giveErr:    errFoo,
+			giveOpts:   []retry.Option{retry.WithDelay(time.Millisecond)},
+			wantDelays: []time.Duration{time.Millisecond, time.Millisecond, time.Millisecond},
+		},
+		"exponential backoff with max delay": {
+			giveErr: errFoo,
+			giveOpts: []retry.Option{
+				retry.WithDelay(time.Millisecond), retry.WithBackoff(2), retry.WithMaxDelay(3 * time.Millisecond),
+			},
+			wantDelays: []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond},
+		},
+		"full jitter": {
+			giveErr:  errFoo,
+			giveOpts: []retry.Option{retry.WithDelay(2 * time.Millisecond), retry.WithJitter(true)},
+			checkDelay: func(t *testing.T, d time.Duration) {
+				if d < 0 || d > 2*time.Millisecond {
+					t.Errorf("delay %s is out of [0, 2ms]", d)
+				}
+			},
+		},
+		"suggested by the error": {
+			giveErr:    fmt.Errorf("wrapped: %w", retryAfterError{2 * time.Millisecond}),
+			giveOpts:   []retry.Option{retry.WithDelay(time.Hour), retry.WithJitter(true), retry.WithBackoff(2)},
+			wantDelays: []time.Duration{2 * time.Millisecond, 2 * time.Millisecond, 2 * time.Millisecond},
+		},
+		"suggested by the error is capped": {
+			giveErr:    retryAfterError{time.Hour},
+			giveOpts:   []retry.Option{retry.WithMaxDelay(time.Millisecond)},
+			wantDelays: []time.Duration{time.Millisecond, time.Millisecond, time.Millisecond},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var delays []time.Duration
+
+			err := retry.Do(t.Context(), func(context.Context, uint) (bool, error) { return false, tc.giveErr },
+				append(tc.giveOpts,
+					retry.WithMaxAttempts(4),
+					retry.WithNotify(func(_ uint, _ error, d time.Duration) { delays = append(delays, d) }),
+				)...,
+			)
+
+			assertErrorIs(t, err, tc.giveErr)
+			assertEqual(t, len(delays), 3, "number of delays")
+
+			for i, d := range delays {
+				if tc.checkDelay != nil {
+					tc.checkDelay(t, d)
+				} else {
+					assertEqual(t, d, tc.wantDelays[i], fmt.Sprintf("delay #%d", i))
+				}
+			}
+		})
+	}
+
+	t.Run("deadline", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+
+		err := retry.Do(t.Context(), func(context.Context, uint) (bool, error) {
+			calls++
+
+			return false, errFoo
+		}, retry.WithDelay(20*time.Millisecond), retry.WithDeadline(30*time.Millisecond))
+
+		// the first retry starts at ~20ms, the second one would start after the deadline
+		assertErrorIs(t, err, errFoo)
+		assertEqual(t, calls, 2)
+	})
+}
+
+// retryAfterError is an error with the delay suggested by the "server".
+type retryAfterError struct{ d time.Duration }
+
+func (e retryAfterError) Error() string { return "slow down" }
+
+func (e retryAfterError) RetryAfter() time.Duration { return e.d }
+
 // assertNoError fails the test if err is not nil, indicating an unexpected error occurred.
 func assertNoError(t *testing.T, err error) {
 	t.Helper()
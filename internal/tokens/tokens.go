@@ -0,0 +1,146 @@
+// Package tokens estimates the number of tokens in a text without the model's tokenizer (fully offline).
+//
+// The estimation mimics the pre-tokenization step of the BPE tokenizers (words, numbers, punctuation and
+// whitespace are tokenized separately) and approximates the merges inside each piece. It is not exact, but it
+// is close enough to check the budget before sending the request (the punctuation-heavy text, like diffs, tends to
+// be overestimated, which errs on the safe side).
+package tokens
+
+import (
+	"math"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	wordCharsPerToken   = 8 // the common words (and the camelCase parts) are usually a single token
+	otherCharsPerToken  = 2 // non-Latin alphabets (Cyrillic, Greek, etc.) are split into shorter pieces
+	digitsPerToken      = 3 // the numbers are split into groups of up to 3 digits
+	symbolsPerToken     = 2 // the common punctuation sequences ("()", "->", "==", etc.) are merged
+	tokensPerRareSymbol = 2 // emoji and other rare symbols take several (byte-level) tokens
+)
+
+// ratios adjust the estimation for the tokenizers of the particular providers (the keys are the provider
+// names, see the ai package). The estimation itself is calibrated for the OpenAI tokenizers.
+var ratios = map[string]float64{ //nolint:gochecknoglobals
+	"anthropic": 1.2, // the Claude tokenizer produces noticeably more tokens for the same text
+}
+
+// Estimate returns the approximate number of tokens in the text.
+func Estimate(s string) int {
+	var n int
+
+	for len(s) > 0 {
+		r, _ := utf8.DecodeRuneInString(s)
+
+		var piece string
+
+		switch {
+		case unicode.IsLetter(r):
+			piece, s = cut(s, unicode.IsLetter)
+			n += wordTokens(piece)
+		case unicode.IsDigit(r):
+			piece, s = cut(s, unicode.IsDigit)
+			n += ceilDiv(len(piece), digitsPerToken)
+		case unicode.IsSpace(r):
+			piece, s = cut(s, unicode.IsSpace)
+
+			if piece != " " { // a single space is merged with the following word
+				n++
+			}
+		default:
+			piece, s = cut(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r) })
+			n += symbolTokens(piece)
+		}
+	}
+
+	return n
+}
+
+// EstimateFor returns the approximate number of tokens in the text for the tokenizer of the given provider
+// (the [Estimate] result is used for unknown providers).
+func EstimateFor(provider, s string) int {
+	var n = Estimate(s)
+
+	if ratio, ok := ratios[provider]; ok {
+		return int(math.Ceil(float64(n) * ratio))
+	}
+
+	return n
+}
+
+// cut splits s into the longest prefix of the runes matching fn and the rest.
+func cut(s string, fn func(rune) bool) (prefix, rest string) {
+	for i, r := range s {
+		if !fn(r) {
+			return s[:i], s[i:]
+		}
+	}
+
+	return s, ""
+}
+
+// wordTokens estimates the number of tokens in the sequence of letters. The camelCase words are split into
+// parts, since the identifiers are tokenized by their parts too.
+func wordTokens(word string) (n int) {
+	var (
+		latin, other int  // the number of letters in the current part
+		prevLower    bool // the previous letter is lowercase (for the camelCase splitting)
+	)
+
+	var flush = func() {
+		if latin > 0 {
+			n += ceilDiv(latin, wordCharsPerToken)
+		}
+
+		if other > 0 {
+			n += ceilDiv(other, otherCharsPerToken)
+		}
+
+		latin, other = 0, 0
+	}
+
+	for _, r := range word {
+		switch {
+		case isIdeograph(r): // each ideograph is (at least) a token
+			flush()
+			n++
+		case r < utf8.RuneSelf:
+			if prevLower && unicode.IsUpper(r) {
+				flush()
+			}
+
+			latin++
+		default:
+			other++
+		}
+
+		prevLower = unicode.IsLower(r)
+	}
+
+	flush()
+
+	return n
+}
+
+// symbolTokens estimates the number of tokens in the sequence of punctuation and other symbols.
+func symbolTokens(s string) (n int) {
+	var ascii int
+
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			n += tokensPerRareSymbol
+		}
+	}
+
+	return n + ceilDiv(ascii, symbolsPerToken)
+}
+
+// isIdeograph reports whether the rune belongs to the CJK scripts.
+func isIdeograph(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+func ceilDiv(a, b int) int { return (a + b - 1) / b }
@@ -0,0 +1,56 @@
+package tokens_test
+
+import (
+	"strings"
+	"testing"
+
+	"gh.tarampamp.am/describe-commit/internal/tokens"
+)
+
+func TestEstimate(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		give string
+		want int
+	}{
+		"empty":       {give: "", want: 0},
+		"english":     {give: "The quick brown fox jumps over the lazy dog.", want: 10},
+		"long word":   {give: "internationalization", want: 3},
+		"camel case":  {give: "parseResponse", want: 2},
+		"numbers":     {give: "12345678", want: 3},
+		"punctuation": {give: "if (a != b) {", want: 7},
+		"indentation": {give: "    return nil\n\t}\n", want: 6},
+		"cyrillic":    {give: "Привет, как дела?", want: 9},
+		"cjk":         {give: "日本語のテキスト", want: 8},
+		"emoji":       {give: "🚀 feat: add", want: 5},
+		"diff": {
+			give: "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1,3 +1,4 @@\n+import \"fmt\"\n",
+			want: 47,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tokens.Estimate(tc.give); got != tc.want {
+				t.Errorf("Estimate(%q) = %d, want %d", tc.give, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEstimateFor(t *testing.T) {
+	t.Parallel()
+
+	var text = strings.Repeat("The quick brown fox jumps over the lazy dog.\n", 10) // 110 tokens
+
+	for provider, want := range map[string]int{
+		"openai":    110,
+		"anthropic": 132,
+		"unknown":   110,
+	} {
+		if got := tokens.EstimateFor(provider, text); got != want {
+			t.Errorf("EstimateFor(%q) = %d, want %d", provider, got, want)
+		}
+	}
+}